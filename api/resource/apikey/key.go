@@ -0,0 +1,41 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	// KeyPrefix marks raw keys issued by this service, so they can be told apart from other bearer tokens.
+	KeyPrefix = "songs_"
+
+	keyBytes      = 32
+	displayPrefix = 8
+)
+
+// Generate returns a new random raw key together with its short display prefix.
+// The raw key is only ever shown once; only its hash is persisted.
+func Generate() (raw, prefix string, err error) {
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	secret := base64.RawURLEncoding.EncodeToString(b)
+
+	return KeyPrefix + secret, secret[:displayPrefix], nil
+}
+
+// Hash returns the hex encoded SHA-256 digest of a raw key.
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsKey reports whether the token looks like an API key issued by this service.
+func IsKey(token string) bool {
+	return strings.HasPrefix(token, KeyPrefix)
+}
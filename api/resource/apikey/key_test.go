@@ -0,0 +1,39 @@
+package apikey_test
+
+import (
+	"strings"
+	"testing"
+
+	"songs/api/resource/apikey"
+	testUtil "songs/util/test"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+
+	raw, prefix, err := apikey.Generate()
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, apikey.IsKey(raw))
+	testUtil.Equal(t, len(prefix), 8)
+	testUtil.Equal(t, true, strings.HasPrefix(raw, apikey.KeyPrefix+prefix))
+
+	other, _, err := apikey.Generate()
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, false, raw == other)
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	testUtil.Equal(t, apikey.Hash("songs_secret"), apikey.Hash("songs_secret"))
+	testUtil.Equal(t, len(apikey.Hash("songs_secret")), 64)
+	testUtil.Equal(t, false, apikey.Hash("songs_secret") == apikey.Hash("songs_secreT"))
+}
+
+func TestIsKey(t *testing.T) {
+	t.Parallel()
+
+	testUtil.Equal(t, true, apikey.IsKey("songs_abc"))
+	testUtil.Equal(t, false, apikey.IsKey("eyJhbGciOiJSUzI1NiJ9.e30.sig"))
+	testUtil.Equal(t, false, apikey.IsKey(""))
+}
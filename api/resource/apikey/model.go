@@ -0,0 +1,47 @@
+package apikey
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+type Key struct {
+	ID         uuid.UUID  `gorm:"primarykey" json:"id"`
+	Name       string     `gorm:"column:name" json:"name"`
+	Prefix     string     `gorm:"column:prefix" json:"prefix"`
+	Hash       string     `gorm:"column:key_hash" json:"-"`
+	Scopes     string     `gorm:"column:scopes" json:"scopes"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at" json:"expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+}
+
+func (Key) TableName() string {
+	return "api_keys"
+}
+
+// HasScope reports whether the key grants the given scope.
+// The admin scope implies every other scope.
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range strings.Fields(k.Scopes) {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Expired reports whether the key is past its expiry time at the given moment.
+func (k *Key) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
@@ -0,0 +1,49 @@
+package apikey_test
+
+import (
+	"testing"
+	"time"
+
+	"songs/api/resource/apikey"
+	testUtil "songs/util/test"
+)
+
+func TestKey_HasScope(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		scopes   string
+		scope    string
+		expected bool
+	}{
+		{name: "granted", scopes: "read write", scope: apikey.ScopeWrite, expected: true},
+		{name: "missing", scopes: "read", scope: apikey.ScopeWrite, expected: false},
+		{name: "admin implies all", scopes: "admin", scope: apikey.ScopeWrite, expected: true},
+		{name: "no scopes", scopes: "", scope: apikey.ScopeRead, expected: false},
+		{name: "no partial match", scopes: "readonly", scope: apikey.ScopeRead, expected: false},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			k := &apikey.Key{Scopes: tc.scopes}
+			testUtil.Equal(t, tc.expected, k.HasScope(tc.scope))
+		})
+	}
+}
+
+func TestKey_Expired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	testUtil.Equal(t, false, (&apikey.Key{}).Expired(now))
+	testUtil.Equal(t, true, (&apikey.Key{ExpiresAt: &past}).Expired(now))
+	testUtil.Equal(t, true, (&apikey.Key{ExpiresAt: &now}).Expired(now))
+	testUtil.Equal(t, false, (&apikey.Key{ExpiresAt: &future}).Expired(now))
+}
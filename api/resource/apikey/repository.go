@@ -0,0 +1,80 @@
+package apikey
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid api key")
+	ErrExpiredKey = errors.New("expired api key")
+)
+
+type Repository struct {
+	db     *gorm.DB
+	logger *zerolog.Logger
+}
+
+func NewRepository(db *gorm.DB, l *zerolog.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: l,
+	}
+}
+
+func (r *Repository) List() ([]*Key, error) {
+	var keys []*Key
+	if err := r.db.Order("created_at").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *Repository) Create(key *Key) (*Key, error) {
+	r.logger.Debug().Msgf("Attempting to create a new api key: %s", key.Name)
+
+	if err := r.db.Create(key).Error; err != nil {
+		return nil, err
+	}
+
+	r.logger.Debug().Msgf("Successfully created api key with ID: %s", key.ID)
+	return key, nil
+}
+
+func (r *Repository) Revoke(name string) (int64, error) {
+	r.logger.Debug().Msgf("Attempting to revoke api key: %s", name)
+
+	result := r.db.Model(&Key{}).
+		Where("name = ? AND revoked_at IS NULL", name).
+		Update("revoked_at", time.Now())
+
+	r.logger.Debug().Msgf("Revoked api key: %s, rows affected: %d", name, result.RowsAffected)
+	return result.RowsAffected, result.Error
+}
+
+// Authenticate looks up an active key by its raw value and records its use.
+func (r *Repository) Authenticate(raw string) (*Key, error) {
+	key := &Key{}
+	err := r.db.Where("key_hash = ? AND revoked_at IS NULL", Hash(raw)).First(key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.Expired(now) {
+		return nil, ErrExpiredKey
+	}
+
+	if err := r.db.Model(&Key{}).Where("id = ?", key.ID).Update("last_used_at", now).Error; err != nil {
+		r.logger.Error().Err(err).Str("api_key", key.Name).Msg("Failed to record api key usage")
+	}
+
+	return key, nil
+}
@@ -0,0 +1,94 @@
+package apikey_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"songs/api/resource/apikey"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+var testLogger = zerolog.Nop()
+
+var keyColumns = []string{"id", "name", "prefix", "key_hash", "scopes", "expires_at", "revoked_at"}
+
+func TestRepository_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	id := uuid.New()
+	mock.ExpectQuery(`^SELECT \* FROM "api_keys" WHERE key_hash = \$1 AND revoked_at IS NULL ORDER BY "api_keys"."id" LIMIT \$2`).
+		WithArgs(apikey.Hash("songs_secret"), 1).
+		WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(id, "ci", "secret12", apikey.Hash("songs_secret"), "write", time.Now().Add(time.Hour), nil))
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "api_keys" SET "last_used_at"=\$1 WHERE id = \$2`).
+		WithArgs(sqlmock.AnyArg(), id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	key, err := apikey.NewRepository(db, &testLogger).Authenticate("songs_secret")
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, key.Name, "ci")
+	testUtil.Equal(t, true, key.HasScope(apikey.ScopeWrite))
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_AuthenticateUnknownOrRevoked(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	// Revoked keys are filtered out by the query, so they look the same as unknown ones.
+	mock.ExpectQuery(`^SELECT \* FROM "api_keys" WHERE key_hash = \$1 AND revoked_at IS NULL`).
+		WillReturnRows(sqlmock.NewRows(keyColumns))
+
+	_, err = apikey.NewRepository(db, &testLogger).Authenticate("songs_revoked")
+	if !errors.Is(err, apikey.ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_AuthenticateExpired(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	mock.ExpectQuery(`^SELECT \* FROM "api_keys" WHERE key_hash = \$1 AND revoked_at IS NULL`).
+		WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(uuid.New(), "old", "old12345", apikey.Hash("songs_old"), "read", time.Now().Add(-time.Hour), nil))
+
+	// An expired key is rejected without recording its use.
+	_, err = apikey.NewRepository(db, &testLogger).Authenticate("songs_old")
+	if !errors.Is(err, apikey.ErrExpiredKey) {
+		t.Fatalf("expected ErrExpiredKey, got %v", err)
+	}
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Revoke(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "api_keys" SET "revoked_at"=\$1 WHERE name = \$2 AND revoked_at IS NULL`).
+		WithArgs(sqlmock.AnyArg(), "ci").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rows, err := apikey.NewRepository(db, &testLogger).Revoke("ci")
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, rows, int64(1))
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,70 @@
+package decode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	ctxUtil "songs/util/ctx"
+	validatorUtil "songs/util/validator"
+)
+
+// JSON decodes the request body into v, rejecting fields v does not declare
+// and any trailing data after the JSON value.
+func JSON(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("request body must contain a single JSON object")
+	}
+
+	return nil
+}
+
+// Validated decodes the request body into v and validates it, answering 400 or 422 itself
+// and returning false when it is unusable.
+func Validated(w http.ResponseWriter, r *http.Request, logger *zerolog.Logger, validate *validator.Validate, v interface{}) bool {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	if err := JSON(r, v); err != nil {
+		logger.Debug().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to decode JSON")
+		e.BadRequest(w, r, e.RespJSONDecodeFailure.WithDetail(err.Error()))
+		return false
+	}
+
+	if err := validate.Struct(v); err != nil {
+		params := validatorUtil.ToInvalidParams(err, ctxUtil.Language(r.Context()))
+
+		logger.Debug().Str(l.KeyReqID, reqID).Msgf("Validation errors: %+v", params)
+		e.ValidationErrors(w, r, params)
+		return false
+	}
+
+	return true
+}
+
+// QueryInt reads the integer query parameter name, which must be between min and max,
+// returning def when it is absent.
+func QueryInt(r *http.Request, name string, def, min, max int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < min || n > max {
+		return 0, fmt.Errorf("%s must be between %d and %d", name, min, max)
+	}
+	return n, nil
+}
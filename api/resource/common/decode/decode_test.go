@@ -0,0 +1,88 @@
+package decode_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"songs/api/resource/common/decode"
+	testUtil "songs/util/test"
+	validatorUtil "songs/util/validator"
+)
+
+func TestQueryInt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+		err      bool
+	}{
+		{name: "absent", query: "", expected: 20},
+		{name: "lowest", query: "?top=0", expected: 0},
+		{name: "highest", query: "?top=100", expected: 100},
+		{name: "below", query: "?top=-1", err: true},
+		{name: "above", query: "?top=101", err: true},
+		{name: "not a number", query: "?top=many", err: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest("GET", "/"+tc.query, nil)
+			n, err := decode.QueryInt(r, "top", 20, 0, 100)
+			if tc.err {
+				if err == nil || err.Error() != "top must be between 0 and 100" {
+					t.Fatalf("expected a range error, got %v", err)
+				}
+				return
+			}
+			testUtil.NoError(t, err)
+			testUtil.Equal(t, tc.expected, n)
+		})
+	}
+}
+
+func TestValidated(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Name string `json:"name" form:"required"`
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		ok     bool
+		status int
+	}{
+		{name: "valid", body: `{"name": "Кино"}`, ok: true, status: http.StatusOK},
+		{name: "malformed", body: `{"name":`, status: http.StatusBadRequest},
+		{name: "unknown field", body: `{"name": "Кино", "year": 1982}`, status: http.StatusBadRequest},
+		{name: "invalid", body: `{"name": ""}`, status: http.StatusUnprocessableEntity},
+	}
+
+	logger := zerolog.Nop()
+	validate := validatorUtil.New()
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/", strings.NewReader(tc.body))
+			ok := decode.Validated(w, r, &logger, validate, &request{})
+			testUtil.Equal(t, tc.ok, ok)
+			testUtil.Equal(t, tc.status, w.Code)
+		})
+	}
+}
@@ -1,40 +1,164 @@
 package err
 
 import (
+	"encoding/json"
 	"net/http"
+	"strings"
+
+	ctxUtil "songs/util/ctx"
+	"songs/util/i18n"
+	validatorUtil "songs/util/validator"
+)
+
+const (
+	ContentTypeProblem = "application/problem+json"
+
+	typeURIPrefix = "urn:songs:problem:"
 )
 
 var (
-	RespDBDataInsertFailure = []byte(`{"error": "db data insert failure"}`)
-	RespDBDataAccessFailure = []byte(`{"error": "db data access failure"}`)
-	RespDBDataUpdateFailure = []byte(`{"error": "db data update failure"}`)
-	RespDBDataRemoveFailure = []byte(`{"error": "db data remove failure"}`)
+	RespDBDataInsertFailure = newProblem("db-data-insert-failure", "db data insert failure")
+	RespDBDataAccessFailure = newProblem("db-data-access-failure", "db data access failure")
+	RespDBDataUpdateFailure = newProblem("db-data-update-failure", "db data update failure")
+	RespDBDataRemoveFailure = newProblem("db-data-remove-failure", "db data remove failure")
+
+	RespJSONEncodeFailure  = newProblem("json-encode-failure", "json encode failure")
+	RespJSONDecodeFailure  = newProblem("json-decode-failure", "json decode failure")
+	RespEncodeFailure      = newProblem("encode-failure", "response encode failure")
+	RespSheetRenderFailure = newProblem("sheet-render-failure", "lyric sheet render failure")
 
-	RespJSONEncodeFailure = []byte(`{"error": "json encode failure"}`)
-	RespJSONDecodeFailure = []byte(`{"error": "json decode failure"}`)
+	RespInvalidURLParamID = newProblem("invalid-url-param-id", "invalid url param-id")
+	RespInvalidQuery      = newProblem("invalid-query", "invalid query parameters")
+	RespInvalidLanguage   = newProblem("invalid-language", "invalid language tag")
 
-	RespInvalidURLParamID = []byte(`{"error": "invalid url param-id"}`)
+	RespUnauthorized = newProblem("unauthorized", "missing or invalid credentials")
+	RespKeyExpired   = newProblem("api-key-expired", "api key expired")
+	RespForbidden    = newProblem("forbidden", "insufficient scope")
+	RespAuthFailure  = newProblem("auth-failure", "authentication failure")
+
+	RespTooManyRequests = newProblem("too-many-requests", "too many requests")
+
+	RespValidationFailure = newProblem("validation-failure", "request validation failure")
+
+	RespSongNotFound         = newProblem("song-not-found", "song not found")
+	RespSyncedNotFound       = newProblem("synced-lyrics-not-found", "synced lyrics not found")
+	RespInvalidLRC           = newProblem("invalid-lrc", "invalid lrc document")
+	RespChordsNotFound       = newProblem("chords-not-found", "song has no chords")
+	RespPlaylistNotFound     = newProblem("playlist-not-found", "playlist not found")
+	RespPlaylistItemNotFound = newProblem("playlist-item-not-found", "playlist item not found")
+	RespGenreNotFound        = newProblem("genre-not-found", "genre not found")
+	RespGenreExists          = newProblem("genre-exists", "genre already exists")
+	RespGenreHasChildren     = newProblem("genre-has-subgenres", "genre has subgenres")
+	RespPersonNotFound       = newProblem("person-not-found", "person not found")
+	RespPersonHasCredits     = newProblem("person-has-credits", "person is credited on songs")
+	RespLyricsNotFound       = newProblem("lyrics-not-found", "lyrics not found in this language")
+	RespGroupNotFound        = newProblem("group-not-found", "group not found")
+	RespAliasNotFound        = newProblem("alias-not-found", "alias not found")
+	RespDuplicateSong        = newProblem("duplicate-song", "song likely duplicates an existing one")
+	RespRouteNotFound        = newProblem("route-not-found", "route not found")
+	RespMethodNotAllowed     = newProblem("method-not-allowed", "method not allowed")
+	RespNotAcceptable        = newProblem("not-acceptable", "not acceptable")
 )
 
-type Error struct {
-	Error string `json:"error"`
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type          string         `json:"type"`
+	Title         string         `json:"title"`
+	Status        int            `json:"status"`
+	Detail        string         `json:"detail,omitempty"`
+	Instance      string         `json:"instance,omitempty"`
+	RequestID     string         `json:"request_id,omitempty"`
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
 }
 
-type Errors struct {
-	Errors []string `json:"errors"`
+// InvalidParam is a request field that failed validation.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
 }
 
-func ServerError(w http.ResponseWriter, error []byte) {
-	w.WriteHeader(http.StatusInternalServerError)
-	w.Write(error)
+func newProblem(slug, title string) Problem {
+	return Problem{
+		Type:  typeURIPrefix + slug,
+		Title: title,
+	}
 }
 
-func BadRequest(w http.ResponseWriter, error []byte) {
-	w.WriteHeader(http.StatusBadRequest)
-	w.Write(error)
+// WithDetail returns a copy of the problem carrying an occurrence specific explanation.
+func (p Problem) WithDetail(detail string) Problem {
+	p.Detail = detail
+	return p
 }
 
-func ValidationErrors(w http.ResponseWriter, reps []byte) {
-	w.WriteHeader(http.StatusUnprocessableEntity)
-	w.Write(reps)
+func ServerError(w http.ResponseWriter, r *http.Request, p Problem) {
+	Write(w, r, http.StatusInternalServerError, p)
+}
+
+func BadRequest(w http.ResponseWriter, r *http.Request, p Problem) {
+	Write(w, r, http.StatusBadRequest, p)
+}
+
+func Unauthorized(w http.ResponseWriter, r *http.Request, p Problem) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	Write(w, r, http.StatusUnauthorized, p)
+}
+
+func Forbidden(w http.ResponseWriter, r *http.Request, p Problem) {
+	Write(w, r, http.StatusForbidden, p)
+}
+
+func NotFound(w http.ResponseWriter, r *http.Request, p Problem) {
+	Write(w, r, http.StatusNotFound, p)
+}
+
+func Conflict(w http.ResponseWriter, r *http.Request, p Problem) {
+	Write(w, r, http.StatusConflict, p)
+}
+
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request, p Problem) {
+	Write(w, r, http.StatusMethodNotAllowed, p)
+}
+
+func NotAcceptable(w http.ResponseWriter, r *http.Request, p Problem) {
+	Write(w, r, http.StatusNotAcceptable, p)
+}
+
+func TooManyRequests(w http.ResponseWriter, r *http.Request, p Problem) {
+	Write(w, r, http.StatusTooManyRequests, p)
+}
+
+func ValidationErrors(w http.ResponseWriter, r *http.Request, params []validatorUtil.InvalidParam) {
+	p := RespValidationFailure
+	for _, param := range params {
+		p.InvalidParams = append(p.InvalidParams, InvalidParam(param))
+	}
+
+	Write(w, r, http.StatusUnprocessableEntity, p)
+}
+
+// Invalid answers 422 for one invalid field, with the reason for tag in the request's
+// language.
+func Invalid(w http.ResponseWriter, r *http.Request, field, tag, param string) {
+	lang := ctxUtil.Language(r.Context())
+	ValidationErrors(w, r, []validatorUtil.InvalidParam{{Name: field, Reason: i18n.Validation(lang, tag, field, param)}})
+}
+
+// Write sends the problem with the given status, filling in the request specific members
+// and translating the title into the negotiated language.
+func Write(w http.ResponseWriter, r *http.Request, status int, p Problem) {
+	lang := ctxUtil.Language(r.Context())
+	if lang == "" {
+		lang = i18n.DefaultLanguage
+	}
+
+	p.Title = i18n.Error(lang, strings.TrimPrefix(p.Type, typeURIPrefix), p.Title)
+	p.Status = status
+	p.Instance = r.URL.RequestURI()
+	p.RequestID = ctxUtil.RequestID(r.Context())
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(p)
 }
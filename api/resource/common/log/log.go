@@ -1,3 +1,7 @@
 package log
 
-const KeyReqID = "request_id"
+const (
+	KeyReqID   = "request_id"
+	KeyAPIKey  = "api_key"
+	KeySubject = "subject"
+)
@@ -0,0 +1,286 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v2"
+
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	ctxUtil "songs/util/ctx"
+)
+
+const (
+	MediaJSON     = "application/json"
+	MediaXML      = "application/xml"
+	MediaYAML     = "application/yaml"
+	MediaText     = "text/plain"
+	MediaMarkdown = "text/markdown"
+)
+
+// ErrNotAcceptable is returned by Respond after it has answered 406 Not Acceptable.
+var ErrNotAcceptable = errors.New("no acceptable representation")
+
+// aliases maps media types clients commonly send to the ones offered here.
+var aliases = map[string]string{
+	"text/xml":           MediaXML,
+	"application/x-yaml": MediaYAML,
+	"text/yaml":          MediaYAML,
+	"text/x-yaml":        MediaYAML,
+	"text/x-markdown":    MediaMarkdown,
+}
+
+// PlainTexter is implemented by values that have a text/plain representation.
+type PlainTexter interface {
+	PlainText() string
+}
+
+// Markdowner is implemented by values that have a text/markdown representation.
+type Markdowner interface {
+	Markdown() string
+}
+
+// Offers lists the media types v can be rendered as, JSON first.
+func Offers(v interface{}) []string {
+	offers := []string{MediaJSON, MediaXML, MediaYAML}
+	if _, ok := v.(PlainTexter); ok {
+		offers = append(offers, MediaText)
+	}
+	if _, ok := v.(Markdowner); ok {
+		offers = append(offers, MediaMarkdown)
+	}
+
+	return offers
+}
+
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func (m mediaRange) specificity() int {
+	switch {
+	case m.typ == "*":
+		return 0
+	case m.subtype == "*":
+		return 1
+	}
+	return 2
+}
+
+func (m mediaRange) matches(offer string) bool {
+	typ, subtype, _ := strings.Cut(offer, "/")
+	return (m.typ == "*" || m.typ == typ) && (m.subtype == "*" || m.subtype == subtype)
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if alias, ok := aliases[mt]; ok {
+			mt = alias
+		}
+
+		typ, subtype, ok := strings.Cut(mt, "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+
+	return ranges
+}
+
+// Negotiate picks the offer the Accept header prefers. Each offer takes the quality of the
+// most specific range matching it, and ties go to the earlier offer. It returns "" when
+// nothing is acceptable; an empty header accepts the first offer.
+func Negotiate(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		if len(offers) == 0 {
+			return ""
+		}
+		return offers[0]
+	}
+
+	ranges := parseAccept(accept)
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, m := range ranges {
+			if m.matches(offer) && m.specificity() > specificity {
+				q, specificity = m.q, m.specificity()
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+
+	return best
+}
+
+// Respond writes v with the given status in the representation the request's Accept header
+// prefers. When nothing fits it answers 406 and returns ErrNotAcceptable; when encoding fails
+// it answers 500. Either way the response is complete and the error is only for logging.
+func Respond(w http.ResponseWriter, r *http.Request, status int, v interface{}) error {
+	offers := Offers(v)
+	w.Header().Add("Vary", "Accept")
+
+	media := Negotiate(r.Header.Get("Accept"), offers)
+	if media == "" {
+		e.NotAcceptable(w, r, e.RespNotAcceptable.WithDetail("supported media types: "+strings.Join(offers, ", ")))
+		return ErrNotAcceptable
+	}
+
+	body, err := encode(media, v)
+	if err != nil {
+		e.ServerError(w, r, e.RespEncodeFailure)
+		return err
+	}
+
+	w.Header().Set("Content-Type", media+"; charset=utf-8")
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
+
+// WriteJSON writes v as JSON with the given status, for endpoints that only speak JSON.
+// When encoding fails it answers 500 instead and logs the error.
+func WriteJSON(w http.ResponseWriter, r *http.Request, logger *zerolog.Logger, status int, v interface{}) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(v); err != nil {
+		logger.Error().Str(l.KeyReqID, ctxUtil.RequestID(r.Context())).Err(err).Msg("Failed to encode response")
+		e.ServerError(w, r, e.RespJSONEncodeFailure)
+		return
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write(body.Bytes())
+}
+
+func encode(media string, v interface{}) ([]byte, error) {
+	switch media {
+	case MediaXML:
+		var buf bytes.Buffer
+		buf.WriteString(xml.Header)
+		enc := xml.NewEncoder(&buf)
+		enc.Indent("", "  ")
+		if err := enc.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: rootName(v)}}); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+		return buf.Bytes(), nil
+	case MediaYAML:
+		return yaml.Marshal(v)
+	case MediaText:
+		return []byte(withNewline(v.(PlainTexter).PlainText())), nil
+	case MediaMarkdown:
+		return []byte(withNewline(v.(Markdowner).Markdown())), nil
+	default:
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(v); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+}
+
+// rootName names the XML document element after v's type, so a Song becomes <song>.
+func rootName(v interface{}) string {
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Name() == "" {
+		return "response"
+	}
+
+	return strings.ToLower(t.Name())
+}
+
+func withNewline(s string) string {
+	if strings.HasSuffix(s, "\n") {
+		return s
+	}
+	return s + "\n"
+}
+
+// EscapeMarkdown escapes s so it renders as literal text in Markdown.
+func EscapeMarkdown(s string) string {
+	var b strings.Builder
+	for i, line := range strings.Split(s, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(escapeMarkdownLine(line))
+	}
+	return b.String()
+}
+
+func escapeMarkdownLine(line string) string {
+	var b strings.Builder
+
+	trimmed := strings.TrimLeft(line, " ")
+	indent := line[:len(line)-len(trimmed)]
+	b.WriteString(indent)
+
+	// Characters that only matter at the start of a line: lists, quotes and ordered lists.
+	if strings.HasPrefix(trimmed, "-") || strings.HasPrefix(trimmed, "+") || strings.HasPrefix(trimmed, ">") {
+		b.WriteByte('\\')
+	} else if dot := strings.IndexAny(trimmed, ".)"); dot > 0 && isDigits(trimmed[:dot]) {
+		b.WriteString(trimmed[:dot])
+		b.WriteByte('\\')
+		trimmed = trimmed[dot:]
+	}
+
+	for _, r := range trimmed {
+		if strings.ContainsRune("\\`*_[]<>#|~", r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// MarkdownLines joins lyric lines with hard line breaks, keeping blank lines between stanzas.
+func MarkdownLines(text string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = EscapeMarkdown(strings.TrimRight(line, " "))
+		if line != "" && i+1 < len(lines) && lines[i+1] != "" {
+			lines[i] += "  "
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,149 @@
+package render_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	e "songs/api/resource/common/err"
+	"songs/api/resource/common/render"
+)
+
+type lyric struct {
+	Song string `json:"song" xml:"song" yaml:"song"`
+	Text string `json:"text" xml:"text" yaml:"text"`
+}
+
+func (l lyric) PlainText() string { return l.Text }
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	offers := []string{render.MediaJSON, render.MediaXML, render.MediaYAML, render.MediaText}
+
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", render.MediaJSON},
+		{"*/*", render.MediaJSON},
+		{"application/xml", render.MediaXML},
+		{"text/xml", render.MediaXML},
+		{"application/x-yaml", render.MediaYAML},
+		{"text/*", render.MediaText},
+		{"text/html, application/xml;q=0.9, */*;q=0.8", render.MediaXML},
+		{"application/json;q=0.5, text/plain", render.MediaText},
+		{"*/*;q=0.1, application/json;q=0", render.MediaXML},
+		{"text/html", ""},
+		{"image/png, text/markdown", ""},
+	}
+
+	for _, tt := range tests {
+		if got := render.Negotiate(tt.accept, offers); got != tt.expected {
+			t.Errorf("Negotiate(%q) = %q, want %q", tt.accept, got, tt.expected)
+		}
+	}
+}
+
+func TestRespond(t *testing.T) {
+	t.Parallel()
+
+	v := lyric{Song: "Starlight", Text: "Far away"}
+
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "application/json; charset=utf-8", `{"song":"Starlight","text":"Far away"}` + "\n"},
+		{"application/xml", "application/xml; charset=utf-8", "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<lyric>\n  <song>Starlight</song>\n  <text>Far away</text>\n</lyric>\n"},
+		{"application/yaml", "application/yaml; charset=utf-8", "song: Starlight\ntext: Far away\n"},
+		{"text/plain", "text/plain; charset=utf-8", "Far away\n"},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", tt.accept)
+		w := httptest.NewRecorder()
+
+		if err := render.Respond(w, r, http.StatusOK, v); err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.accept, err)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != tt.contentType {
+			t.Errorf("%q: expected content type %q, got %q", tt.accept, tt.contentType, ct)
+		}
+		if w.Body.String() != tt.body {
+			t.Errorf("%q: unexpected body %q", tt.accept, w.Body.String())
+		}
+		if w.Header().Get("Vary") != "Accept" {
+			t.Errorf("%q: expected Vary: Accept", tt.accept)
+		}
+	}
+}
+
+func TestRespondNotAcceptable(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/markdown")
+	w := httptest.NewRecorder()
+
+	if err := render.Respond(w, r, http.StatusOK, lyric{}); err != render.ErrNotAcceptable {
+		t.Fatalf("expected ErrNotAcceptable, got %v", err)
+	}
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("expected 406, got %d", w.Code)
+	}
+
+	var p e.Problem
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Type != e.RespNotAcceptable.Type || !strings.Contains(p.Detail, render.MediaText) {
+		t.Errorf("unexpected problem %+v", p)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	w := httptest.NewRecorder()
+	render.WriteJSON(w, r, &logger, http.StatusCreated, lyric{Song: "Yesterday"})
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d", w.Code)
+	}
+	if w.Body.String() != `{"song":"Yesterday","text":""}`+"\n" {
+		t.Errorf("unexpected body %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	render.WriteJSON(w, r, &logger, http.StatusOK, func() {})
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500 for an unencodable value, got %d", w.Code)
+	}
+}
+
+func TestEscapeMarkdown(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"# not a heading": `\# not a heading`,
+		"- not a list":    `\- not a list`,
+		"1. not a list":   `1\. not a list`,
+		"*soft* _words_":  `\*soft\* \_words\_`,
+		"plain words":     "plain words",
+	}
+
+	for input, expected := range tests {
+		if got := render.EscapeMarkdown(input); got != expected {
+			t.Errorf("EscapeMarkdown(%q) = %q, want %q", input, got, expected)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package duplicate
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/common/decode"
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/api/resource/common/render"
+	"songs/api/resource/song"
+	ctxUtil "songs/util/ctx"
+)
+
+const (
+	defaultClustersLimit = 100
+	maxClustersLimit     = 1000
+)
+
+type API struct {
+	logger     *zerolog.Logger
+	validator  *validator.Validate
+	repository *Repository
+	songs      *song.Repository
+	minScore   float64
+}
+
+// New returns the duplicates API; minScore is the default least score of a duplicate.
+func New(logger *zerolog.Logger, validator *validator.Validate, db *gorm.DB, minScore float64) *API {
+	return &API{
+		logger:     logger,
+		validator:  validator,
+		repository: NewRepository(db, logger),
+		songs:      song.NewRepository(db, logger),
+		minScore:   minScore,
+	}
+}
+
+// List godoc
+//
+//	@summary		List likely duplicate songs
+//	@description	Cluster the songs that likely duplicate each other, the likeliest first. A score weighs the share of
+//	@description	lyric shingles two songs have in common, estimated by MinHash, with how alike their titles and groups
+//	@description	are across Cyrillic and Latin spellings; 1 means the same.
+//	@tags			duplicates
+//	@produce		json
+//	@param			min_score	query		number	false	"Least score of a duplicate, between 0 and 1 (default is configured)"
+//	@param			limit		query		int		false	"Maximum number of clusters (default is 100, max is 1000)"
+//	@success		200			{array}		song.Cluster
+//	@failure		400			{object}	err.Problem
+//	@failure		500			{object}	err.Problem
+//	@router			/duplicates [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	minScore := a.minScore
+	if v := r.URL.Query().Get("min_score"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			e.BadRequest(w, r, e.RespInvalidQuery.WithDetail("min_score must be greater than 0 and at most 1"))
+			return
+		}
+		minScore = f
+	}
+
+	limit, err := decode.QueryInt(r, "limit", defaultClustersLimit, 1, maxClustersLimit)
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+
+	songs, err := a.songs.ClusterCandidates()
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to read song fingerprints")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	clusters := song.Clusters(songs, minScore)
+	if len(clusters) > limit {
+		clusters = clusters[:limit]
+	}
+	render.WriteJSON(w, r, a.logger, http.StatusOK, clusters)
+}
+
+// Merge godoc
+//
+//	@summary		Merge duplicate songs
+//	@description	Merge songs into the one to keep. Each merged song is kept as a revision of it; its playlist items,
+//	@description	revisions, genres, tags and translations move to it, its credits too if the kept song has none, and
+//	@description	it is deleted.
+//	@tags			duplicates
+//	@accept			json
+//	@produce		json
+//	@param			body	body		MergeRequest	true	"Song to keep and songs to merge into it"
+//	@success		200		{object}	MergeResult
+//	@failure		400		{object}	err.Problem
+//	@failure		401		{object}	err.Problem
+//	@failure		403		{object}	err.Problem
+//	@failure		404		{object}	err.Problem
+//	@failure		422		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/duplicates/merge [post]
+func (a *API) Merge(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	req := &MergeRequest{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	keep, merge := req.ToModel()
+	if len(merge) == 0 {
+		e.Invalid(w, r, "merge", "merge_self", "")
+		return
+	}
+
+	res, err := a.repository.Merge(keep, merge)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		e.NotFound(w, r, e.RespSongNotFound)
+		return
+	}
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to merge songs")
+		e.ServerError(w, r, e.RespDBDataUpdateFailure)
+		return
+	}
+
+	merged := make([]string, len(merge))
+	for i, id := range merge {
+		merged[i] = id.String()
+	}
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", keep.String()).Strs("merged", merged).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Songs merged")
+	render.WriteJSON(w, r, a.logger, http.StatusOK, res)
+}
+
+// Revisions godoc
+//
+//	@summary		List song revisions
+//	@description	List the earlier versions of a song, the latest first, such as the songs merged into it.
+//	@tags			duplicates
+//	@produce		json
+//	@param			id	path		string	true	"Song ID"
+//	@success		200	{array}		Revision
+//	@failure		400	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@router			/{id}/revisions [get]
+func (a *API) Revisions(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
+		return
+	}
+
+	if _, err := a.songs.Read(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			e.NotFound(w, r, e.RespSongNotFound)
+		} else {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to access the song in the database")
+			e.ServerError(w, r, e.RespDBDataAccessFailure)
+		}
+		return
+	}
+
+	revisions, err := a.repository.Revisions(id)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to retrieve song revisions")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	render.WriteJSON(w, r, a.logger, http.StatusOK, revisions)
+}
@@ -0,0 +1,78 @@
+package duplicate
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"songs/api/resource/song"
+)
+
+// Why a revision was recorded.
+const ReasonMerged = "merged"
+
+// Revision is an earlier version of a song, kept when the song it belonged to, SourceID,
+// was merged into SongID.
+type Revision struct {
+	ID          uuid.UUID `gorm:"primarykey" json:"id"`
+	SongID      uuid.UUID `gorm:"column:song_id" json:"song_id"`
+	SourceID    uuid.UUID `gorm:"column:source_id" json:"source_id"`
+	Reason      string    `gorm:"column:reason" json:"reason"`
+	Group       string    `gorm:"column:group_name" json:"group"`
+	Song        string    `gorm:"column:song_name" json:"song"`
+	Text        string    `gorm:"column:text" json:"text"`
+	ReleaseDate string    `gorm:"column:release_date" json:"release_date"`
+	Link        string    `gorm:"column:link" json:"link"`
+	ChordPro    string    `gorm:"column:chordpro" json:"chordpro,omitempty"`
+	Language    string    `gorm:"column:language" json:"language,omitempty"`
+	CreatedAt   time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+func (Revision) TableName() string {
+	return "song_revisions"
+}
+
+type MergeRequest struct {
+	// Keep is the song the others are merged into.
+	Keep  string   `json:"keep" form:"required,uuid"`
+	Merge []string `json:"merge" form:"required,min=1,max=50,dive,uuid"`
+}
+
+// MergeResult is the kept song with the revisions recorded for the songs merged into it.
+type MergeResult struct {
+	Song      *song.Song `json:"song"`
+	Revisions []Revision `json:"revisions"`
+}
+
+// ToModel returns the song to keep and the songs to merge, without repeats or the kept one.
+func (r *MergeRequest) ToModel() (uuid.UUID, []uuid.UUID) {
+	keep := uuid.MustParse(r.Keep)
+
+	seen := map[uuid.UUID]bool{keep: true}
+	var merge []uuid.UUID
+	for _, v := range r.Merge {
+		id := uuid.MustParse(v)
+		if !seen[id] {
+			seen[id] = true
+			merge = append(merge, id)
+		}
+	}
+	return keep, merge
+}
+
+// NewRevision snapshots s as a revision of the song with ID songID.
+func NewRevision(songID uuid.UUID, s *song.Song, reason string) *Revision {
+	return &Revision{
+		ID:          uuid.New(),
+		SongID:      songID,
+		SourceID:    s.ID,
+		Reason:      reason,
+		Group:       s.Group,
+		Song:        s.Song,
+		Text:        s.Text,
+		ReleaseDate: s.ReleaseDate,
+		Link:        s.Link,
+		ChordPro:    s.ChordPro,
+		Language:    s.Language,
+	}
+}
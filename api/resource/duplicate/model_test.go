@@ -0,0 +1,23 @@
+package duplicate_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"songs/api/resource/duplicate"
+	testUtil "songs/util/test"
+)
+
+func TestMergeRequest_ToModel(t *testing.T) {
+	t.Parallel()
+
+	keep, a, b := uuid.New(), uuid.New(), uuid.New()
+	req := &duplicate.MergeRequest{Keep: keep.String(), Merge: []string{a.String(), keep.String(), b.String(), a.String()}}
+
+	gotKeep, merge := req.ToModel()
+	testUtil.Equal(t, gotKeep, keep)
+	testUtil.Equal(t, len(merge), 2)
+	testUtil.Equal(t, merge[0], a)
+	testUtil.Equal(t, merge[1], b)
+}
@@ -0,0 +1,97 @@
+package duplicate
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/song"
+)
+
+// mergeStatements move what a merged song, @from, has to the kept song, @keep. Playlist
+// items and revisions move outright; genres, tags and translations join the kept song's
+// own, and credits are copied only when it has none, so shares still add up.
+var mergeStatements = []string{
+	`UPDATE playlist_items SET song_id = @keep WHERE song_id = @from`,
+	`UPDATE song_revisions SET song_id = @keep WHERE song_id = @from`,
+	`INSERT INTO song_genres (song_id, genre_id)
+	 SELECT @keep, genre_id FROM song_genres WHERE song_id = @from
+	 ON CONFLICT DO NOTHING`,
+	`INSERT INTO song_tags (song_id, tag_id)
+	 SELECT @keep, tag_id FROM song_tags WHERE song_id = @from
+	 ON CONFLICT DO NOTHING`,
+	`INSERT INTO song_credits (song_id, person_id, role, share)
+	 SELECT @keep, person_id, role, share FROM song_credits
+	 WHERE song_id = @from AND NOT EXISTS (SELECT 1 FROM song_credits WHERE song_id = @keep)`,
+	`INSERT INTO song_lyrics (song_id, language, is_original, text, translator)
+	 SELECT @keep, language, FALSE, text, translator FROM song_lyrics
+	 WHERE song_id = @from AND NOT is_original
+	 ON CONFLICT DO NOTHING`,
+	`INSERT INTO song_synced_lyrics (song_id, lrc, updated_at)
+	 SELECT @keep, lrc, updated_at FROM song_synced_lyrics WHERE song_id = @from
+	 ON CONFLICT DO NOTHING`,
+	`DELETE FROM songs WHERE id = @from`,
+}
+
+type Repository struct {
+	db     *gorm.DB
+	logger *zerolog.Logger
+}
+
+func NewRepository(db *gorm.DB, l *zerolog.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: l,
+	}
+}
+
+// Merge merges the songs into the song keep: each is recorded as a revision of keep, its
+// playlist items, genres, tags, credits and translations move to keep, and it is deleted.
+// It returns gorm.ErrRecordNotFound when any of the songs does not exist.
+func (r *Repository) Merge(keep uuid.UUID, merge []uuid.UUID) (*MergeResult, error) {
+	r.logger.Debug().Msgf("Merging songs %v into %s", merge, keep)
+
+	res := &MergeResult{Song: &song.Song{}, Revisions: []Revision{}}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", keep).First(res.Song).Error; err != nil {
+			return err
+		}
+
+		var merged []song.Song
+		if err := tx.Where("id IN ?", merge).Order("id").Find(&merged).Error; err != nil {
+			return err
+		}
+		if len(merged) != len(merge) {
+			return gorm.ErrRecordNotFound
+		}
+
+		for i := range merged {
+			rev := NewRevision(keep, &merged[i], ReasonMerged)
+			if err := tx.Create(rev).Error; err != nil {
+				return err
+			}
+
+			for _, stmt := range mergeStatements {
+				if err := tx.Exec(stmt, sql.Named("keep", keep), sql.Named("from", merged[i].ID)).Error; err != nil {
+					return err
+				}
+			}
+			res.Revisions = append(res.Revisions, *rev)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Revisions lists the revisions of a song, the latest first.
+func (r *Repository) Revisions(songID uuid.UUID) ([]Revision, error) {
+	revisions := []Revision{}
+	err := r.db.Where("song_id = ?", songID).Order("created_at DESC, id").Find(&revisions).Error
+	return revisions, err
+}
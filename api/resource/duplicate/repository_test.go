@@ -0,0 +1,80 @@
+package duplicate_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"songs/api/resource/duplicate"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+var testLogger = zerolog.Nop()
+
+func TestRepository_Merge(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := duplicate.NewRepository(db, &testLogger)
+	keep, from := uuid.New(), uuid.New()
+	columns := []string{"id", "group_name", "song_name", "text", "release_date", "link"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT \* FROM "songs" WHERE id = \$1`).
+		WithArgs(keep, 1).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(keep, "Кино", "Группа крови", "Теплое место", "1988-01-01", "https://example.com/1"))
+	mock.ExpectQuery(`^SELECT \* FROM "songs" WHERE id IN \(\$1\)`).
+		WithArgs(from).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(from, "Kino", "Gruppa krovi", "Теплое место, но", "1988-01-01", "https://example.com/2"))
+	mock.ExpectExec(`^INSERT INTO "song_revisions"`).
+		WithArgs(sqlmock.AnyArg(), keep, from, duplicate.ReasonMerged, "Kino", "Gruppa krovi", "Теплое место, но", "1988-01-01", "https://example.com/2", "", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^UPDATE playlist_items SET song_id = \$1 WHERE song_id = \$2`).
+		WithArgs(keep, from).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`^UPDATE song_revisions`).WithArgs(keep, from).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO song_genres`).WithArgs(keep, from).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^INSERT INTO song_tags`).WithArgs(keep, from).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO song_credits (.+) NOT EXISTS \(SELECT 1 FROM song_credits WHERE song_id = \$3\)`).
+		WithArgs(keep, from, keep).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO song_lyrics (.+) AND NOT is_original`).WithArgs(keep, from).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^INSERT INTO song_synced_lyrics`).WithArgs(keep, from).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^DELETE FROM songs WHERE id = \$1`).WithArgs(from).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	res, err := repo.Merge(keep, []uuid.UUID{from})
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, res.Song.ID, keep)
+	testUtil.Equal(t, len(res.Revisions), 1)
+	testUtil.Equal(t, res.Revisions[0].SourceID, from)
+	testUtil.Equal(t, res.Revisions[0].Song, "Gruppa krovi")
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_MergeMissingSong(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	keep := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT \* FROM "songs" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(keep))
+	mock.ExpectQuery(`^SELECT \* FROM "songs" WHERE id IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err = duplicate.NewRepository(db, &testLogger).Merge(keep, []uuid.UUID{uuid.New()})
+	if err == nil {
+		t.Fatal("expected an error for a missing song")
+	}
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
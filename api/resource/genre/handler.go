@@ -0,0 +1,311 @@
+package genre
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/common/decode"
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/api/resource/common/render"
+	"songs/api/resource/song"
+	ctxUtil "songs/util/ctx"
+)
+
+type API struct {
+	logger     *zerolog.Logger
+	validator  *validator.Validate
+	repository *Repository
+	songs      *song.Repository
+}
+
+func New(logger *zerolog.Logger, validator *validator.Validate, db *gorm.DB) *API {
+	return &API{
+		logger:     logger,
+		validator:  validator,
+		repository: NewRepository(db, logger),
+		songs:      song.NewRepository(db, logger),
+	}
+}
+
+// List godoc
+//
+//	@summary		List genres
+//	@description	List the genre tree with the number of songs in each genre, counting subgenres.
+//	@tags			genres
+//	@produce		json
+//	@success		200	{array}		Node
+//	@failure		500	{object}	err.Problem
+//	@router			/genres [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	genres, err := a.repository.All()
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to retrieve genres")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	counts, err := a.repository.Counts()
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to count songs per genre")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	tree := Tree(genres, counts)
+	if tree == nil {
+		tree = []*Node{}
+	}
+	render.WriteJSON(w, r, a.logger, http.StatusOK, tree)
+}
+
+// Create godoc
+//
+//	@summary		Create genre
+//	@description	Create a genre, optionally inside a parent genre.
+//	@tags			genres
+//	@accept			json
+//	@produce		json
+//	@param			body	body		Request	true	"Genre details"
+//	@success		201		{object}	Genre
+//	@failure		400		{object}	err.Problem
+//	@failure		401		{object}	err.Problem
+//	@failure		403		{object}	err.Problem
+//	@failure		409		{object}	err.Problem
+//	@failure		422		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/genres [post]
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	req := &Request{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	g := req.ToModel()
+	g.ID = uuid.New()
+	if !a.resolveParent(w, r, g, req.Parent) {
+		return
+	}
+
+	if err := a.repository.Create(g); err != nil {
+		a.fail(w, r, err, e.RespDBDataInsertFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("genre", g.Slug).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("New genre created")
+	render.WriteJSON(w, r, a.logger, http.StatusCreated, g)
+}
+
+// Update godoc
+//
+//	@summary		Update genre
+//	@description	Change a genre's slug, name or parent. A genre cannot move inside its own subgenres.
+//	@tags			genres
+//	@accept			json
+//	@param			slug	path	string	true	"Genre slug"
+//	@param			body	body	Request	true	"Genre details"
+//	@success		200
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		409	{object}	err.Problem
+//	@failure		422	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/genres/{slug} [put]
+func (a *API) Update(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+	slug := chi.URLParam(r, "slug")
+
+	req := &Request{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	g := req.ToModel()
+	if !a.resolveParent(w, r, g, req.Parent) {
+		return
+	}
+
+	if err := a.repository.Update(slug, g); err != nil {
+		if errors.Is(err, ErrCycle) {
+			e.Invalid(w, r, "parent", "genre_parent", "")
+			return
+		}
+		a.fail(w, r, err, e.RespDBDataUpdateFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("genre", slug).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Genre updated")
+}
+
+// Delete godoc
+//
+//	@summary		Delete genre
+//	@description	Delete a genre without subgenres. Its songs are kept and lose the genre.
+//	@tags			genres
+//	@param			slug	path	string	true	"Genre slug"
+//	@success		200
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		409	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/genres/{slug} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+	slug := chi.URLParam(r, "slug")
+
+	rows, err := a.repository.Delete(slug)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataRemoveFailure)
+		return
+	}
+	if rows == 0 {
+		e.NotFound(w, r, e.RespGenreNotFound)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("genre", slug).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Genre deleted")
+}
+
+// SongGenres godoc
+//
+//	@summary		List song genres
+//	@description	List the genres assigned to a song.
+//	@tags			genres
+//	@produce		json
+//	@param			id	path		string	true	"Song ID"
+//	@success		200	{array}		Genre
+//	@failure		400	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@router			/{id}/genres [get]
+func (a *API) SongGenres(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.song(w, r)
+	if !ok {
+		return
+	}
+
+	genres, err := a.repository.SongGenres(id)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return
+	}
+
+	render.WriteJSON(w, r, a.logger, http.StatusOK, genres)
+}
+
+// SetSongGenres godoc
+//
+//	@summary		Set song genres
+//	@description	Replace the genres assigned to a song.
+//	@tags			genres
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string		true	"Song ID"
+//	@param			body	body		SongRequest	true	"Genre slugs"
+//	@success		200		{array}		Genre
+//	@failure		400		{object}	err.Problem
+//	@failure		401		{object}	err.Problem
+//	@failure		403		{object}	err.Problem
+//	@failure		404		{object}	err.Problem
+//	@failure		422		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/{id}/genres [put]
+func (a *API) SetSongGenres(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := a.song(w, r)
+	if !ok {
+		return
+	}
+
+	req := &SongRequest{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	genres, err := a.repository.SetSongGenres(id, req.Genres)
+	var unknown *UnknownError
+	if errors.As(err, &unknown) {
+		e.Invalid(w, r, "genres", "genre_exists", strings.Join(unknown.Slugs, ", "))
+		return
+	}
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataUpdateFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Strs("genres", req.Genres).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Song genres set")
+	render.WriteJSON(w, r, a.logger, http.StatusOK, genres)
+}
+
+// resolveParent looks up the parent slug, answering 422 itself when it does not exist.
+func (a *API) resolveParent(w http.ResponseWriter, r *http.Request, g *Genre, slug string) bool {
+	if slug == "" {
+		return true
+	}
+
+	parent, err := a.repository.Read(slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		e.Invalid(w, r, "parent", "genre_exists", slug)
+		return false
+	}
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return false
+	}
+
+	g.ParentID = &parent.ID
+	return true
+}
+
+// song parses the song ID from the URL and checks the song exists.
+func (a *API) song(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
+		return uuid.Nil, false
+	}
+
+	if _, err := a.songs.Read(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			e.NotFound(w, r, e.RespSongNotFound)
+		} else {
+			a.fail(w, r, err, e.RespDBDataAccessFailure)
+		}
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func (a *API) fail(w http.ResponseWriter, r *http.Request, err error, p e.Problem) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		e.NotFound(w, r, e.RespGenreNotFound)
+	case errors.Is(err, ErrExists):
+		e.Conflict(w, r, e.RespGenreExists)
+	case errors.Is(err, ErrHasChildren):
+		e.Conflict(w, r, e.RespGenreHasChildren)
+	default:
+		a.logger.Error().Str(l.KeyReqID, ctxUtil.RequestID(r.Context())).Err(err).Msg("Genre query failed")
+		e.ServerError(w, r, p)
+	}
+}
@@ -0,0 +1,94 @@
+package genre
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+type Genre struct {
+	ID       uuid.UUID  `gorm:"primarykey" json:"-"`
+	Slug     string     `gorm:"column:slug" json:"slug"`
+	Name     string     `gorm:"column:name" json:"name"`
+	ParentID *uuid.UUID `gorm:"column:parent_id" json:"-"`
+}
+
+func (Genre) TableName() string {
+	return "genres"
+}
+
+type SongGenre struct {
+	SongID  uuid.UUID `gorm:"column:song_id;primarykey"`
+	GenreID uuid.UUID `gorm:"column:genre_id;primarykey"`
+}
+
+func (SongGenre) TableName() string {
+	return "song_genres"
+}
+
+// Node is a genre in the genre tree. SongCount counts the songs in the genre or any of its
+// subgenres, each song once.
+type Node struct {
+	Slug      string  `json:"slug"`
+	Name      string  `json:"name"`
+	SongCount int     `json:"song_count"`
+	Children  []*Node `json:"children,omitempty"`
+}
+
+type Request struct {
+	Slug string `json:"slug" form:"required,max=64,slug"`
+	Name string `json:"name" form:"required,max=255"`
+	// Parent is the slug of the genre this one belongs to; top-level genres have none.
+	Parent string `json:"parent" form:"omitempty,max=64,slug"`
+}
+
+type SongRequest struct {
+	Genres []string `json:"genres" form:"max=20,dive,required,max=64,slug"`
+}
+
+func (r *Request) ToModel() *Genre {
+	return &Genre{
+		Slug: r.Slug,
+		Name: r.Name,
+	}
+}
+
+// Tree arranges the genres under their parents, each level sorted by name.
+func Tree(genres []Genre, counts map[uuid.UUID]int) []*Node {
+	nodes := make(map[uuid.UUID]*Node, len(genres))
+	for _, g := range genres {
+		nodes[g.ID] = &Node{Slug: g.Slug, Name: g.Name, SongCount: counts[g.ID]}
+	}
+
+	var roots []*Node
+	for _, g := range genres {
+		node := nodes[g.ID]
+		if parent, ok := nodes[parentID(g)]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+
+	sortNodes(roots)
+	return roots
+}
+
+func parentID(g Genre) uuid.UUID {
+	if g.ParentID == nil {
+		return uuid.Nil
+	}
+	return *g.ParentID
+}
+
+func sortNodes(nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Name != nodes[j].Name {
+			return nodes[i].Name < nodes[j].Name
+		}
+		return nodes[i].Slug < nodes[j].Slug
+	})
+	for _, n := range nodes {
+		sortNodes(n.Children)
+	}
+}
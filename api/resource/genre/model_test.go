@@ -0,0 +1,35 @@
+package genre_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"songs/api/resource/genre"
+	testUtil "songs/util/test"
+)
+
+func TestTree(t *testing.T) {
+	t.Parallel()
+
+	rock, punk, postPunk, jazz := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	genres := []genre.Genre{
+		{ID: postPunk, Slug: "post-punk", Name: "Post-punk", ParentID: &punk},
+		{ID: rock, Slug: "rock", Name: "Rock"},
+		{ID: jazz, Slug: "jazz", Name: "Jazz"},
+		{ID: punk, Slug: "punk", Name: "Punk", ParentID: &rock},
+	}
+	counts := map[uuid.UUID]int{rock: 5, punk: 3, postPunk: 2}
+
+	tree := genre.Tree(genres, counts)
+
+	testUtil.Equal(t, 2, len(tree))
+	testUtil.Equal(t, "jazz", tree[0].Slug)
+	testUtil.Equal(t, 0, tree[0].SongCount)
+	testUtil.Equal(t, "rock", tree[1].Slug)
+	testUtil.Equal(t, 5, tree[1].SongCount)
+	testUtil.Equal(t, 1, len(tree[1].Children))
+	testUtil.Equal(t, "punk", tree[1].Children[0].Slug)
+	testUtil.Equal(t, "post-punk", tree[1].Children[0].Children[0].Slug)
+	testUtil.Equal(t, 2, tree[1].Children[0].Children[0].SongCount)
+}
@@ -0,0 +1,238 @@
+package genre
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrExists      = errors.New("genre already exists")
+	ErrHasChildren = errors.New("genre has subgenres")
+	ErrCycle       = errors.New("genre cannot be a subgenre of itself")
+)
+
+// UnknownError lists genre slugs that do not exist.
+type UnknownError struct {
+	Slugs []string
+}
+
+func (e *UnknownError) Error() string {
+	return "unknown genres"
+}
+
+type Repository struct {
+	db     *gorm.DB
+	logger *zerolog.Logger
+}
+
+func NewRepository(db *gorm.DB, l *zerolog.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: l,
+	}
+}
+
+func (r *Repository) All() ([]Genre, error) {
+	var genres []Genre
+	if err := r.db.Order("name").Find(&genres).Error; err != nil {
+		return nil, err
+	}
+
+	return genres, nil
+}
+
+// Counts returns, per genre, how many songs are in the genre or any of its subgenres.
+func (r *Repository) Counts() (map[uuid.UUID]int, error) {
+	var rows []struct {
+		GenreID uuid.UUID
+		Count   int
+	}
+
+	err := r.db.Raw(`WITH RECURSIVE tree AS (
+			SELECT id AS ancestor, id AS genre FROM genres
+			UNION
+			SELECT tree.ancestor, g.id FROM tree JOIN genres AS g ON g.parent_id = tree.genre
+		)
+		SELECT tree.ancestor AS genre_id, COUNT(DISTINCT sg.song_id) AS count
+		FROM tree JOIN song_genres AS sg ON sg.genre_id = tree.genre
+		GROUP BY tree.ancestor`).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		counts[row.GenreID] = row.Count
+	}
+
+	return counts, nil
+}
+
+func (r *Repository) Read(slug string) (*Genre, error) {
+	g := &Genre{}
+	if err := r.db.Where("slug = ?", slug).First(g).Error; err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+func (r *Repository) Create(g *Genre) error {
+	r.logger.Debug().Msgf("Attempting to create a new genre: %+v", g)
+
+	if taken, err := slugTaken(r.db, g.Slug, uuid.Nil); err != nil || taken {
+		return errOrExists(err)
+	}
+
+	return r.db.Create(g).Error
+}
+
+// Update replaces the genre's slug, name and parent. It returns gorm.ErrRecordNotFound
+// when no genre has the given slug, and ErrCycle when the parent is the genre itself or
+// one of its subgenres.
+func (r *Repository) Update(slug string, g *Genre) error {
+	r.logger.Debug().Msgf("Attempting to update genre %s: %+v", slug, g)
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock every genre, so a concurrent update cannot move the new parent under
+		// this genre between the check and the update.
+		var ids []uuid.UUID
+		if err := tx.Model(&Genre{}).Clauses(clause.Locking{Strength: "UPDATE"}).Order("id").Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+
+		current := &Genre{}
+		if err := tx.Where("slug = ?", slug).First(current).Error; err != nil {
+			return err
+		}
+		g.ID = current.ID
+
+		if taken, err := slugTaken(tx, g.Slug, g.ID); err != nil || taken {
+			return errOrExists(err)
+		}
+		if g.ParentID != nil {
+			inside, err := inSubtree(tx, g.ID, *g.ParentID)
+			if err != nil {
+				return err
+			}
+			if inside {
+				return ErrCycle
+			}
+		}
+
+		return tx.Model(&Genre{}).
+			Select("Slug", "Name", "ParentID").
+			Where("id = ?", g.ID).
+			Updates(g).Error
+	})
+}
+
+// Delete removes a genre that has no subgenres; its songs lose the genre.
+func (r *Repository) Delete(slug string) (int64, error) {
+	r.logger.Debug().Msgf("Attempting to delete genre: %s", slug)
+
+	var children int64
+	if err := r.db.Model(&Genre{}).Where("parent_id = (SELECT id FROM genres WHERE slug = ?)", slug).Count(&children).Error; err != nil {
+		return 0, err
+	}
+	if children > 0 {
+		return 0, ErrHasChildren
+	}
+
+	result := r.db.Where("slug = ?", slug).Delete(&Genre{})
+	return result.RowsAffected, result.Error
+}
+
+// inSubtree reports whether candidate is the genre root or one of its subgenres.
+func inSubtree(db *gorm.DB, root, candidate uuid.UUID) (bool, error) {
+	var found int64
+	err := db.Raw(`WITH RECURSIVE sub AS (
+			SELECT id FROM genres WHERE id = ?
+			UNION SELECT g.id FROM genres AS g JOIN sub ON g.parent_id = sub.id
+		)
+		SELECT COUNT(*) FROM sub WHERE id = ?`, root, candidate).Scan(&found).Error
+
+	return found > 0, err
+}
+
+// SongGenres returns the genres assigned to the song, sorted by name.
+func (r *Repository) SongGenres(songID uuid.UUID) ([]Genre, error) {
+	genres := []Genre{}
+	err := r.db.Joins("JOIN song_genres AS sg ON sg.genre_id = genres.id").
+		Where("sg.song_id = ?", songID).
+		Order("genres.name").
+		Find(&genres).Error
+
+	return genres, err
+}
+
+// SetSongGenres replaces the song's genres. It returns an *UnknownError when any slug
+// does not exist.
+func (r *Repository) SetSongGenres(songID uuid.UUID, slugs []string) ([]Genre, error) {
+	genres := []Genre{}
+	if len(slugs) > 0 {
+		if err := r.db.Where("slug IN ?", slugs).Order("name").Find(&genres).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if missing := missingSlugs(slugs, genres); len(missing) > 0 {
+		return nil, &UnknownError{Slugs: missing}
+	}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("song_id = ?", songID).Delete(&SongGenre{}).Error; err != nil {
+			return err
+		}
+		if len(genres) == 0 {
+			return nil
+		}
+
+		rows := make([]SongGenre, len(genres))
+		for i, g := range genres {
+			rows[i] = SongGenre{SongID: songID, GenreID: g.ID}
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return genres, nil
+}
+
+func slugTaken(db *gorm.DB, slug string, except uuid.UUID) (bool, error) {
+	var count int64
+	err := db.Model(&Genre{}).Where("slug = ? AND id <> ?", slug, except).Count(&count).Error
+	return count > 0, err
+}
+
+func errOrExists(err error) error {
+	if err != nil {
+		return err
+	}
+	return ErrExists
+}
+
+func missingSlugs(slugs []string, genres []Genre) []string {
+	found := make(map[string]bool, len(genres))
+	for _, g := range genres {
+		found[g.Slug] = true
+	}
+
+	var missing []string
+	for _, s := range slugs {
+		if !found[s] {
+			missing = append(missing, s)
+			found[s] = true
+		}
+	}
+
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,72 @@
+package genre_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"songs/api/resource/genre"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+var testLogger = zerolog.Nop()
+
+func TestRepository_Update(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := genre.NewRepository(db, &testLogger)
+
+	id, parent := uuid.New(), uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT \"id\" FROM \"genres\" ORDER BY id FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id).AddRow(parent))
+	mock.ExpectQuery("^SELECT (.+) FROM \"genres\" WHERE slug = (.+)").
+		WithArgs("indie", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}).AddRow(id, "indie"))
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM \"genres\" WHERE slug = (.+) AND id <> (.+)").
+		WithArgs("indie-rock", id).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("^WITH RECURSIVE sub AS (.+) SELECT COUNT\\(\\*\\) FROM sub WHERE id = (.+)").
+		WithArgs(id, parent).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec("^UPDATE \"genres\" SET \"slug\"=(.+),\"name\"=(.+),\"parent_id\"=(.+) WHERE id = (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.Update("indie", &genre.Genre{Slug: "indie-rock", Name: "Indie rock", ParentID: &parent})
+	testUtil.NoError(t, err)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Update_Cycle(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := genre.NewRepository(db, &testLogger)
+
+	id, child := uuid.New(), uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT \"id\" FROM \"genres\" ORDER BY id FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id).AddRow(child))
+	mock.ExpectQuery("^SELECT (.+) FROM \"genres\" WHERE slug = (.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug"}).AddRow(id, "rock"))
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM \"genres\"").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("^WITH RECURSIVE sub AS").
+		WithArgs(id, child).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	err = repo.Update("rock", &genre.Genre{Slug: "rock", Name: "Rock", ParentID: &child})
+	testUtil.Equal(t, true, errors.Is(err, genre.ErrCycle))
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,218 @@
+package group
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/common/decode"
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/api/resource/common/render"
+	"songs/pkg/translit"
+	ctxUtil "songs/util/ctx"
+)
+
+const (
+	defaultMinSimilarity = 0.8
+
+	defaultDuplicatesLimit = 100
+	maxDuplicatesLimit     = 1000
+)
+
+type API struct {
+	logger     *zerolog.Logger
+	validator  *validator.Validate
+	repository *Repository
+}
+
+func New(logger *zerolog.Logger, validator *validator.Validate, db *gorm.DB) *API {
+	return &API{
+		logger:     logger,
+		validator:  validator,
+		repository: NewRepository(db, logger),
+	}
+}
+
+// Aliases godoc
+//
+//	@summary		List group aliases
+//	@description	List the alternate group names and the groups they stand for.
+//	@tags			groups
+//	@produce		json
+//	@param			group	query		string	false	"Only the aliases of this group"
+//	@success		200		{array}		Alias
+//	@failure		500		{object}	err.Problem
+//	@router			/groups/aliases [get]
+func (a *API) Aliases(w http.ResponseWriter, r *http.Request) {
+	aliases, err := a.repository.Aliases(r.URL.Query().Get("group"))
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return
+	}
+
+	render.WriteJSON(w, r, a.logger, http.StatusOK, aliases)
+}
+
+// SetAlias godoc
+//
+//	@summary		Set group alias
+//	@description	Make a name stand for a group in lookups and the group filter. Spellings with the same search key
+//	@description	share the alias. A group that is itself an alias resolves to the group it stands for.
+//	@tags			groups
+//	@accept			json
+//	@produce		json
+//	@param			body	body		AliasRequest	true	"Alias and group"
+//	@success		200		{object}	Alias
+//	@failure		400		{object}	err.Problem
+//	@failure		401		{object}	err.Problem
+//	@failure		403		{object}	err.Problem
+//	@failure		422		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/groups/aliases [put]
+func (a *API) SetAlias(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	req := &AliasRequest{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	alias := req.ToModel()
+	if err := a.repository.SetAlias(alias); err != nil {
+		a.fail(w, r, err, e.RespDBDataUpdateFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("alias", alias.Alias).Str("group", alias.Group).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Group alias set")
+	render.WriteJSON(w, r, a.logger, http.StatusOK, alias)
+}
+
+// DeleteAlias godoc
+//
+//	@summary		Delete group alias
+//	@description	Delete an alias, given as its key or any spelling of it.
+//	@tags			groups
+//	@param			key	path	string	true	"Alias key or name"
+//	@success		200
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/groups/aliases/{key} [delete]
+func (a *API) DeleteAlias(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+	key := translit.Key(chi.URLParam(r, "key"))
+
+	rows, err := a.repository.DeleteAlias(key)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataRemoveFailure)
+		return
+	}
+	if rows == 0 {
+		e.NotFound(w, r, e.RespAliasNotFound)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("alias", key).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Group alias deleted")
+}
+
+// Merge godoc
+//
+//	@summary		Merge groups
+//	@description	Move every song of one group, under any spelling with the same search key, to another group. The old
+//	@description	name stays as an alias of the group, and so do its own aliases. Names with the same search key, such as
+//	@description	"AC/DC" and "AC-DC", need no alias: their songs are only renamed.
+//	@tags			groups
+//	@accept			json
+//	@produce		json
+//	@param			body	body		MergeRequest	true	"Groups to merge"
+//	@success		200		{object}	MergeResult
+//	@failure		400		{object}	err.Problem
+//	@failure		401		{object}	err.Problem
+//	@failure		403		{object}	err.Problem
+//	@failure		404		{object}	err.Problem
+//	@failure		422		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/groups/merge [post]
+func (a *API) Merge(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	req := &MergeRequest{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	res, err := a.repository.Merge(strings.TrimSpace(req.From), strings.TrimSpace(req.To))
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataUpdateFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("from", req.From).Str("to", res.Group).Int64("songs", res.Songs).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Groups merged")
+	render.WriteJSON(w, r, a.logger, http.StatusOK, res)
+}
+
+// Duplicates godoc
+//
+//	@summary		Report likely duplicate groups
+//	@description	Pair the group names whose search keys, ignoring spaces, are alike: 1 means the same key, lower values
+//	@description	count the letters to change. Candidates for aliases or a merge.
+//	@tags			groups
+//	@produce		json
+//	@param			min_similarity	query		number	false	"Least similarity of a pair, between 0 and 1 (default is 0.8)"
+//	@param			limit			query		int		false	"Maximum number of pairs (default is 100, max is 1000)"
+//	@success		200				{array}		Duplicate
+//	@failure		400				{object}	err.Problem
+//	@failure		500				{object}	err.Problem
+//	@router			/groups/duplicates [get]
+func (a *API) Duplicates(w http.ResponseWriter, r *http.Request) {
+	minSimilarity := defaultMinSimilarity
+	if v := r.URL.Query().Get("min_similarity"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			e.BadRequest(w, r, e.RespInvalidQuery.WithDetail("min_similarity must be greater than 0 and at most 1"))
+			return
+		}
+		minSimilarity = f
+	}
+
+	limit, err := decode.QueryInt(r, "limit", defaultDuplicatesLimit, 1, maxDuplicatesLimit)
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+
+	groups, err := a.repository.Groups()
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return
+	}
+
+	duplicates := Duplicates(groups, minSimilarity)
+	if len(duplicates) > limit {
+		duplicates = duplicates[:limit]
+	}
+	render.WriteJSON(w, r, a.logger, http.StatusOK, duplicates)
+}
+
+func (a *API) fail(w http.ResponseWriter, r *http.Request, err error, p e.Problem) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		e.NotFound(w, r, e.RespGroupNotFound)
+	case errors.Is(err, ErrSameGroup):
+		e.Invalid(w, r, "alias", "alias_self", "")
+	default:
+		a.logger.Error().Str(l.KeyReqID, ctxUtil.RequestID(r.Context())).Err(err).Msg("Group query failed")
+		e.ServerError(w, r, p)
+	}
+}
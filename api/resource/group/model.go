@@ -0,0 +1,104 @@
+package group
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"songs/pkg/translit"
+)
+
+// Alias is an alternate name of a group, matched by its search key.
+type Alias struct {
+	Key      string `gorm:"column:alias_key;primarykey" json:"key"`
+	Alias    string `gorm:"column:alias" json:"alias"`
+	Group    string `gorm:"column:group_name" json:"group"`
+	GroupKey string `gorm:"column:group_key" json:"-"`
+}
+
+func (Alias) TableName() string {
+	return "group_aliases"
+}
+
+type AliasRequest struct {
+	Alias string `json:"alias" form:"required,max=255"`
+	// Group is the canonical name; an alias given here is resolved to its group.
+	Group string `json:"group" form:"required,max=255"`
+}
+
+type MergeRequest struct {
+	From string `json:"from" form:"required,max=255"`
+	To   string `json:"to" form:"required,max=255"`
+}
+
+// MergeResult tells which group the songs were moved to and how many there were.
+type MergeResult struct {
+	Group string `json:"group"`
+	Songs int64  `json:"songs"`
+}
+
+// Group is a group name as stored on songs, with the number of its songs.
+type Group struct {
+	Name  string `json:"name"`
+	Key   string `json:"-"`
+	Songs int    `json:"songs"`
+}
+
+// Duplicate is a pair of group names that likely mean the same group.
+type Duplicate struct {
+	Groups     [2]Group `json:"groups"`
+	Similarity float64  `json:"similarity"`
+}
+
+func (r *AliasRequest) ToModel() *Alias {
+	alias := strings.TrimSpace(r.Alias)
+	group := strings.TrimSpace(r.Group)
+
+	return &Alias{
+		Key:      translit.Key(alias),
+		Alias:    alias,
+		Group:    group,
+		GroupKey: translit.Key(group),
+	}
+}
+
+// Duplicates pairs the groups whose names are at least minSimilarity alike by
+// translit.Similarity, most similar first: "AC/DC", "ACDC" and "AC-DC" come out
+// identical, as do "Кино" and "Kino".
+func Duplicates(groups []Group, minSimilarity float64) []Duplicate {
+	lengths := make([]int, len(groups))
+	for i, g := range groups {
+		lengths[i] = utf8.RuneCountInString(strings.ReplaceAll(g.Key, " ", ""))
+	}
+
+	duplicates := []Duplicate{}
+	for i := range groups {
+		for j := i + 1; j < len(groups); j++ {
+			longest := max(lengths[i], lengths[j])
+			if longest == 0 {
+				continue
+			}
+			// Names differ by at least their difference in length; skip pairs that cannot match.
+			if 1-float64(abs(lengths[i]-lengths[j]))/float64(longest) < minSimilarity {
+				continue
+			}
+
+			similarity := translit.Similarity(groups[i].Key, groups[j].Key)
+			if similarity >= minSimilarity {
+				duplicates = append(duplicates, Duplicate{Groups: [2]Group{groups[i], groups[j]}, Similarity: similarity})
+			}
+		}
+	}
+
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Similarity > duplicates[j].Similarity
+	})
+	return duplicates
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
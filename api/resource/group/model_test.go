@@ -0,0 +1,36 @@
+package group_test
+
+import (
+	"testing"
+
+	"songs/api/resource/group"
+	"songs/pkg/translit"
+	testUtil "songs/util/test"
+)
+
+func TestDuplicates(t *testing.T) {
+	t.Parallel()
+
+	var groups []group.Group
+	for _, name := range []string{"AC-DC", "AC/DC", "ACDC", "Kino", "Кино", "Muse", "Mumiy Troll", "Мумий Тролль"} {
+		groups = append(groups, group.Group{Name: name, Key: translit.Key(name), Songs: 1})
+	}
+
+	duplicates := group.Duplicates(groups, 0.8)
+
+	pairs := map[[2]string]float64{}
+	for _, d := range duplicates {
+		pairs[[2]string{d.Groups[0].Name, d.Groups[1].Name}] = d.Similarity
+	}
+	testUtil.Equal(t, len(pairs), 5)
+	for _, pair := range [][2]string{{"AC-DC", "AC/DC"}, {"AC-DC", "ACDC"}, {"AC/DC", "ACDC"}, {"Kino", "Кино"}, {"Mumiy Troll", "Мумий Тролль"}} {
+		if pairs[pair] != 1 {
+			t.Errorf("%v: expected similarity 1, got %v", pair, pairs[pair])
+		}
+	}
+
+	near := group.Duplicates([]group.Group{{Name: "Nautilus", Key: "nautilus"}, {Name: "Nautilus Pompilius", Key: "nautilus pompilius"}, {Name: "Nautilis", Key: "nautilis"}}, 0.8)
+	testUtil.Equal(t, len(near), 1)
+	testUtil.Equal(t, near[0].Groups[1].Name, "Nautilis")
+	testUtil.Equal(t, near[0].Similarity, 0.875)
+}
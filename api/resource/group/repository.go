@@ -0,0 +1,137 @@
+package group
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"songs/api/resource/song"
+	"songs/pkg/translit"
+)
+
+// ErrSameGroup is returned for an alias whose two names are the same group.
+var ErrSameGroup = errors.New("names are the same group")
+
+type Repository struct {
+	db     *gorm.DB
+	logger *zerolog.Logger
+}
+
+func NewRepository(db *gorm.DB, l *zerolog.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: l,
+	}
+}
+
+// Aliases lists the aliases by name, only those of group when it is set.
+func (r *Repository) Aliases(group string) ([]Alias, error) {
+	query := r.db.Model(&Alias{})
+	if group != "" {
+		query = query.Where("group_key = ?", translit.Key(group))
+	}
+
+	aliases := []Alias{}
+	err := query.Order("alias").Find(&aliases).Error
+	return aliases, err
+}
+
+// SetAlias makes a.Alias stand for a.Group, or for the group a.Group is itself an alias
+// of. Aliases of a.Alias move to that group too, so no alias points at another.
+func (r *Repository) SetAlias(a *Alias) error {
+	r.logger.Debug().Msgf("Setting group alias: %+v", a)
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := canonical(tx, a); err != nil {
+			return err
+		}
+		return setAlias(tx, a)
+	})
+}
+
+// DeleteAlias removes the alias with the given search key.
+func (r *Repository) DeleteAlias(key string) (int64, error) {
+	r.logger.Debug().Msgf("Deleting group alias: %s", key)
+
+	result := r.db.Where("alias_key = ?", key).Delete(&Alias{})
+	return result.RowsAffected, result.Error
+}
+
+// Merge moves the songs of group from, by any spelling with the same search key, to group
+// to, and keeps from as an alias of to. When both names have the same search key, such as
+// "AC/DC" and "AC-DC", it only renames the songs to to. It returns gorm.ErrRecordNotFound
+// when from has no songs.
+func (r *Repository) Merge(from, to string) (*MergeResult, error) {
+	r.logger.Debug().Msgf("Merging group %s into %s", from, to)
+
+	res := &MergeResult{}
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		a := &Alias{Key: translit.Key(from), Alias: from, Group: to, GroupKey: translit.Key(to)}
+		if err := canonical(tx, a); err != nil {
+			return err
+		}
+		result := tx.Model(&song.Song{}).
+			Where("group_key = ?", a.Key).
+			Updates(map[string]interface{}{"group_name": a.Group, "group_key": a.GroupKey})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		res.Group, res.Songs = a.Group, result.RowsAffected
+		if a.Key == a.GroupKey {
+			return nil
+		}
+		return setAlias(tx, a)
+	})
+
+	return res, err
+}
+
+// Groups lists the group names stored on songs, by name, with their song counts.
+func (r *Repository) Groups() ([]Group, error) {
+	groups := []Group{}
+	err := r.db.Model(&song.Song{}).
+		Select("group_name AS name, group_key AS key, COUNT(*) AS songs").
+		Group("group_name, group_key").
+		Order("group_name").
+		Scan(&groups).Error
+
+	return groups, err
+}
+
+// setAlias stores a, whose group must be canonical, and moves the aliases of a.Alias to
+// a.Group.
+func setAlias(tx *gorm.DB, a *Alias) error {
+	if a.Key == a.GroupKey {
+		return ErrSameGroup
+	}
+
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "alias_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"alias", "group_name", "group_key"}),
+	}).Create(a).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&Alias{}).
+		Where("group_key = ?", a.Key).
+		Updates(map[string]interface{}{"group_name": a.Group, "group_key": a.GroupKey}).Error
+}
+
+// canonical points a at the group its group is an alias of, if it is one.
+func canonical(tx *gorm.DB, a *Alias) error {
+	var target []Alias
+	if err := tx.Where("alias_key = ?", a.GroupKey).Limit(1).Find(&target).Error; err != nil {
+		return err
+	}
+	if len(target) > 0 {
+		a.Group, a.GroupKey = target[0].Group, target[0].GroupKey
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package group_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/rs/zerolog"
+
+	"songs/api/resource/group"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+var testLogger = zerolog.Nop()
+
+func TestRepository_Merge(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := group.NewRepository(db, &testLogger)
+
+	// "Эйси Диси" is itself an alias of AC/DC, so the songs of ACDC go straight to AC/DC.
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT \* FROM "group_aliases" WHERE alias_key = \$1`).
+		WithArgs("eisi disi", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"alias_key", "alias", "group_name", "group_key"}).
+			AddRow("eisi disi", "Эйси Диси", "AC/DC", "ac dc"))
+	mock.ExpectExec(`^UPDATE "songs" SET "group_key"=\$1,"group_name"=\$2 WHERE group_key = \$3`).
+		WithArgs("ac dc", "AC/DC", "acdc").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`^INSERT INTO "group_aliases" (.+) ON CONFLICT \("alias_key"\) DO UPDATE`).
+		WithArgs("acdc", "ACDC", "AC/DC", "ac dc").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^UPDATE "group_aliases" SET "group_key"=\$1,"group_name"=\$2 WHERE group_key = \$3`).
+		WithArgs("ac dc", "AC/DC", "acdc").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	res, err := repo.Merge("ACDC", "Эйси Диси")
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, res.Group, "AC/DC")
+	testUtil.Equal(t, res.Songs, int64(3))
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_MergeSameKey(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	// "AC-DC" and "AC/DC" share the key "ac dc": the songs are renamed and no alias is kept.
+	mock.ExpectBegin()
+	mock.ExpectQuery(`^SELECT \* FROM "group_aliases" WHERE alias_key = \$1`).
+		WithArgs("ac dc", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"alias_key"}))
+	mock.ExpectExec(`^UPDATE "songs" SET "group_key"=\$1,"group_name"=\$2 WHERE group_key = \$3`).
+		WithArgs("ac dc", "AC/DC", "ac dc").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	res, err := group.NewRepository(db, &testLogger).Merge("AC-DC", "AC/DC")
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, res.Group, "AC/DC")
+	testUtil.Equal(t, res.Songs, int64(2))
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,118 @@
+package lookup
+
+import (
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/common/decode"
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/api/resource/common/render"
+	"songs/api/resource/song"
+	"songs/pkg/translit"
+	ctxUtil "songs/util/ctx"
+)
+
+const (
+	// minKeyLength is how many letters and digits a line needs to be looked up.
+	minKeyLength = 3
+
+	defaultLimit = 10
+	maxLimit     = 50
+
+	defaultContext = 2
+	maxContext     = 10
+)
+
+type API struct {
+	logger     *zerolog.Logger
+	repository *Repository
+	songs      *song.Repository
+}
+
+func New(logger *zerolog.Logger, db *gorm.DB) *API {
+	return &API{
+		logger:     logger,
+		repository: NewRepository(db, logger),
+		songs:      song.NewRepository(db, logger),
+	}
+}
+
+// Lookup godoc
+//
+//	@summary		Find songs by a line
+//	@description	Find the songs with a lyric line like the given one, or part of one, regardless of case, punctuation,
+//	@description	Cyrillic or Latin spelling and small typos. Each song comes with its best matching line, the line's
+//	@description	stanza and the lines around it; 1 is an exact match.
+//	@tags			songs
+//	@produce		json
+//	@param			line	query		string	true	"Lyric line, or part of one"
+//	@param			limit	query		int		false	"Maximum number of songs (default is 10, max is 50)"
+//	@param			context	query		int		false	"Lines to show before and after the match (default is 2, max is 10)"
+//	@success		200		{array}		Result
+//	@failure		400		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@router			/lookup [get]
+func (a *API) Lookup(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Lookup function started")
+
+	key := translit.Key(r.URL.Query().Get("line"))
+	if utf8.RuneCountInString(key) < minKeyLength {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(fmt.Sprintf("line must have at least %d letters or digits", minKeyLength)))
+		return
+	}
+
+	limit, err := decode.QueryInt(r, "limit", defaultLimit, 1, maxLimit)
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+	context, err := decode.QueryInt(r, "context", defaultContext, 0, maxContext)
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+
+	hits, err := a.repository.Search(key, limit)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to look up songs by line")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	ids := make([]uuid.UUID, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.SongID
+	}
+	songs, err := a.songs.ReadMany(ids)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to retrieve songs")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+	byID := make(map[uuid.UUID]*song.Song, len(songs))
+	for i := range songs {
+		byID[songs[i].ID] = &songs[i]
+	}
+
+	results := []Result{}
+	for _, hit := range hits {
+		s, ok := byID[hit.SongID]
+		if !ok {
+			continue
+		}
+		if res, ok := NewResult(s, hit, context); ok {
+			results = append(results, res)
+		}
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Int("songs", len(results)).Msg("Songs looked up by line")
+	render.WriteJSON(w, r, a.logger, http.StatusOK, results)
+}
@@ -0,0 +1,79 @@
+package lookup
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+
+	"songs/api/resource/song"
+)
+
+// Hit is the best matching line of a song in the line index.
+type Hit struct {
+	SongID uuid.UUID `gorm:"column:song_id"`
+	Number int       `gorm:"column:line_no"`
+	Stanza int       `gorm:"column:stanza"`
+	Score  float64   `gorm:"column:score"`
+}
+
+// Result is a song with the line that matched, as written in the lyrics, its stanza and
+// the lines around it. Line and stanza numbers count from 1; line numbers count blank
+// lines too.
+type Result struct {
+	ID          uuid.UUID `json:"id"`
+	Group       string    `json:"group"`
+	Song        string    `json:"song"`
+	Line        int       `json:"line"`
+	Text        string    `json:"text"`
+	Stanza      int       `json:"stanza"`
+	StanzaLines []string  `json:"stanza_lines"`
+	Before      []string  `json:"before"`
+	After       []string  `json:"after"`
+	Score       float64   `json:"score"`
+}
+
+// NewResult finds the hit's line in the song's lyrics, with up to context non-blank lines
+// on each side. It returns false when the lyrics no longer have that line.
+func NewResult(s *song.Song, hit Hit, context int) (Result, bool) {
+	lines := song.SplitLines(s.Text)
+	i := hit.Number - 1
+	if i < 0 || i >= len(lines) || strings.TrimSpace(lines[i]) == "" {
+		return Result{}, false
+	}
+
+	res := Result{
+		ID:     s.ID,
+		Group:  s.Group,
+		Song:   s.Song,
+		Line:   hit.Number,
+		Text:   strings.TrimSpace(lines[i]),
+		Stanza: hit.Stanza,
+		Before: []string{},
+		After:  []string{},
+		Score:  hit.Score,
+	}
+
+	for j := i - 1; j >= 0 && len(res.Before) < context; j-- {
+		if line := strings.TrimSpace(lines[j]); line != "" {
+			res.Before = append([]string{line}, res.Before...)
+		}
+	}
+	for j := i + 1; j < len(lines) && len(res.After) < context; j++ {
+		if line := strings.TrimSpace(lines[j]); line != "" {
+			res.After = append(res.After, line)
+		}
+	}
+
+	first, last := i, i
+	for first > 0 && strings.TrimSpace(lines[first-1]) != "" {
+		first--
+	}
+	for last+1 < len(lines) && strings.TrimSpace(lines[last+1]) != "" {
+		last++
+	}
+	for _, line := range lines[first : last+1] {
+		res.StanzaLines = append(res.StanzaLines, strings.TrimSpace(line))
+	}
+
+	return res, true
+}
@@ -0,0 +1,32 @@
+package lookup_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"songs/api/resource/lookup"
+	"songs/api/resource/song"
+	testUtil "songs/util/test"
+)
+
+func TestNewResult(t *testing.T) {
+	t.Parallel()
+
+	s := &song.Song{ID: uuid.New(), Group: "Кино", Song: "Группа крови", Text: "Тёплое место, но улицы ждут\r\nОтпечатков наших ног.\r\n\r\nГруппа крови на рукаве,\r\nМой порядковый номер на рукаве.\r\nПожелай мне удачи в бою\r\n\r\nИ есть чем платить"}
+
+	res, ok := lookup.NewResult(s, lookup.Hit{SongID: s.ID, Number: 5, Stanza: 2, Score: 0.9}, 2)
+	testUtil.Equal(t, ok, true)
+	testUtil.Equal(t, res.Text, "Мой порядковый номер на рукаве.")
+	testUtil.Equal(t, res.Stanza, 2)
+	testUtil.Equal(t, true, reflect.DeepEqual(res.StanzaLines, []string{"Группа крови на рукаве,", "Мой порядковый номер на рукаве.", "Пожелай мне удачи в бою"}))
+	testUtil.Equal(t, true, reflect.DeepEqual(res.Before, []string{"Отпечатков наших ног.", "Группа крови на рукаве,"}))
+	testUtil.Equal(t, true, reflect.DeepEqual(res.After, []string{"Пожелай мне удачи в бою", "И есть чем платить"}))
+
+	// The lyrics changed since the line was indexed.
+	_, ok = lookup.NewResult(s, lookup.Hit{SongID: s.ID, Number: 3}, 2)
+	testUtil.Equal(t, ok, false)
+	_, ok = lookup.NewResult(s, lookup.Hit{SongID: s.ID, Number: 20}, 2)
+	testUtil.Equal(t, ok, false)
+}
@@ -0,0 +1,40 @@
+package lookup
+
+import (
+	"database/sql"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+)
+
+// searchQuery takes each song's best line for the search key @key: the line containing
+// the words most like it, by trigrams, so small typos still match.
+const searchQuery = `SELECT song_id, line_no, stanza, score FROM (
+		SELECT DISTINCT ON (song_id) song_id, line_no, stanza, word_similarity(@key, key) AS score
+		FROM song_lines
+		WHERE @key <% key
+		ORDER BY song_id, score DESC, line_no
+	) AS best
+	ORDER BY score DESC, song_id
+	LIMIT @limit`
+
+type Repository struct {
+	db     *gorm.DB
+	logger *zerolog.Logger
+}
+
+func NewRepository(db *gorm.DB, l *zerolog.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: l,
+	}
+}
+
+// Search returns up to limit songs with a line like the search key, the best match first.
+func (r *Repository) Search(key string, limit int) ([]Hit, error) {
+	r.logger.Debug().Msgf("Looking up songs by line: %q", key)
+
+	hits := []Hit{}
+	err := r.db.Raw(searchQuery, sql.Named("key", key), sql.Named("limit", limit)).Scan(&hits).Error
+	return hits, err
+}
@@ -0,0 +1,36 @@
+package lookup_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"songs/api/resource/lookup"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+var testLogger = zerolog.Nop()
+
+func TestRepository_Search(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	id := uuid.New()
+	mock.ExpectQuery(`^SELECT song_id, line_no, stanza, score FROM \(\s+SELECT DISTINCT ON \(song_id\) (.+) word_similarity\(\$1, key\) AS score\s+FROM song_lines\s+WHERE \$2 <% key (.+) LIMIT \$3`).
+		WithArgs("grupa krovi na rukave", "grupa krovi na rukave", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"song_id", "line_no", "stanza", "score"}).AddRow(id, 4, 2, 0.95))
+
+	hits, err := lookup.NewRepository(db, &testLogger).Search("grupa krovi na rukave", 10)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, len(hits), 1)
+	testUtil.Equal(t, hits[0].SongID, id)
+	testUtil.Equal(t, hits[0].Number, 4)
+	testUtil.Equal(t, hits[0].Stanza, 2)
+	testUtil.Equal(t, hits[0].Score, 0.95)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
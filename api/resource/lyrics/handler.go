@@ -0,0 +1,244 @@
+package lyrics
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/common/decode"
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/api/resource/common/render"
+	"songs/api/resource/song"
+	ctxUtil "songs/util/ctx"
+)
+
+// undetermined is the BCP-47 tag for an original whose language is neither recorded nor
+// detected.
+const undetermined = "und"
+
+type API struct {
+	logger     *zerolog.Logger
+	validator  *validator.Validate
+	repository *Repository
+	songs      *song.Repository
+}
+
+func New(logger *zerolog.Logger, validator *validator.Validate, db *gorm.DB) *API {
+	return &API{
+		logger:     logger,
+		validator:  validator,
+		repository: NewRepository(db, logger),
+		songs:      song.NewRepository(db, logger),
+	}
+}
+
+// List godoc
+//
+//	@summary		List lyrics versions
+//	@description	List a song's lyrics in every language it has, the original first.
+//	@tags			lyrics
+//	@produce		json
+//	@param			id	path		string	true	"Song ID"
+//	@success		200	{array}		song.Lyrics
+//	@failure		400	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@router			/{id}/lyrics [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	s, ok := a.song(w, r)
+	if !ok {
+		return
+	}
+
+	versions, err := a.songs.Versions(s.ID)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return
+	}
+
+	render.WriteJSON(w, r, a.logger, http.StatusOK, versions)
+}
+
+// Set godoc
+//
+//	@summary		Set lyrics version
+//	@description	Store a translation of the song's lyrics, or mark the song's own text as the original in this language,
+//	@description	which then becomes the song's language.
+//	@tags			lyrics
+//	@accept			json
+//	@param			id		path	string	true	"Song ID"
+//	@param			lang	path	string	true	"BCP-47 language tag"
+//	@param			body	body	Request	true	"Lyrics version"
+//	@success		200
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		422	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/{id}/lyrics/{lang} [put]
+func (a *API) Set(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	s, ok := a.song(w, r)
+	if !ok {
+		return
+	}
+
+	lang, ok := urlLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	req := &Request{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	if err := a.repository.Set(s.ID, req.ToModel(lang)); err != nil {
+		a.fail(w, r, err, e.RespDBDataUpdateFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", s.ID.String()).Str("lang", lang).Bool("original", req.IsOriginal).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Song lyrics set")
+}
+
+// Delete godoc
+//
+//	@summary		Delete lyrics version
+//	@description	Delete a translation. Deleting the original only forgets its language; the song keeps its text.
+//	@tags			lyrics
+//	@param			id		path	string	true	"Song ID"
+//	@param			lang	path	string	true	"BCP-47 language tag"
+//	@success		200
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/{id}/lyrics/{lang} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
+		return
+	}
+
+	lang, ok := urlLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := a.repository.Delete(id, lang)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataRemoveFailure)
+		return
+	}
+	if rows == 0 {
+		e.NotFound(w, r, e.RespLyricsNotFound)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Str("lang", lang).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Song lyrics deleted")
+}
+
+// SideBySide godoc
+//
+//	@summary		Read original and translation side by side
+//	@description	Align the original lyrics with a translation stanza by stanza and line by line. Plain text lays them out
+//	@description	in two columns, Markdown as a table.
+//	@tags			lyrics
+//	@produce		json,xml,application/yaml,plain,text/markdown
+//	@param			id		path		string	true	"Song ID"
+//	@param			lang	query		string	true	"BCP-47 language of the translation"
+//	@success		200		{object}	SideBySide
+//	@failure		400		{object}	err.Problem
+//	@failure		404		{object}	err.Problem
+//	@failure		406		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@router			/{id}/lyrics/side-by-side [get]
+func (a *API) SideBySide(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	s, ok := a.song(w, r)
+	if !ok {
+		return
+	}
+
+	lang, err := song.ParseLanguage(r.URL.Query().Get("lang"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidLanguage.WithDetail(err.Error()))
+		return
+	}
+
+	versions, err := a.songs.Versions(s.ID)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return
+	}
+
+	translation := song.MatchLyrics(versions, lang)
+	if translation == nil || translation.IsOriginal {
+		e.NotFound(w, r, e.RespLyricsNotFound)
+		return
+	}
+
+	original := song.Lyrics{Language: s.Language, IsOriginal: true, Text: s.Text}
+	if original.Language == "" {
+		original.Language = undetermined
+	}
+	if o := song.OriginalLyrics(versions); o != nil {
+		original = *o
+	}
+
+	if err := render.Respond(w, r, http.StatusOK, Align(original, *translation)); err != nil {
+		a.logger.Warn().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to render lyrics side by side")
+	}
+}
+
+// song parses the song ID from the URL and reads the song, answering 400 or 404 itself.
+func (a *API) song(w http.ResponseWriter, r *http.Request) (*song.Song, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
+		return nil, false
+	}
+
+	s, err := a.songs.Read(id)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return nil, false
+	}
+
+	return s, true
+}
+
+// fail answers with 404 for a missing song and with the given problem otherwise.
+func (a *API) fail(w http.ResponseWriter, r *http.Request, err error, p e.Problem) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		e.NotFound(w, r, e.RespSongNotFound)
+		return
+	}
+
+	a.logger.Error().Str(l.KeyReqID, ctxUtil.RequestID(r.Context())).Err(err).Msg("Lyrics query failed")
+	e.ServerError(w, r, p)
+}
+
+func urlLanguage(w http.ResponseWriter, r *http.Request) (string, bool) {
+	lang, err := song.ParseLanguage(chi.URLParam(r, "lang"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidLanguage.WithDetail(err.Error()))
+		return "", false
+	}
+	return lang, true
+}
@@ -0,0 +1,151 @@
+package lyrics
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"songs/api/resource/common/render"
+	"songs/api/resource/song"
+)
+
+type Request struct {
+	// IsOriginal marks the song's own text as being in this language; it takes no text.
+	IsOriginal bool   `json:"is_original"`
+	Text       string `json:"text" form:"required_if=IsOriginal false,excluded_if=IsOriginal true"`
+	Translator string `json:"translator" form:"max=255,excluded_if=IsOriginal true"`
+}
+
+// SideBySide pairs an original with a translation stanza by stanza and line by line.
+type SideBySide struct {
+	Original    Version  `json:"original" xml:"original" yaml:"original"`
+	Translation Version  `json:"translation" xml:"translation" yaml:"translation"`
+	Stanzas     []Stanza `json:"stanzas" xml:"stanzas>stanza" yaml:"stanzas"`
+}
+
+// Version describes one side of a SideBySide.
+type Version struct {
+	Language   string `json:"language" xml:"language" yaml:"language"`
+	Translator string `json:"translator,omitempty" xml:"translator,omitempty" yaml:"translator,omitempty"`
+}
+
+type Stanza struct {
+	Lines []Pair `json:"lines" xml:"lines>line" yaml:"lines"`
+}
+
+// Pair is a line of the original with its translation; either is empty when the stanzas
+// differ in length.
+type Pair struct {
+	Original    string `json:"original" xml:"original" yaml:"original"`
+	Translation string `json:"translation" xml:"translation" yaml:"translation"`
+}
+
+func (r *Request) ToModel(lang string) *song.Lyrics {
+	return &song.Lyrics{
+		Language:   lang,
+		IsOriginal: r.IsOriginal,
+		Text:       r.Text,
+		Translator: strings.TrimSpace(r.Translator),
+	}
+}
+
+// Align pairs the original's stanzas, separated by blank lines, with the translation's,
+// and their lines in order. Unmatched stanzas and lines are paired with empty ones.
+func Align(original, translation song.Lyrics) *SideBySide {
+	left, right := stanzas(original.Text), stanzas(translation.Text)
+
+	s := &SideBySide{
+		Original:    Version{Language: original.Language, Translator: original.Translator},
+		Translation: Version{Language: translation.Language, Translator: translation.Translator},
+		Stanzas:     make([]Stanza, max(len(left), len(right))),
+	}
+
+	for i := range s.Stanzas {
+		l, r := at(left, i), at(right, i)
+		lines := make([]Pair, max(len(l), len(r)))
+		for j := range lines {
+			lines[j] = Pair{Original: at(l, j), Translation: at(r, j)}
+		}
+		s.Stanzas[i].Lines = lines
+	}
+
+	return s
+}
+
+// PlainText lays the original and the translation out in two columns.
+func (s *SideBySide) PlainText() string {
+	width := 0
+	for _, st := range s.Stanzas {
+		for _, p := range st.Lines {
+			width = max(width, utf8.RuneCountInString(p.Original))
+		}
+	}
+
+	var b strings.Builder
+	for i, st := range s.Stanzas {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		for _, p := range st.Lines {
+			line := p.Original
+			if p.Translation != "" {
+				line += strings.Repeat(" ", width-utf8.RuneCountInString(p.Original)) + " | " + p.Translation
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+// Markdown renders a two-column table with a blank row between stanzas.
+func (s *SideBySide) Markdown() string {
+	var b strings.Builder
+
+	b.WriteString("| " + s.Original.Language + " | " + s.Translation.Language + " |\n")
+	b.WriteString("| --- | --- |\n")
+	for i, st := range s.Stanzas {
+		if i > 0 {
+			b.WriteString("| | |\n")
+		}
+		for _, p := range st.Lines {
+			b.WriteString("| " + render.EscapeMarkdown(p.Original) + " | " + render.EscapeMarkdown(p.Translation) + " |\n")
+		}
+	}
+
+	if s.Translation.Translator != "" {
+		b.WriteString("\n_" + render.EscapeMarkdown(s.Translation.Translator) + "_\n")
+	}
+
+	return b.String()
+}
+
+// stanzas splits lyrics into stanzas of trimmed lines, dropping repeated blank lines.
+func stanzas(text string) [][]string {
+	var result [][]string
+	var current []string
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			if len(current) > 0 {
+				result = append(result, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		result = append(result, current)
+	}
+
+	return result
+}
+
+func at[T any](s []T, i int) T {
+	var zero T
+	if i < len(s) {
+		return s[i]
+	}
+	return zero
+}
@@ -0,0 +1,48 @@
+package lyrics_test
+
+import (
+	"testing"
+
+	"songs/api/resource/lyrics"
+	"songs/api/resource/song"
+	testUtil "songs/util/test"
+	"songs/util/validator"
+)
+
+func TestAlign(t *testing.T) {
+	t.Parallel()
+
+	original := song.Lyrics{Language: "ru", Text: "Тёплое место\nНо улицы ждут\n\n\nГруппа крови\n"}
+	translation := song.Lyrics{Language: "en", Text: "A warm place\nBut the streets await\n\nBlood type\nOn the sleeve\n\nExtra"}
+
+	s := lyrics.Align(original, translation)
+
+	testUtil.Equal(t, 3, len(s.Stanzas))
+	testUtil.Equal(t, lyrics.Pair{Original: "Но улицы ждут", Translation: "But the streets await"}, s.Stanzas[0].Lines[1])
+	testUtil.Equal(t, lyrics.Pair{Translation: "On the sleeve"}, s.Stanzas[1].Lines[1])
+	testUtil.Equal(t, lyrics.Pair{Translation: "Extra"}, s.Stanzas[2].Lines[0])
+
+	expected := "Тёплое место  | A warm place\n" +
+		"Но улицы ждут | But the streets await\n" +
+		"\n" +
+		"Группа крови  | Blood type\n" +
+		"              | On the sleeve\n" +
+		"\n" +
+		"              | Extra\n"
+	testUtil.Equal(t, expected, s.PlainText())
+}
+
+func TestRequest_Validation(t *testing.T) {
+	t.Parallel()
+
+	vr := validator.New()
+
+	testUtil.NoError(t, vr.Struct(lyrics.Request{Text: "Blood type", Translator: "J. Doe"}))
+	testUtil.NoError(t, vr.Struct(lyrics.Request{IsOriginal: true}))
+
+	err := vr.Struct(lyrics.Request{})
+	testUtil.Equal(t, "text is a required field", validator.ToErrResponse(err).Errors[0])
+
+	err = vr.Struct(lyrics.Request{IsOriginal: true, Text: "Группа крови"})
+	testUtil.Equal(t, "text is an excluded field", validator.ToErrResponse(err).Errors[0])
+}
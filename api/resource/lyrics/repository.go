@@ -0,0 +1,68 @@
+package lyrics
+
+import (
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"songs/api/resource/song"
+)
+
+type Repository struct {
+	db     *gorm.DB
+	logger *zerolog.Logger
+}
+
+func NewRepository(db *gorm.DB, l *zerolog.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: l,
+	}
+}
+
+// Set stores the song's version in v.Language, replacing any there is. Marking a version
+// as the original drops the previous original's record, as the song has one text, and
+// makes its language the song's.
+func (r *Repository) Set(songID uuid.UUID, v *song.Lyrics) error {
+	r.logger.Debug().Msgf("Setting %s lyrics of song %s, original: %t", v.Language, songID, v.IsOriginal)
+
+	v.SongID = songID
+	row := map[string]interface{}{
+		"song_id":     songID,
+		"language":    v.Language,
+		"is_original": v.IsOriginal,
+		"text":        nil,
+		"translator":  v.Translator,
+	}
+	if !v.IsOriginal {
+		row["text"] = v.Text
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if v.IsOriginal {
+			if err := tx.Where("song_id = ? AND is_original", songID).Delete(&song.Lyrics{}).Error; err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(&song.Lyrics{}).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "song_id"}, {Name: "language"}},
+			DoUpdates: clause.AssignmentColumns([]string{"is_original", "text", "translator"}),
+		}).Create(row).Error
+		if err != nil || !v.IsOriginal {
+			return err
+		}
+
+		return song.SyncLanguage(tx, songID)
+	})
+}
+
+// Delete removes the song's version in lang. Deleting the original only forgets its
+// language; the song keeps its text.
+func (r *Repository) Delete(songID uuid.UUID, lang string) (int64, error) {
+	r.logger.Debug().Msgf("Deleting %s lyrics of song %s", lang, songID)
+
+	result := r.db.Where("song_id = ? AND language = ?", songID, lang).Delete(&song.Lyrics{})
+	return result.RowsAffected, result.Error
+}
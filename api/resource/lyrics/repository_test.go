@@ -0,0 +1,60 @@
+package lyrics_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"songs/api/resource/lyrics"
+	"songs/api/resource/song"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+var testLogger = zerolog.Nop()
+
+func TestRepository_Set_Original(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := lyrics.NewRepository(db, &testLogger)
+
+	id := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^DELETE FROM "song_lyrics" WHERE song_id = \$1 AND is_original`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^INSERT INTO "song_lyrics" (.+) ON CONFLICT \("song_id","language"\) DO UPDATE`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^UPDATE songs SET language = l.language FROM song_lyrics AS l (.+) AND songs.id = \$1`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.Set(id, &song.Lyrics{Language: "ru", IsOriginal: true})
+	testUtil.NoError(t, err)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Set_Translation(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := lyrics.NewRepository(db, &testLogger)
+
+	id := uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectExec(`^INSERT INTO "song_lyrics"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repo.Set(id, &song.Lyrics{Language: "en", Text: "Blood type", Translator: "Anna"})
+	testUtil.NoError(t, err)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,344 @@
+package person
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/common/decode"
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/api/resource/common/render"
+	"songs/api/resource/song"
+	"songs/pkg/pagination"
+	ctxUtil "songs/util/ctx"
+)
+
+type API struct {
+	logger     *zerolog.Logger
+	validator  *validator.Validate
+	repository *Repository
+	songs      *song.Repository
+}
+
+func New(logger *zerolog.Logger, validator *validator.Validate, db *gorm.DB) *API {
+	return &API{
+		logger:     logger,
+		validator:  validator,
+		repository: NewRepository(db, logger),
+		songs:      song.NewRepository(db, logger),
+	}
+}
+
+// List godoc
+//
+//	@summary		List people
+//	@description	List the people songs can credit, ordered by name.
+//	@tags			people
+//	@produce		json
+//	@param			q			query		string				false	"Text the name must contain, ignoring case"
+//	@param			page		query		int					false	"Page number (default is 1)"
+//	@param			per_page	query		int					false	"Number of items per page (default is 10, max is 100)"
+//	@success		200			{object}	pagination.Pages	"Paginated list of people"
+//	@failure		500			{object}	err.Problem
+//	@router			/people [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	pages := pagination.NewFromRequest(r, -1)
+
+	page, err := a.repository.List(pages.Page, pages.PerPage, strings.TrimSpace(r.URL.Query().Get("q")))
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to retrieve people from repository")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	w.Header().Set("Link", page.BuildLinkHeader(r.URL.String(), pagination.DefaultPageSize))
+	render.WriteJSON(w, r, a.logger, http.StatusOK, page)
+}
+
+// Create godoc
+//
+//	@summary		Create person
+//	@description	Create a person to credit on songs.
+//	@tags			people
+//	@accept			json
+//	@produce		json
+//	@param			body	body		Request	true	"Person details"
+//	@success		201		{object}	Person
+//	@failure		400		{object}	err.Problem
+//	@failure		401		{object}	err.Problem
+//	@failure		403		{object}	err.Problem
+//	@failure		422		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/people [post]
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	req := &Request{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	p := req.ToModel()
+	p.ID = uuid.New()
+
+	p, err := a.repository.Create(p)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to create person")
+		e.ServerError(w, r, e.RespDBDataInsertFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", p.ID.String()).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("New person created")
+
+	w.Header().Set("Location", "/v1/people/"+p.ID.String())
+	render.WriteJSON(w, r, a.logger, http.StatusCreated, p)
+}
+
+// Read godoc
+//
+//	@summary		Read person
+//	@description	Read a person. Their songs are listed by GET / with a role filter, e.g. ?lyricist={id}.
+//	@tags			people
+//	@produce		json
+//	@param			id	path		string	true	"Person ID"
+//	@success		200	{object}	Person
+//	@failure		400	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@router			/people/{id} [get]
+func (a *API) Read(w http.ResponseWriter, r *http.Request) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+
+	p, err := a.repository.Read(id)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return
+	}
+
+	render.WriteJSON(w, r, a.logger, http.StatusOK, p)
+}
+
+// Update godoc
+//
+//	@summary		Update person
+//	@description	Rename a person.
+//	@tags			people
+//	@accept			json
+//	@param			id		path	string	true	"Person ID"
+//	@param			body	body	Request	true	"Person details"
+//	@success		200
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		422	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/people/{id} [put]
+func (a *API) Update(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+
+	req := &Request{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	p := req.ToModel()
+	p.ID = id
+
+	rows, err := a.repository.Update(p)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataUpdateFailure)
+		return
+	}
+	if rows == 0 {
+		e.NotFound(w, r, e.RespPersonNotFound)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Person updated")
+}
+
+// Delete godoc
+//
+//	@summary		Delete person
+//	@description	Delete a person. People still credited on songs cannot be deleted.
+//	@tags			people
+//	@param			id	path	string	true	"Person ID"
+//	@success		200
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		409	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/people/{id} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := urlID(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := a.repository.Delete(id)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataRemoveFailure)
+		return
+	}
+	if rows == 0 {
+		e.NotFound(w, r, e.RespPersonNotFound)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Person deleted")
+}
+
+// SongCredits godoc
+//
+//	@summary		List song credits
+//	@description	List the people credited on a song, ordered by role, then by name.
+//	@tags			people
+//	@produce		json
+//	@param			id	path		string	true	"Song ID"
+//	@success		200	{array}		Credit
+//	@failure		400	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@router			/{id}/credits [get]
+func (a *API) SongCredits(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.song(w, r)
+	if !ok {
+		return
+	}
+
+	credits, err := a.repository.SongCredits(id)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return
+	}
+
+	render.WriteJSON(w, r, a.logger, http.StatusOK, credits)
+}
+
+// SetSongCredits godoc
+//
+//	@summary		Set song credits
+//	@description	Replace a song's credits. A person may hold several roles but each role once, and the shares of
+//	@description	each role may add up to at most 100%.
+//	@tags			people
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string			true	"Song ID"
+//	@param			body	body		CreditsRequest	true	"Credits"
+//	@success		200		{array}		Credit
+//	@failure		400		{object}	err.Problem
+//	@failure		401		{object}	err.Problem
+//	@failure		403		{object}	err.Problem
+//	@failure		404		{object}	err.Problem
+//	@failure		422		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/{id}/credits [put]
+func (a *API) SetSongCredits(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := a.song(w, r)
+	if !ok {
+		return
+	}
+
+	req := &CreditsRequest{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+	if person, dup := req.Duplicate(); dup {
+		e.Invalid(w, r, "credits", "credit_duplicate", person)
+		return
+	}
+	if role, over := req.Overallotted(); over {
+		e.Invalid(w, r, "credits", "share_total", role)
+		return
+	}
+
+	err := a.repository.SetSongCredits(id, req.ToModel(id))
+	var unknown *UnknownError
+	if errors.As(err, &unknown) {
+		e.Invalid(w, r, "credits", "person_exists", strings.Join(unknown.IDs, ", "))
+		return
+	}
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataUpdateFailure)
+		return
+	}
+
+	credits, err := a.repository.SongCredits(id)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Int("credits", len(credits)).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Song credits set")
+	render.WriteJSON(w, r, a.logger, http.StatusOK, credits)
+}
+
+// song parses the song ID from the URL and checks the song exists.
+func (a *API) song(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, ok := urlID(w, r)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	if _, err := a.songs.Read(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			e.NotFound(w, r, e.RespSongNotFound)
+		} else {
+			a.fail(w, r, err, e.RespDBDataAccessFailure)
+		}
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+// fail answers with 404 for a missing person, 409 for deleting a credited one and with
+// the given problem otherwise.
+func (a *API) fail(w http.ResponseWriter, r *http.Request, err error, p e.Problem) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		e.NotFound(w, r, e.RespPersonNotFound)
+	case errors.Is(err, ErrHasCredits):
+		e.Conflict(w, r, e.RespPersonHasCredits)
+	default:
+		a.logger.Error().Str(l.KeyReqID, ctxUtil.RequestID(r.Context())).Err(err).Msg("Person query failed")
+		e.ServerError(w, r, p)
+	}
+}
+
+func urlID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
+		return uuid.Nil, false
+	}
+	return id, true
+}
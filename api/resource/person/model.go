@@ -0,0 +1,124 @@
+package person
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+
+	"songs/api/resource/song"
+)
+
+type Person struct {
+	ID   uuid.UUID `gorm:"primarykey" json:"id"`
+	Name string    `gorm:"column:name" json:"name"`
+}
+
+func (Person) TableName() string {
+	return "people"
+}
+
+// Credit names a person with a role on a song. Share is the person's percentage of the
+// role's rights; roles without rights, such as a producer's, may leave it out.
+type Credit struct {
+	SongID   uuid.UUID `gorm:"column:song_id;primarykey" json:"-"`
+	PersonID uuid.UUID `gorm:"column:person_id;primarykey" json:"-"`
+	Role     string    `gorm:"column:role;primarykey" json:"role"`
+	Share    *float64  `gorm:"column:share" json:"share,omitempty"`
+	// Person is only filled when listing a song's credits.
+	Person Person `gorm:"-" json:"person"`
+}
+
+func (Credit) TableName() string {
+	return "song_credits"
+}
+
+type Request struct {
+	Name string `json:"name" form:"required,max=255"`
+}
+
+type CreditRequest struct {
+	PersonID string   `json:"person_id" form:"required,uuid"`
+	Role     string   `json:"role" form:"required,oneof=lyricist composer producer featured_artist"`
+	Share    *float64 `json:"share" form:"omitempty,gte=0,lte=100"`
+}
+
+type CreditsRequest struct {
+	Credits []CreditRequest `json:"credits" form:"max=100,dive"`
+}
+
+// creditRow is one row of the credits query, which joins credits with their people.
+type creditRow struct {
+	PersonID uuid.UUID
+	Name     string
+	Role     string
+	Share    *float64
+}
+
+func (r *Request) ToModel() *Person {
+	return &Person{
+		Name: r.Name,
+	}
+}
+
+// ToModel converts the request into the song's credits. The person IDs must be valid.
+func (r *CreditsRequest) ToModel(songID uuid.UUID) []Credit {
+	credits := make([]Credit, len(r.Credits))
+	for i, c := range r.Credits {
+		credits[i] = Credit{
+			SongID:   songID,
+			PersonID: uuid.MustParse(c.PersonID),
+			Role:     c.Role,
+			Share:    c.Share,
+		}
+	}
+
+	return credits
+}
+
+// Duplicate returns the first person credited twice with the same role, if any.
+func (r *CreditsRequest) Duplicate() (string, bool) {
+	seen := map[CreditRequest]bool{}
+	for _, c := range r.Credits {
+		key := CreditRequest{PersonID: c.PersonID, Role: c.Role}
+		if seen[key] {
+			return c.PersonID, true
+		}
+		seen[key] = true
+	}
+
+	return "", false
+}
+
+// Overallotted returns the first role whose shares add up to more than 100%, if any.
+func (r *CreditsRequest) Overallotted() (string, bool) {
+	totals := map[string]float64{}
+	for _, c := range r.Credits {
+		if c.Share != nil {
+			totals[c.Role] += *c.Share
+		}
+	}
+
+	for _, role := range song.CreditRoles {
+		// The margin absorbs float rounding, so 33.3 + 33.3 + 33.4 is not over.
+		if totals[role] > 100+1e-9 {
+			return role, true
+		}
+	}
+
+	return "", false
+}
+
+// sortCredits orders credits by role, as listed in song.CreditRoles, then by name.
+func sortCredits(credits []Credit) {
+	rank := make(map[string]int, len(song.CreditRoles))
+	for i, role := range song.CreditRoles {
+		rank[role] = i
+	}
+
+	sort.SliceStable(credits, func(i, j int) bool {
+		if credits[i].Role != credits[j].Role {
+			return rank[credits[i].Role] < rank[credits[j].Role]
+		}
+		return credits[i].Person.Name < credits[j].Person.Name
+	})
+}
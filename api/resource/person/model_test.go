@@ -0,0 +1,91 @@
+package person_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"songs/api/resource/person"
+	testUtil "songs/util/test"
+	"songs/util/validator"
+)
+
+func share(v float64) *float64 {
+	return &v
+}
+
+func TestCreditsRequest_Overallotted(t *testing.T) {
+	t.Parallel()
+
+	a, b, c := uuid.NewString(), uuid.NewString(), uuid.NewString()
+
+	tests := []struct {
+		name     string
+		credits  []person.CreditRequest
+		expected string
+	}{
+		{
+			name: "full split",
+			credits: []person.CreditRequest{
+				{PersonID: a, Role: "lyricist", Share: share(33.3)},
+				{PersonID: b, Role: "lyricist", Share: share(33.3)},
+				{PersonID: c, Role: "lyricist", Share: share(33.4)},
+				{PersonID: a, Role: "composer", Share: share(100)},
+				{PersonID: b, Role: "producer"},
+			},
+		},
+		{
+			name: "over",
+			credits: []person.CreditRequest{
+				{PersonID: a, Role: "lyricist", Share: share(50)},
+				{PersonID: a, Role: "composer", Share: share(60)},
+				{PersonID: b, Role: "composer", Share: share(50)},
+			},
+			expected: "composer",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := person.CreditsRequest{Credits: tc.credits}
+			testUtil.NoError(t, validator.New().Struct(req))
+
+			role, _ := req.Overallotted()
+			testUtil.Equal(t, tc.expected, role)
+		})
+	}
+}
+
+func TestCreditsRequest_Duplicate(t *testing.T) {
+	t.Parallel()
+
+	a := uuid.NewString()
+	req := person.CreditsRequest{Credits: []person.CreditRequest{
+		{PersonID: a, Role: "lyricist"},
+		{PersonID: a, Role: "composer"},
+	}}
+
+	_, dup := req.Duplicate()
+	testUtil.Equal(t, false, dup)
+
+	req.Credits = append(req.Credits, person.CreditRequest{PersonID: a, Role: "lyricist", Share: share(10)})
+	id, dup := req.Duplicate()
+	testUtil.Equal(t, true, dup)
+	testUtil.Equal(t, a, id)
+}
+
+func TestCreditRequest_Validation(t *testing.T) {
+	t.Parallel()
+
+	vr := validator.New()
+
+	err := vr.Struct(person.CreditRequest{PersonID: uuid.NewString(), Role: "drummer"})
+	testUtil.Equal(t, "role must be one of [lyricist composer producer featured_artist]", validator.ToErrResponse(err).Errors[0])
+
+	err = vr.Struct(person.CreditRequest{PersonID: uuid.NewString(), Role: "lyricist", Share: share(120)})
+	testUtil.Equal(t, "share must be 100 or less", validator.ToErrResponse(err).Errors[0])
+}
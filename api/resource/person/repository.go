@@ -0,0 +1,187 @@
+package person
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/pkg/pagination"
+)
+
+var ErrHasCredits = errors.New("person has credits")
+
+// UnknownError lists person IDs that do not exist.
+type UnknownError struct {
+	IDs []string
+}
+
+func (e *UnknownError) Error() string {
+	return "unknown people"
+}
+
+type Repository struct {
+	db     *gorm.DB
+	logger *zerolog.Logger
+}
+
+func NewRepository(db *gorm.DB, l *zerolog.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: l,
+	}
+}
+
+// List returns a page of people ordered by name, optionally only those whose name
+// contains search, ignoring case.
+func (r *Repository) List(page, pageSize int, search string) (*pagination.Pages, error) {
+	var people []Person
+	var total int64
+
+	query := r.db.Model(&Person{})
+	if search != "" {
+		query = query.Where("LOWER(name) LIKE ?", "%"+escapeLike(strings.ToLower(search))+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	pages := pagination.New(page, pageSize, int(total))
+	if err := query.Order("name, id").Offset(pages.Offset()).Limit(pages.Limit()).Find(&people).Error; err != nil {
+		return nil, err
+	}
+
+	r.logger.Debug().Msgf("Retrieved %d people for page: %d", len(people), pages.Page)
+
+	pages.Items = people
+	return pages, nil
+}
+
+func (r *Repository) Create(p *Person) (*Person, error) {
+	r.logger.Debug().Msgf("Attempting to create a new person: %+v", p)
+
+	if err := r.db.Create(p).Error; err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *Repository) Read(id uuid.UUID) (*Person, error) {
+	p := &Person{}
+	if err := r.db.Where("id = ?", id).First(p).Error; err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *Repository) Update(p *Person) (int64, error) {
+	r.logger.Debug().Msgf("Attempting to update person with ID: %s, data: %+v", p.ID, p)
+
+	result := r.db.Model(&Person{}).Select("Name").Where("id = ?", p.ID).Updates(p)
+	return result.RowsAffected, result.Error
+}
+
+// Delete removes a person without credits; it returns ErrHasCredits otherwise.
+func (r *Repository) Delete(id uuid.UUID) (int64, error) {
+	r.logger.Debug().Msgf("Attempting to delete person with ID: %s", id.String())
+
+	var credits int64
+	if err := r.db.Model(&Credit{}).Where("person_id = ?", id).Count(&credits).Error; err != nil {
+		return 0, err
+	}
+	if credits > 0 {
+		return 0, ErrHasCredits
+	}
+
+	result := r.db.Where("id = ?", id).Delete(&Person{})
+	return result.RowsAffected, result.Error
+}
+
+// SongCredits returns the song's credits ordered by role, then by name.
+func (r *Repository) SongCredits(songID uuid.UUID) ([]Credit, error) {
+	var rows []creditRow
+	err := r.db.Table("song_credits AS sc").
+		Select("sc.person_id, p.name, sc.role, sc.share").
+		Joins("JOIN people AS p ON p.id = sc.person_id").
+		Where("sc.song_id = ?", songID).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	credits := make([]Credit, len(rows))
+	for i, row := range rows {
+		credits[i] = Credit{
+			SongID:   songID,
+			PersonID: row.PersonID,
+			Role:     row.Role,
+			Share:    row.Share,
+			Person:   Person{ID: row.PersonID, Name: row.Name},
+		}
+	}
+
+	sortCredits(credits)
+	return credits, nil
+}
+
+// SetSongCredits replaces the song's credits. It returns an *UnknownError when any of the
+// people does not exist.
+func (r *Repository) SetSongCredits(songID uuid.UUID, credits []Credit) error {
+	r.logger.Debug().Msgf("Setting credits of song %s: %+v", songID, credits)
+
+	ids := make([]uuid.UUID, len(credits))
+	for i, c := range credits {
+		ids[i] = c.PersonID
+	}
+
+	var found []uuid.UUID
+	if len(ids) > 0 {
+		if err := r.db.Model(&Person{}).Where("id IN ?", ids).Pluck("id", &found).Error; err != nil {
+			return err
+		}
+	}
+
+	if missing := missingIDs(ids, found); len(missing) > 0 {
+		return &UnknownError{IDs: missing}
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("song_id = ?", songID).Delete(&Credit{}).Error; err != nil {
+			return err
+		}
+		if len(credits) == 0 {
+			return nil
+		}
+
+		return tx.Create(&credits).Error
+	})
+}
+
+func missingIDs(ids, found []uuid.UUID) []string {
+	present := make(map[uuid.UUID]bool, len(found))
+	for _, id := range found {
+		present[id] = true
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if !present[id] {
+			missing = append(missing, id.String())
+			present[id] = true
+		}
+	}
+
+	sort.Strings(missing)
+	return missing
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
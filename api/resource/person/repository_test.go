@@ -0,0 +1,57 @@
+package person_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"songs/api/resource/person"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+var testLogger = zerolog.Nop()
+
+func TestRepository_Delete_HasCredits(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := person.NewRepository(db, &testLogger)
+
+	id := uuid.New()
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM \"song_credits\" WHERE person_id = \\$1").
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	_, err = repo.Delete(id)
+	testUtil.Equal(t, true, errors.Is(err, person.ErrHasCredits))
+}
+
+func TestRepository_SetSongCredits_UnknownPerson(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := person.NewRepository(db, &testLogger)
+
+	songID, known, unknown := uuid.New(), uuid.New(), uuid.New()
+	mock.ExpectQuery("^SELECT \"id\" FROM \"people\" WHERE id IN \\(\\$1,\\$2\\)").
+		WithArgs(known, unknown).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(known))
+
+	err = repo.SetSongCredits(songID, []person.Credit{
+		{SongID: songID, PersonID: known, Role: "lyricist"},
+		{SongID: songID, PersonID: unknown, Role: "composer"},
+	})
+
+	var unknownErr *person.UnknownError
+	testUtil.Equal(t, true, errors.As(err, &unknownErr))
+	testUtil.Equal(t, unknown.String(), unknownErr.IDs[0])
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,400 @@
+package playlist
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/common/decode"
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/api/resource/common/render"
+	"songs/pkg/pagination"
+	ctxUtil "songs/util/ctx"
+)
+
+type API struct {
+	logger     *zerolog.Logger
+	validator  *validator.Validate
+	repository *Repository
+}
+
+func New(logger *zerolog.Logger, validator *validator.Validate, db *gorm.DB) *API {
+	return &API{
+		logger:     logger,
+		validator:  validator,
+		repository: NewRepository(db, logger),
+	}
+}
+
+// List godoc
+//
+//	@summary		List playlists
+//	@description	List playlists ordered by name, with the number of songs in each.
+//	@tags			playlists
+//	@produce		json
+//	@param			page		query		int					false	"Page number (default is 1)"
+//	@param			per_page	query		int					false	"Number of items per page (default is 10, max is 100)"
+//	@success		200			{object}	pagination.Pages	"Paginated list of playlists"
+//	@failure		500			{object}	err.Problem
+//	@router			/playlists [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	pages := pagination.NewFromRequest(r, -1)
+
+	page, err := a.repository.List(pages.Page, pages.PerPage)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to retrieve playlists from repository")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	w.Header().Set("Link", page.BuildLinkHeader(r.URL.String(), pagination.DefaultPageSize))
+	render.WriteJSON(w, r, a.logger, http.StatusOK, page)
+}
+
+// Create godoc
+//
+//	@summary		Create playlist
+//	@description	Create an empty playlist.
+//	@tags			playlists
+//	@accept			json
+//	@produce		json
+//	@param			body	body		Request		true	"Playlist details"
+//	@success		201		{object}	Playlist
+//	@failure		400		{object}	err.Problem
+//	@failure		401		{object}	err.Problem
+//	@failure		403		{object}	err.Problem
+//	@failure		422		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/playlists [post]
+func (a *API) Create(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	req := &Request{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	p := req.ToModel()
+	p.ID = uuid.New()
+
+	p, err := a.repository.Create(p)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to create playlist")
+		e.ServerError(w, r, e.RespDBDataInsertFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", p.ID.String()).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("New playlist created")
+
+	w.Header().Set("Location", "/v1/playlists/"+p.ID.String())
+	render.WriteJSON(w, r, a.logger, http.StatusCreated, p)
+}
+
+// Read godoc
+//
+//	@summary		Read playlist
+//	@description	Read a playlist's details. Its songs are listed by /playlists/{id}/items.
+//	@tags			playlists
+//	@produce		json
+//	@param			id	path		string	true	"Playlist ID"
+//	@success		200	{object}	Playlist
+//	@failure		400	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@router			/playlists/{id} [get]
+func (a *API) Read(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := urlID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	p, err := a.repository.Read(id)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return
+	}
+
+	a.logger.Debug().Str(l.KeyReqID, reqID).Msgf("Retrieved playlist: %+v", p)
+	render.WriteJSON(w, r, a.logger, http.StatusOK, p)
+}
+
+// Update godoc
+//
+//	@summary		Update playlist
+//	@description	Rename a playlist or change its description.
+//	@tags			playlists
+//	@accept			json
+//	@param			id		path	string	true	"Playlist ID"
+//	@param			body	body	Request	true	"Playlist details"
+//	@success		200
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		422	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/playlists/{id} [put]
+func (a *API) Update(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := urlID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	req := &Request{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	p := req.ToModel()
+	p.ID = id
+
+	rows, err := a.repository.Update(p)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to update playlist")
+		e.ServerError(w, r, e.RespDBDataUpdateFailure)
+		return
+	}
+	if rows == 0 {
+		e.NotFound(w, r, e.RespPlaylistNotFound)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Playlist updated")
+}
+
+// Delete godoc
+//
+//	@summary		Delete playlist
+//	@description	Delete a playlist. The songs in it are kept.
+//	@tags			playlists
+//	@param			id	path	string	true	"Playlist ID"
+//	@success		200
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/playlists/{id} [delete]
+func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := urlID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	rows, err := a.repository.Delete(id)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to delete playlist")
+		e.ServerError(w, r, e.RespDBDataRemoveFailure)
+		return
+	}
+	if rows == 0 {
+		e.NotFound(w, r, e.RespPlaylistNotFound)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Playlist deleted")
+}
+
+// Items godoc
+//
+//	@summary		List playlist contents
+//	@description	List the playlist's songs in playlist order.
+//	@tags			playlists
+//	@produce		json
+//	@param			id			path		string				true	"Playlist ID"
+//	@param			page		query		int					false	"Page number (default is 1)"
+//	@param			per_page	query		int					false	"Number of items per page (default is 10, max is 100)"
+//	@success		200			{object}	pagination.Pages	"Paginated list of entries"
+//	@failure		400			{object}	err.Problem
+//	@failure		404			{object}	err.Problem
+//	@failure		500			{object}	err.Problem
+//	@router			/playlists/{id}/items [get]
+func (a *API) Items(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := urlID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	if _, err := a.repository.Read(id); err != nil {
+		a.fail(w, r, err, e.RespDBDataAccessFailure)
+		return
+	}
+
+	pages := pagination.NewFromRequest(r, -1)
+	page, err := a.repository.Items(id, pages.Page, pages.PerPage)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to retrieve playlist items from repository")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	w.Header().Set("Link", page.BuildLinkHeader(r.URL.String(), pagination.DefaultPageSize))
+	render.WriteJSON(w, r, a.logger, http.StatusOK, page)
+}
+
+// AddItem godoc
+//
+//	@summary		Add song to playlist
+//	@description	Insert a song at the given position, or at the end when no position is given.
+//	@tags			playlists
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string		true	"Playlist ID"
+//	@param			body	body		ItemRequest	true	"Song and position"
+//	@success		201		{object}	Item
+//	@failure		400		{object}	err.Problem
+//	@failure		401		{object}	err.Problem
+//	@failure		403		{object}	err.Problem
+//	@failure		404		{object}	err.Problem
+//	@failure		422		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/playlists/{id}/items [post]
+func (a *API) AddItem(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := urlID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	req := &ItemRequest{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	item, err := a.repository.AddItem(id, uuid.MustParse(req.SongID), req.Position)
+	if errors.Is(err, ErrSongNotFound) {
+		e.Invalid(w, r, "song_id", "song_exists", "")
+		return
+	}
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataInsertFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Str("song", req.SongID).Int("position", item.Position).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Song added to playlist")
+	render.WriteJSON(w, r, a.logger, http.StatusCreated, item)
+}
+
+// MoveItem godoc
+//
+//	@summary		Move playlist item
+//	@description	Move an item to another position; the items in between shift by one.
+//	@tags			playlists
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string		true	"Playlist ID"
+//	@param			itemID	path		string		true	"Item ID"
+//	@param			body	body		MoveRequest	true	"New position"
+//	@success		200		{object}	Item
+//	@failure		400		{object}	err.Problem
+//	@failure		401		{object}	err.Problem
+//	@failure		403		{object}	err.Problem
+//	@failure		404		{object}	err.Problem
+//	@failure		422		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/playlists/{id}/items/{itemID} [patch]
+func (a *API) MoveItem(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := urlID(w, r, "id")
+	if !ok {
+		return
+	}
+	itemID, ok := urlID(w, r, "itemID")
+	if !ok {
+		return
+	}
+
+	req := &MoveRequest{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
+
+	item, err := a.repository.MoveItem(id, itemID, req.Position)
+	if err != nil {
+		a.fail(w, r, err, e.RespDBDataUpdateFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Str("item", itemID.String()).Int("position", item.Position).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Playlist item moved")
+	render.WriteJSON(w, r, a.logger, http.StatusOK, item)
+}
+
+// RemoveItem godoc
+//
+//	@summary		Remove playlist item
+//	@description	Remove an item from the playlist; the items after it move up.
+//	@tags			playlists
+//	@param			id		path	string	true	"Playlist ID"
+//	@param			itemID	path	string	true	"Item ID"
+//	@success		200
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/playlists/{id}/items/{itemID} [delete]
+func (a *API) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := urlID(w, r, "id")
+	if !ok {
+		return
+	}
+	itemID, ok := urlID(w, r, "itemID")
+	if !ok {
+		return
+	}
+
+	if err := a.repository.RemoveItem(id, itemID); err != nil {
+		a.fail(w, r, err, e.RespDBDataRemoveFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Str("item", itemID.String()).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Playlist item removed")
+}
+
+// fail answers with 404 for a missing playlist or item and with the given problem otherwise.
+func (a *API) fail(w http.ResponseWriter, r *http.Request, err error, p e.Problem) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		e.NotFound(w, r, e.RespPlaylistNotFound)
+	case errors.Is(err, ErrItemNotFound):
+		e.NotFound(w, r, e.RespPlaylistItemNotFound)
+	default:
+		a.logger.Error().Str(l.KeyReqID, ctxUtil.RequestID(r.Context())).Err(err).Msg("Playlist query failed")
+		e.ServerError(w, r, p)
+	}
+}
+
+func urlID(w http.ResponseWriter, r *http.Request, param string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, param))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
+		return uuid.Nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,102 @@
+package playlist
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Playlist struct {
+	ID          uuid.UUID `gorm:"primarykey" json:"id"`
+	Name        string    `gorm:"column:name" json:"name"`
+	Description string    `gorm:"column:description" json:"description"`
+	// SongCount is only filled by queries that count the playlist's items.
+	SongCount int       `gorm:"column:song_count;->" json:"song_count"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (Playlist) TableName() string {
+	return "playlists"
+}
+
+// Item places a song in a playlist. Positions start at 1 and have no gaps; the same song
+// may appear more than once.
+type Item struct {
+	ID         uuid.UUID `gorm:"primarykey" json:"id"`
+	PlaylistID uuid.UUID `gorm:"column:playlist_id" json:"playlist_id"`
+	SongID     uuid.UUID `gorm:"column:song_id" json:"song_id"`
+	Position   int       `gorm:"column:position" json:"position"`
+	AddedAt    time.Time `gorm:"column:added_at;autoCreateTime" json:"added_at"`
+}
+
+func (Item) TableName() string {
+	return "playlist_items"
+}
+
+// Entry is an item as listed in the playlist's contents, with the song it refers to.
+type Entry struct {
+	ID       uuid.UUID `json:"id"`
+	Position int       `json:"position"`
+	AddedAt  time.Time `json:"added_at"`
+	Song     Song      `json:"song"`
+}
+
+// Song is the part of a song shown in playlist contents.
+type Song struct {
+	ID          uuid.UUID `json:"id"`
+	Group       string    `json:"group"`
+	Song        string    `json:"song"`
+	ReleaseDate string    `json:"release_date"`
+	Link        string    `json:"link"`
+}
+
+type Request struct {
+	Name        string `json:"name" form:"required,max=255"`
+	Description string `json:"description" form:"max=2000"`
+}
+
+type ItemRequest struct {
+	SongID string `json:"song_id" form:"required,uuid"`
+	// Position is where to insert the song; it goes to the end when omitted or past the end.
+	Position int `json:"position" form:"omitempty,gte=1"`
+}
+
+type MoveRequest struct {
+	// Position is where to move the item; positions past the end move it to the end.
+	Position int `json:"position" form:"required,gte=1"`
+}
+
+func (r *Request) ToModel() *Playlist {
+	return &Playlist{
+		Name:        r.Name,
+		Description: r.Description,
+	}
+}
+
+// entryRow is one row of the contents query, which joins items with their songs.
+type entryRow struct {
+	ID          uuid.UUID
+	Position    int
+	AddedAt     time.Time
+	SongID      uuid.UUID
+	GroupName   string
+	SongName    string
+	ReleaseDate string
+	Link        string
+}
+
+func (r entryRow) toEntry() Entry {
+	return Entry{
+		ID:       r.ID,
+		Position: r.Position,
+		AddedAt:  r.AddedAt,
+		Song: Song{
+			ID:          r.SongID,
+			Group:       r.GroupName,
+			Song:        r.SongName,
+			ReleaseDate: r.ReleaseDate,
+			Link:        r.Link,
+		},
+	}
+}
@@ -0,0 +1,255 @@
+package playlist
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"songs/pkg/pagination"
+)
+
+var (
+	ErrItemNotFound = errors.New("playlist item not found")
+	ErrSongNotFound = errors.New("song not found")
+)
+
+const withSongCount = "playlists.*, (SELECT COUNT(*) FROM playlist_items WHERE playlist_items.playlist_id = playlists.id) AS song_count"
+
+type Repository struct {
+	db     *gorm.DB
+	logger *zerolog.Logger
+}
+
+func NewRepository(db *gorm.DB, l *zerolog.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: l,
+	}
+}
+
+func (r *Repository) List(page, pageSize int) (*pagination.Pages, error) {
+	var playlists []Playlist
+	var total int64
+
+	if err := r.db.Model(&Playlist{}).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	pages := pagination.New(page, pageSize, int(total))
+	if err := r.db.Select(withSongCount).Order("name, id").Offset(pages.Offset()).Limit(pages.Limit()).Find(&playlists).Error; err != nil {
+		return nil, err
+	}
+
+	r.logger.Debug().Msgf("Retrieved %d playlists for page: %d", len(playlists), pages.Page)
+
+	pages.Items = playlists
+	return pages, nil
+}
+
+func (r *Repository) Create(p *Playlist) (*Playlist, error) {
+	r.logger.Debug().Msgf("Attempting to create a new playlist: %+v", p)
+
+	if err := r.db.Create(p).Error; err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *Repository) Read(id uuid.UUID) (*Playlist, error) {
+	p := &Playlist{}
+	if err := r.db.Select(withSongCount).Where("id = ?", id).First(p).Error; err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (r *Repository) Update(p *Playlist) (int64, error) {
+	r.logger.Debug().Msgf("Attempting to update playlist with ID: %s, data: %+v", p.ID, p)
+
+	result := r.db.Model(&Playlist{}).
+		Select("Name", "Description", "UpdatedAt").
+		Where("id = ?", p.ID).
+		Updates(p)
+
+	return result.RowsAffected, result.Error
+}
+
+// Delete removes the playlist along with its items.
+func (r *Repository) Delete(id uuid.UUID) (int64, error) {
+	r.logger.Debug().Msgf("Attempting to delete playlist with ID: %s", id.String())
+
+	result := r.db.Where("id = ?", id).Delete(&Playlist{})
+	return result.RowsAffected, result.Error
+}
+
+// Items returns a page of the playlist's entries in playlist order.
+func (r *Repository) Items(id uuid.UUID, page, pageSize int) (*pagination.Pages, error) {
+	var total int64
+	if err := r.db.Model(&Item{}).Where("playlist_id = ?", id).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	pages := pagination.New(page, pageSize, int(total))
+
+	var rows []entryRow
+	err := r.db.Table("playlist_items AS i").
+		Select("i.id, i.position, i.added_at, s.id AS song_id, s.group_name, s.song_name, "+
+			"COALESCE(s.release_date, '') AS release_date, COALESCE(s.link, '') AS link").
+		Joins("JOIN songs AS s ON s.id = i.song_id").
+		Where("i.playlist_id = ?", id).
+		Order("i.position").
+		Offset(pages.Offset()).
+		Limit(pages.Limit()).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, len(rows))
+	for i, row := range rows {
+		entries[i] = row.toEntry()
+	}
+
+	pages.Items = entries
+	return pages, nil
+}
+
+// AddItem inserts the song at the given position, shifting later items down. Positions
+// below 1 or past the end append the song. It returns gorm.ErrRecordNotFound when the
+// playlist does not exist and ErrSongNotFound when the song does not.
+func (r *Repository) AddItem(playlistID, songID uuid.UUID, position int) (*Item, error) {
+	item := &Item{ID: uuid.New(), PlaylistID: playlistID, SongID: songID}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		count, err := lock(tx, playlistID)
+		if err != nil {
+			return err
+		}
+
+		var songs int64
+		if err := tx.Table("songs").Where("id = ?", songID).Count(&songs).Error; err != nil {
+			return err
+		}
+		if songs == 0 {
+			return ErrSongNotFound
+		}
+
+		item.Position = clamp(position, count+1)
+		if err := tx.Model(&Item{}).
+			Where("playlist_id = ? AND position >= ?", playlistID, item.Position).
+			Update("position", gorm.Expr("position + 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+
+		return touch(tx, playlistID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.logger.Debug().Msgf("Added song %s to playlist %s at position %d", songID, playlistID, item.Position)
+	return item, nil
+}
+
+// MoveItem moves the item to the given position, shifting the items in between. Positions
+// past the end move it to the end.
+func (r *Repository) MoveItem(playlistID, itemID uuid.UUID, position int) (*Item, error) {
+	item := &Item{}
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		count, err := lock(tx, playlistID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ? AND playlist_id = ?", itemID, playlistID).First(item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrItemNotFound
+			}
+			return err
+		}
+
+		from, to := item.Position, clamp(position, count)
+		if from == to {
+			return nil
+		}
+
+		shift := tx.Model(&Item{}).Where("playlist_id = ?", playlistID)
+		if to < from {
+			shift = shift.Where("position >= ? AND position < ?", to, from).Update("position", gorm.Expr("position + 1"))
+		} else {
+			shift = shift.Where("position > ? AND position <= ?", from, to).Update("position", gorm.Expr("position - 1"))
+		}
+		if shift.Error != nil {
+			return shift.Error
+		}
+
+		item.Position = to
+		if err := tx.Model(item).Update("position", to).Error; err != nil {
+			return err
+		}
+
+		return touch(tx, playlistID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+// RemoveItem deletes the item; the items after it move up a position.
+func (r *Repository) RemoveItem(playlistID, itemID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lock(tx, playlistID); err != nil {
+			return err
+		}
+
+		// The delete trigger closes the gap and touches the playlist.
+		result := tx.Where("id = ? AND playlist_id = ?", itemID, playlistID).Delete(&Item{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrItemNotFound
+		}
+
+		return nil
+	})
+}
+
+// lock serialises changes to one playlist's items and returns how many it has.
+func lock(tx *gorm.DB, playlistID uuid.UUID) (int, error) {
+	p := &Playlist{}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", playlistID).First(p).Error; err != nil {
+		return 0, err
+	}
+
+	var count int64
+	if err := tx.Model(&Item{}).Where("playlist_id = ?", playlistID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+func touch(tx *gorm.DB, playlistID uuid.UUID) error {
+	return tx.Model(&Playlist{}).Where("id = ?", playlistID).Update("updated_at", time.Now()).Error
+}
+
+// clamp fits a requested position into 1..last, treating unset positions as last.
+func clamp(position, last int) int {
+	if position < 1 || position > last {
+		return last
+	}
+	return position
+}
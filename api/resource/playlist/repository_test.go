@@ -0,0 +1,159 @@
+package playlist_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/playlist"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+var testLogger = zerolog.Nop()
+
+func TestRepository_List(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := playlist.NewRepository(db, &testLogger)
+
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM \"playlists\"").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("^SELECT playlists.\\*, \\(SELECT COUNT\\(\\*\\) FROM playlist_items (.+) ORDER BY name, id").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "song_count"}).AddRow(uuid.New(), "Rehearsal", 3))
+
+	pages, err := repo.List(1, 10)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1, pages.TotalCount)
+	testUtil.Equal(t, 3, pages.Items.([]playlist.Playlist)[0].SongCount)
+}
+
+func TestRepository_Read_NotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := playlist.NewRepository(db, &testLogger)
+
+	id := uuid.New()
+	mock.ExpectQuery("^SELECT (.+) FROM \"playlists\" WHERE id = (.+)").
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	_, err = repo.Read(id)
+	testUtil.Equal(t, true, errors.Is(err, gorm.ErrRecordNotFound))
+}
+
+func TestRepository_AddItem(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := playlist.NewRepository(db, &testLogger)
+
+	playlistID, songID := uuid.New(), uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT (.+) FROM \"playlists\" WHERE id = (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(playlistID))
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM \"playlist_items\"").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM \"songs\"").
+		WithArgs(songID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec("^UPDATE \"playlist_items\" SET \"position\"=position \\+ 1 WHERE playlist_id = (.+) AND position >= (.+)").
+		WithArgs(playlistID, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^INSERT INTO \"playlist_items\"").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("^UPDATE \"playlists\" SET \"updated_at\"").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	item, err := repo.AddItem(playlistID, songID, 2)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 2, item.Position)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_AddItem_UnknownSong(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := playlist.NewRepository(db, &testLogger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT (.+) FROM \"playlists\" WHERE id = (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM \"playlist_items\"").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM \"songs\"").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+
+	_, err = repo.AddItem(uuid.New(), uuid.New(), 0)
+	testUtil.Equal(t, true, errors.Is(err, playlist.ErrSongNotFound))
+}
+
+func TestRepository_MoveItem(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := playlist.NewRepository(db, &testLogger)
+
+	playlistID, itemID := uuid.New(), uuid.New()
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT (.+) FROM \"playlists\" WHERE id = (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(playlistID))
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM \"playlist_items\"").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery("^SELECT (.+) FROM \"playlist_items\" WHERE id = (.+) AND playlist_id = (.+)").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "playlist_id", "position"}).AddRow(itemID, playlistID, 4))
+	mock.ExpectExec("^UPDATE \"playlist_items\" SET \"position\"=position \\+ 1 WHERE playlist_id = (.+) AND \\(position >= (.+) AND position < (.+)\\)").
+		WithArgs(playlistID, 1, 4).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("^UPDATE \"playlist_items\" SET \"position\"").
+		WithArgs(1, itemID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE \"playlists\" SET \"updated_at\"").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	item, err := repo.MoveItem(playlistID, itemID, 1)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1, item.Position)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_RemoveItem_NotFound(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := playlist.NewRepository(db, &testLogger)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT (.+) FROM \"playlists\" WHERE id = (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM \"playlist_items\"").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec("^DELETE FROM \"playlist_items\"").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err = repo.RemoveItem(uuid.New(), uuid.New())
+	testUtil.Equal(t, true, errors.Is(err, playlist.ErrItemNotFound))
+}
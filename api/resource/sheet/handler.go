@@ -0,0 +1,307 @@
+package sheet
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/api/resource/common/render"
+	"songs/api/resource/song"
+	"songs/pkg/chordpro"
+	ctxUtil "songs/util/ctx"
+)
+
+const (
+	MediaHTML = "text/html"
+	MediaPDF  = "application/pdf"
+
+	// MaxSongbookSize caps how many songs one songbook may hold.
+	MaxSongbookSize = 200
+
+	defaultSongbookTitle = "Songbook"
+)
+
+var formats = map[string]string{"html": MediaHTML, "pdf": MediaPDF}
+
+type API struct {
+	logger *zerolog.Logger
+	songs  *song.Repository
+}
+
+func New(logger *zerolog.Logger, db *gorm.DB) *API {
+	return &API{
+		logger: logger,
+		songs:  song.NewRepository(db, logger),
+	}
+}
+
+// Sheet godoc
+//
+//	@summary		Print lyric sheet
+//	@description	Render the song as a printable lyric sheet. The format follows the format parameter or else the Accept header.
+//	@tags			sheets
+//	@produce		html,application/pdf
+//	@param			id			path		string	true	"Song ID"
+//	@param			format		query		string	false	"Output format, overrides the Accept header"	Enums(html, pdf)
+//	@param			chords		query		bool	false	"Print chords above the lyrics when the song has ChordPro lyrics"
+//	@param			transpose	query		int		false	"Semitones to transpose the chords by, e.g. +2 or -3"
+//	@param			notation	query		string	false	"Spelling of transposed chords"	Enums(sharp, flat)
+//	@success		200			{string}	string	"Lyric sheet"
+//	@failure		400			{object}	err.Problem
+//	@failure		404			{object}	err.Problem
+//	@failure		406			{object}	err.Problem
+//	@failure		500			{object}	err.Problem
+//	@router			/{id}/sheet [get]
+func (a *API) Sheet(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Sheet function started")
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
+		return
+	}
+
+	media, opts, ok := parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	s, err := a.songs.Read(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			e.NotFound(w, r, e.RespSongNotFound)
+			return
+		}
+
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to access the song in the database")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	sheet, err := FromSong(s, opts)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Stored ChordPro lyrics are invalid")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	a.write(w, r, media, &Book{Title: sheet.Title, Sheets: []*Sheet{sheet}}, s.Group+" - "+s.Song)
+}
+
+// Songbook godoc
+//
+//	@summary		Print songbook
+//	@description	Render several songs as one printable document that opens with a table of contents. Songs are picked by ids, in the order given, or else by the List filters, ordered by group and title.
+//	@tags			sheets
+//	@produce		html,application/pdf
+//	@param			ids			query		string	false	"Comma-separated song IDs"
+//	@param			group		query		string	false	"Group name"
+//	@param			song		query		string	false	"Song name"
+//	@param			text		query		string	false	"Text to search within song lyrics"
+//	@param			releaseDate	query		string	false	"Release date"
+//	@param			year		query		string	false	"Release year"
+//	@param			language	query		string	false	"Detected language of the lyrics, e.g. ru"
+//	@param			link		query		string	false	"Song link"
+//	@param			genre		query		[]string	false	"Genre slugs; subgenres match too"	collectionFormat(multi)
+//	@param			genre_match	query		string	false	"Whether songs need all genres or any (default)"	Enums(all, any)
+//	@param			tag			query		[]string	false	"Tags"	collectionFormat(multi)
+//	@param			tag_match	query		string	false	"Whether songs need all tags or any (default)"	Enums(all, any)
+//	@param			title		query		string	false	"Songbook title (default is Songbook)"
+//	@param			format		query		string	false	"Output format, overrides the Accept header"	Enums(html, pdf)
+//	@param			chords		query		bool	false	"Print chords above the lyrics of songs with ChordPro lyrics"
+//	@param			transpose	query		int		false	"Semitones to transpose the chords by, e.g. +2 or -3"
+//	@param			notation	query		string	false	"Spelling of transposed chords"	Enums(sharp, flat)
+//	@success		200			{string}	string	"Songbook"
+//	@failure		400			{object}	err.Problem
+//	@failure		404			{object}	err.Problem
+//	@failure		406			{object}	err.Problem
+//	@failure		500			{object}	err.Problem
+//	@router			/songbook [get]
+func (a *API) Songbook(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Songbook function started")
+
+	media, opts, ok := parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	ids, err := parseIDs(q)
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+	if len(ids) > MaxSongbookSize {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(fmt.Sprintf("a songbook holds at most %d songs", MaxSongbookSize)))
+		return
+	}
+
+	filters, err := song.Filters(q)
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+
+	var songs []song.Song
+	if len(ids) > 0 {
+		songs, err = a.songs.ReadMany(ids)
+	} else {
+		songs, err = a.songs.Find(filters, MaxSongbookSize+1)
+	}
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to access the songs in the database")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	if missing := missingID(ids, songs); missing != "" {
+		e.NotFound(w, r, e.RespSongNotFound.WithDetail("song "+missing+" not found"))
+		return
+	}
+	if len(songs) == 0 {
+		e.NotFound(w, r, e.RespSongNotFound.WithDetail("no songs match the filter"))
+		return
+	}
+	if len(songs) > MaxSongbookSize {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(fmt.Sprintf("more than %d songs match the filter", MaxSongbookSize)))
+		return
+	}
+
+	book := &Book{Title: strings.TrimSpace(q.Get("title")), Sheets: make([]*Sheet, len(songs))}
+	if book.Title == "" {
+		book.Title = defaultSongbookTitle
+	}
+	for i := range songs {
+		if book.Sheets[i], err = FromSong(&songs[i], opts); err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Str("id", songs[i].ID.String()).Msg("Stored ChordPro lyrics are invalid")
+			e.ServerError(w, r, e.RespDBDataAccessFailure)
+			return
+		}
+	}
+
+	a.write(w, r, media, book, book.Title)
+}
+
+func (a *API) write(w http.ResponseWriter, r *http.Request, media string, b *Book, filename string) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	var buf bytes.Buffer
+	write, ext := WriteHTML, ".html"
+	if media == MediaPDF {
+		write, ext = WritePDF, ".pdf"
+	}
+	if err := write(&buf, b); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to render lyric sheet")
+		e.ServerError(w, r, e.RespSheetRenderFailure)
+		return
+	}
+
+	if media == MediaHTML {
+		media += "; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", media)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename + ext}))
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	if _, err := buf.WriteTo(w); err != nil {
+		a.logger.Warn().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to write lyric sheet")
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("format", ext[1:]).Int("songs", len(b.Sheets)).Msg("Lyric sheet sent")
+}
+
+// parseRequest reads the output format and layout options shared by both endpoints,
+// answering 400 or 406 itself when they are unusable.
+func parseRequest(w http.ResponseWriter, r *http.Request) (string, Options, bool) {
+	q := r.URL.Query()
+	opts := Options{}
+
+	w.Header().Add("Vary", "Accept")
+	media := ""
+	if format := q.Get("format"); format != "" {
+		if media = formats[format]; media == "" {
+			e.BadRequest(w, r, e.RespInvalidQuery.WithDetail("format must be html or pdf"))
+			return "", opts, false
+		}
+	} else if media = render.Negotiate(r.Header.Get("Accept"), []string{MediaHTML, MediaPDF}); media == "" {
+		e.NotAcceptable(w, r, e.RespNotAcceptable.WithDetail("supported media types: "+MediaHTML+", "+MediaPDF))
+		return "", opts, false
+	}
+
+	var err error
+	if v := q.Get("chords"); v != "" {
+		if opts.Chords, err = strconv.ParseBool(v); err != nil {
+			e.BadRequest(w, r, e.RespInvalidQuery.WithDetail("chords must be true or false"))
+			return "", opts, false
+		}
+	}
+
+	// A literal "+2" arrives as " 2" since "+" encodes a space in query strings.
+	if v := strings.TrimSpace(q.Get("transpose")); v != "" {
+		if opts.Transpose, err = strconv.Atoi(v); err != nil {
+			e.BadRequest(w, r, e.RespInvalidQuery.WithDetail("transpose must be a whole number of semitones"))
+			return "", opts, false
+		}
+	}
+
+	if opts.Notation, err = chordpro.ParseNotation(q.Get("notation")); err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return "", opts, false
+	}
+
+	return media, opts, true
+}
+
+// parseIDs reads song IDs given as ids=a,b or as repeated ids parameters, keeping the
+// first mention of each.
+func parseIDs(q url.Values) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	seen := map[uuid.UUID]bool{}
+	for _, v := range q["ids"] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s == "" {
+				continue
+			}
+
+			id, err := uuid.Parse(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid song id %q", s)
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids, nil
+}
+
+func missingID(ids []uuid.UUID, songs []song.Song) string {
+	found := make(map[uuid.UUID]bool, len(songs))
+	for _, s := range songs {
+		found[s.ID] = true
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			return id.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,18 @@
+package sheet
+
+import (
+	_ "embed"
+	"html/template"
+	"io"
+)
+
+//go:embed sheet.html
+var sheetHTML string
+
+var htmlTemplate = template.Must(template.New("sheet").Parse(sheetHTML))
+
+// WriteHTML writes the book as a standalone HTML page with print styles: each song starts
+// on a new page and stanzas are kept together.
+func WriteHTML(w io.Writer, b *Book) error {
+	return htmlTemplate.Execute(w, b)
+}
@@ -0,0 +1,181 @@
+package sheet
+
+import (
+	"strings"
+
+	"songs/api/resource/song"
+	"songs/pkg/chordpro"
+)
+
+// Line is a lyric line with the chords to print above it, if any.
+type Line struct {
+	Chords  string
+	Text    string
+	Comment bool
+}
+
+// Stanza is a block of lines printed together, optionally under a section label.
+type Stanza struct {
+	Label string
+	Lines []Line
+}
+
+// Sheet is one song laid out for printing.
+type Sheet struct {
+	ID          string
+	Title       string
+	Group       string
+	ReleaseDate string
+	Key         string
+	Capo        string
+	// Chords is set when the lines carry chords, which are then printed in a fixed-width font
+	// so they stay above the syllables they belong to.
+	Chords  bool
+	Stanzas []Stanza
+}
+
+// Book is a set of sheets printed as one document. Books of more than one sheet open with
+// a table of contents.
+type Book struct {
+	Title  string
+	Sheets []*Sheet
+}
+
+// Options select how a song is laid out.
+type Options struct {
+	Chords    bool
+	Transpose int
+	Notation  chordpro.Notation
+}
+
+// Anchor is the fragment the table of contents links the sheet with.
+func (s *Sheet) Anchor() string {
+	return "song-" + s.ID
+}
+
+// Details returns the line printed under the title: group, release date, key and capo.
+func (s *Sheet) Details() string {
+	details := []string{s.Group}
+	if s.ReleaseDate != "" {
+		details = append(details, s.ReleaseDate)
+	}
+	if s.Key != "" {
+		details = append(details, "Key: "+s.Key)
+	}
+	if s.Capo != "" {
+		details = append(details, "Capo: "+s.Capo)
+	}
+
+	return strings.Join(details, " · ")
+}
+
+// HasTOC reports whether the book is printed with a table of contents.
+func (b *Book) HasTOC() bool {
+	return len(b.Sheets) > 1
+}
+
+// FromSong lays out a song. With Options.Chords the song's ChordPro lyrics are used when it
+// has any, transposed as asked; otherwise its plain text is split into stanzas at blank lines.
+func FromSong(s *song.Song, o Options) (*Sheet, error) {
+	sheet := &Sheet{
+		ID:          s.ID.String(),
+		Title:       s.Song,
+		Group:       s.Group,
+		ReleaseDate: s.ReleaseDate,
+	}
+
+	if !o.Chords || s.ChordPro == "" {
+		sheet.Stanzas = textStanzas(s.Text)
+		return sheet, nil
+	}
+
+	doc, err := chordpro.Parse(strings.NewReader(s.ChordPro))
+	if err != nil {
+		return nil, err
+	}
+	if o.Transpose != 0 || o.Notation != chordpro.NotationAuto {
+		doc.Transpose(o.Transpose, o.Notation)
+	}
+
+	sheet.Chords = true
+	sheet.Key = doc.Meta["key"]
+	sheet.Capo = doc.Meta["capo"]
+	sheet.Stanzas = chordStanzas(doc)
+
+	return sheet, nil
+}
+
+func textStanzas(text string) []Stanza {
+	var stanzas []Stanza
+	var current []Line
+
+	flush := func() {
+		if len(current) > 0 {
+			stanzas = append(stanzas, Stanza{Lines: current})
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			flush()
+			continue
+		}
+		current = append(current, Line{Text: line})
+	}
+	flush()
+
+	return stanzas
+}
+
+// chordStanzas groups a ChordPro document into stanzas. Blank lines split stanzas outside
+// sections, each section is one labelled stanza, {chorus} prints the last chorus again, and
+// tab blocks are left out since they only line up in the source's own spacing.
+func chordStanzas(doc *chordpro.Song) []Stanza {
+	var stanzas []Stanza
+	var chorus []Line
+	current := Stanza{}
+	section := ""
+
+	flush := func() {
+		if len(current.Lines) > 0 {
+			stanzas = append(stanzas, current)
+		}
+		current = Stanza{}
+	}
+
+	for _, line := range doc.Lines {
+		switch line.Kind {
+		case chordpro.KindLyrics:
+			chords, lyric := line.Columns()
+			current.Lines = append(current.Lines, Line{Chords: chords, Text: lyric})
+		case chordpro.KindComment:
+			current.Lines = append(current.Lines, Line{Text: line.Text, Comment: true})
+		case chordpro.KindEmpty:
+			if section == "" {
+				flush()
+			}
+		case chordpro.KindSectionStart:
+			flush()
+			section = line.Name
+			if section != "tab" {
+				current.Label = line.Label()
+			}
+		case chordpro.KindSectionEnd:
+			if section == "chorus" {
+				chorus = current.Lines
+			}
+			section = ""
+			flush()
+		case chordpro.KindChorusRepeat:
+			flush()
+			if len(chorus) > 0 {
+				stanzas = append(stanzas, Stanza{Label: line.Label(), Lines: chorus})
+			}
+		}
+	}
+	flush()
+
+	return stanzas
+}
@@ -0,0 +1,217 @@
+package sheet
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// The Go fonts are embedded so the PDF needs nothing from the host, and they cover Cyrillic.
+const (
+	fontSans = "go"
+	fontMono = "gomono"
+
+	marginSide   = 18.0
+	marginTop    = 16.0
+	marginBottom = 18.0
+
+	lineHeight      = 5.5
+	chordLineHeight = 4.5
+	labelHeight     = 5.0
+	stanzaGap       = 3.0
+	tocPageWidth    = 14.0
+)
+
+// WritePDF writes the book as an A4 PDF. A book with a table of contents is laid out twice:
+// the first pass finds the page each song starts on, and as the contents take the same room
+// either way, the second pass prints those numbers.
+func WritePDF(w io.Writer, b *Book) error {
+	doc, starts := b.layout(nil)
+	if b.HasTOC() && doc.Ok() {
+		doc, _ = b.layout(starts)
+	}
+
+	return doc.Output(w)
+}
+
+func (b *Book) layout(starts []int) (*fpdf.Fpdf, []int) {
+	doc := fpdf.New("P", "mm", "A4", "")
+	doc.SetMargins(marginSide, marginTop, marginSide)
+	doc.SetAutoPageBreak(true, marginBottom)
+	doc.AddUTF8FontFromBytes(fontSans, "", goregular.TTF)
+	doc.AddUTF8FontFromBytes(fontSans, "B", gobold.TTF)
+	doc.AddUTF8FontFromBytes(fontSans, "I", goitalic.TTF)
+	doc.AddUTF8FontFromBytes(fontMono, "", gomono.TTF)
+	doc.AddUTF8FontFromBytes(fontMono, "B", gomonobold.TTF)
+	doc.SetTitle(b.Title, true)
+	doc.SetCreator("songs", true)
+
+	doc.SetFooterFunc(func() {
+		doc.SetY(-marginBottom + 6)
+		doc.SetFont(fontSans, "", 8)
+		doc.SetTextColor(120, 120, 120)
+		doc.CellFormat(0, 4, strconv.Itoa(doc.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	links := make([]int, len(b.Sheets))
+	for i := range links {
+		links[i] = doc.AddLink()
+	}
+	if b.HasTOC() {
+		b.contents(doc, links, starts)
+	}
+
+	found := make([]int, len(b.Sheets))
+	for i, s := range b.Sheets {
+		doc.AddPage()
+		found[i] = doc.PageNo()
+		doc.SetLink(links[i], 0, doc.PageNo())
+		s.layout(doc)
+	}
+
+	return doc, found
+}
+
+func (b *Book) contents(doc *fpdf.Fpdf, links, starts []int) {
+	doc.AddPage()
+	heading(doc, b.Title, "")
+
+	width := contentWidth(doc) - tocPageWidth
+	for i, s := range b.Sheets {
+		page := ""
+		if starts != nil {
+			page = strconv.Itoa(starts[i])
+		}
+
+		doc.SetFont(fontSans, "", 11)
+		doc.SetTextColor(17, 17, 17)
+		entry := fit(doc, fmt.Sprintf("%d. %s — %s", i+1, s.Title, s.Group), width)
+		doc.CellFormat(width, 6.5, entry, "", 0, "L", false, links[i], "")
+		doc.CellFormat(tocPageWidth, 6.5, page, "", 1, "R", false, links[i], "")
+	}
+}
+
+func (s *Sheet) layout(doc *fpdf.Fpdf) {
+	heading(doc, s.Title, s.Details())
+	doc.Bookmark(s.Title, 0, -1)
+
+	_, pageHeight := doc.GetPageSize()
+	usable := pageHeight - marginTop - marginBottom
+
+	for _, st := range s.Stanzas {
+		// Start a stanza on a new page rather than split it, unless it is too long for any page.
+		h := s.stanzaHeight(doc, st)
+		if doc.GetY()+h > pageHeight-marginBottom && h <= usable {
+			doc.AddPage()
+		}
+
+		if st.Label != "" {
+			doc.SetFont(fontSans, "B", 9)
+			doc.SetTextColor(85, 85, 85)
+			doc.CellFormat(0, labelHeight, strings.ToUpper(st.Label), "", 1, "L", false, 0, "")
+		}
+
+		for _, line := range st.Lines {
+			if line.Chords != "" {
+				doc.SetFont(fontMono, "B", 10)
+				doc.SetTextColor(170, 51, 51)
+				doc.CellFormat(0, chordLineHeight, line.Chords, "", 1, "L", false, 0, "")
+			}
+			if line.Text == "" {
+				continue
+			}
+
+			doc.SetFont(s.lyricFont(line))
+			doc.SetTextColor(17, 17, 17)
+			if s.Chords && !line.Comment {
+				doc.CellFormat(0, lineHeight, line.Text, "", 1, "L", false, 0, "")
+			} else {
+				doc.MultiCell(0, lineHeight, line.Text, "", "L", false)
+			}
+		}
+
+		doc.Ln(stanzaGap)
+	}
+}
+
+// stanzaHeight estimates the room a stanza takes, counting wrapped lyric lines.
+func (s *Sheet) stanzaHeight(doc *fpdf.Fpdf, st Stanza) float64 {
+	h := stanzaGap
+	if st.Label != "" {
+		h += labelHeight
+	}
+
+	width := contentWidth(doc)
+	for _, line := range st.Lines {
+		if line.Chords != "" {
+			h += chordLineHeight
+		}
+		if line.Text == "" {
+			continue
+		}
+
+		if s.Chords && !line.Comment {
+			h += lineHeight
+			continue
+		}
+		doc.SetFont(s.lyricFont(line))
+		h += lineHeight * float64(max(1, len(doc.SplitText(line.Text, width))))
+	}
+
+	return h
+}
+
+func (s *Sheet) lyricFont(line Line) (family, style string, size float64) {
+	switch {
+	case line.Comment:
+		return fontSans, "I", 10
+	case s.Chords:
+		return fontMono, "", 10
+	default:
+		return fontSans, "", 11
+	}
+}
+
+func heading(doc *fpdf.Fpdf, title, details string) {
+	doc.SetFont(fontSans, "B", 18)
+	doc.SetTextColor(17, 17, 17)
+	doc.MultiCell(0, 8, title, "", "L", false)
+
+	if details != "" {
+		doc.SetFont(fontSans, "", 10)
+		doc.SetTextColor(85, 85, 85)
+		doc.MultiCell(0, 5, details, "", "L", false)
+	}
+	doc.Ln(5)
+}
+
+func contentWidth(doc *fpdf.Fpdf) float64 {
+	pageWidth, _ := doc.GetPageSize()
+	return pageWidth - 2*marginSide
+}
+
+// fit shortens text with an ellipsis until it fits the width in the current font.
+func fit(doc *fpdf.Fpdf, text string, width float64) string {
+	if doc.GetStringWidth(text) <= width {
+		return text
+	}
+
+	for text != "" {
+		_, size := utf8.DecodeLastRuneInString(text)
+		text = text[:len(text)-size]
+		if doc.GetStringWidth(text+"…") <= width {
+			break
+		}
+	}
+
+	return text + "…"
+}
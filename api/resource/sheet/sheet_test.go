@@ -0,0 +1,121 @@
+package sheet_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"songs/api/resource/sheet"
+	"songs/api/resource/song"
+	"songs/pkg/chordpro"
+	testUtil "songs/util/test"
+)
+
+const chordProSource = `{title: Группа крови}
+{key: Am}
+
+[Am]Тёплое место, [C]но улицы ждут
+[Dm]Отпечатков наших [G]ног
+
+{start_of_chorus}
+[Am]Группа крови [F]на рукаве
+{end_of_chorus}
+
+{comment: Solo}
+{chorus}
+`
+
+func testSong() *song.Song {
+	return &song.Song{
+		ID:          uuid.MustParse("0b6f1b6e-3e7c-4c1a-9a0e-2f1d7a2c1b10"),
+		Group:       "Кино",
+		Song:        "Группа крови",
+		ReleaseDate: "1988-01-01",
+		Text:        "Тёплое место, но улицы ждут\nОтпечатков наших ног\n\nГруппа крови на рукаве",
+		ChordPro:    chordProSource,
+	}
+}
+
+func TestFromSong_Text(t *testing.T) {
+	t.Parallel()
+
+	s, err := sheet.FromSong(testSong(), sheet.Options{})
+	testUtil.NoError(t, err)
+
+	testUtil.Equal(t, false, s.Chords)
+	testUtil.Equal(t, 2, len(s.Stanzas))
+	testUtil.Equal(t, 2, len(s.Stanzas[0].Lines))
+	testUtil.Equal(t, "Группа крови на рукаве", s.Stanzas[1].Lines[0].Text)
+	testUtil.Equal(t, "Кино · 1988-01-01", s.Details())
+}
+
+func TestFromSong_Chords(t *testing.T) {
+	t.Parallel()
+
+	s, err := sheet.FromSong(testSong(), sheet.Options{Chords: true, Transpose: 2})
+	testUtil.NoError(t, err)
+
+	testUtil.Equal(t, true, s.Chords)
+	testUtil.Equal(t, "Bm", s.Key)
+
+	// Verse, chorus, the comment stanza and the repeated chorus.
+	testUtil.Equal(t, 4, len(s.Stanzas))
+	testUtil.Equal(t, "Bm            D", s.Stanzas[0].Lines[0].Chords)
+	testUtil.Equal(t, "Тёплое место, но улицы ждут", s.Stanzas[0].Lines[0].Text)
+	testUtil.Equal(t, "Chorus", s.Stanzas[1].Label)
+	testUtil.Equal(t, true, s.Stanzas[2].Lines[0].Comment)
+	testUtil.Equal(t, "Chorus", s.Stanzas[3].Label)
+	testUtil.Equal(t, s.Stanzas[1].Lines[0], s.Stanzas[3].Lines[0])
+}
+
+func TestFromSong_ChordsWithoutChordPro(t *testing.T) {
+	t.Parallel()
+
+	sg := testSong()
+	sg.ChordPro = ""
+
+	s, err := sheet.FromSong(sg, sheet.Options{Chords: true, Notation: chordpro.NotationFlat})
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, false, s.Chords)
+	testUtil.Equal(t, 2, len(s.Stanzas))
+}
+
+func testBook(t *testing.T, n int) *sheet.Book {
+	book := &sheet.Book{Title: "Rehearsal"}
+	for i := 0; i < n; i++ {
+		s, err := sheet.FromSong(testSong(), sheet.Options{Chords: i%2 == 0})
+		testUtil.NoError(t, err)
+		book.Sheets = append(book.Sheets, s)
+	}
+	return book
+}
+
+func TestWriteHTML(t *testing.T) {
+	t.Parallel()
+
+	var single bytes.Buffer
+	testUtil.NoError(t, sheet.WriteHTML(&single, testBook(t, 1)))
+	testUtil.Equal(t, false, strings.Contains(single.String(), `class="toc"`))
+	testUtil.Equal(t, true, strings.Contains(single.String(), `<p class="chord">Am            C</p>`))
+
+	var book bytes.Buffer
+	testUtil.NoError(t, sheet.WriteHTML(&book, testBook(t, 2)))
+	testUtil.Equal(t, true, strings.Contains(book.String(), `<a href="#song-0b6f1b6e-3e7c-4c1a-9a0e-2f1d7a2c1b10">Группа крови</a>`))
+	testUtil.Equal(t, 2, strings.Count(book.String(), `<article class="song`))
+}
+
+func TestWritePDF(t *testing.T) {
+	t.Parallel()
+
+	var single bytes.Buffer
+	testUtil.NoError(t, sheet.WritePDF(&single, testBook(t, 1)))
+	testUtil.Equal(t, true, bytes.HasPrefix(single.Bytes(), []byte("%PDF-")))
+	testUtil.Equal(t, true, bytes.Contains(single.Bytes(), []byte("/Count 1")))
+
+	// Two songs and the table of contents.
+	var book bytes.Buffer
+	testUtil.NoError(t, sheet.WritePDF(&book, testBook(t, 2)))
+	testUtil.Equal(t, true, bytes.Contains(book.Bytes(), []byte("/Count 3")))
+}
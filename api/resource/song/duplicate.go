@@ -0,0 +1,237 @@
+package song
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"songs/pkg/minhash"
+	"songs/pkg/translit"
+)
+
+// What Create does about a new song that likely duplicates one already stored.
+const (
+	DuplicatesOff    = "off"
+	DuplicatesWarn   = "warn"
+	DuplicatesReject = "reject"
+)
+
+// DuplicateCheck configures the duplicate check on Create: Mode is one of the Duplicates
+// constants and MinScore the least Score of a likely duplicate.
+type DuplicateCheck struct {
+	Mode     string
+	MinScore float64
+}
+
+// maxDescribedMatches caps the duplicates named in a Create warning or conflict.
+const maxDescribedMatches = 5
+
+// Weights of the parts of a duplicate Score. Lyrics weigh most; a cover by another
+// group with the same lyrics and title stays below a typical MinScore.
+const (
+	lyricsWeight = 0.6
+	titleWeight  = 0.2
+	groupWeight  = 0.2
+)
+
+// Match is a song scored against another.
+type Match struct {
+	ID    uuid.UUID `json:"id"`
+	Group string    `json:"group"`
+	Song  string    `json:"song"`
+	Score float64   `json:"score"`
+}
+
+// Cluster is a set of songs that likely duplicate each other. Score is the highest score
+// of a pair in it; each song's own Score is its best score with another member.
+type Cluster struct {
+	Score float64 `json:"score"`
+	Songs []Match `json:"songs"`
+}
+
+// Band is one MinHash band hash of a song's lyrics in the band index. The hash is stored
+// as the signed integer with the same bits.
+type Band struct {
+	SongID uuid.UUID `gorm:"column:song_id;primarykey"`
+	Band   int       `gorm:"column:band;primarykey"`
+	Hash   int64     `gorm:"column:hash"`
+}
+
+func (Band) TableName() string {
+	return "song_bands"
+}
+
+// Signature returns the MinHash signature of the song's lyrics, computing it when the
+// song was stored without one.
+func (s *Song) Signature() minhash.Signature {
+	var sig minhash.Signature
+	if sig.UnmarshalBinary(s.Minhash) == nil {
+		return sig
+	}
+	return lyricsSignature(s.Text)
+}
+
+// Score tells how likely two songs are the same: the estimated share of lyric shingles
+// they have in common, and how alike their titles and groups are, spelling aside.
+func Score(a, b *Song) float64 {
+	return lyricsWeight*a.Signature().Similarity(b.Signature()) +
+		titleWeight*translit.Similarity(a.SongKey, b.SongKey) +
+		groupWeight*translit.Similarity(a.GroupKey, b.GroupKey)
+}
+
+// Similar scores the song against the others and returns those at least minScore alike,
+// the most similar first.
+func Similar(s *Song, others []Song, minScore float64) []Match {
+	var matches []Match
+	for i := range others {
+		o := &others[i]
+		if o.ID == s.ID {
+			continue
+		}
+		if score := Score(s, o); score >= minScore {
+			matches = append(matches, Match{ID: o.ID, Group: o.Group, Song: o.Song, Score: score})
+		}
+	}
+
+	sortMatches(matches)
+	return matches
+}
+
+// Clusters groups the songs into likely duplicates. Only songs sharing a MinHash band or
+// a title are scored against each other, so most pairs are never compared.
+func Clusters(songs []Song, minScore float64) []Cluster {
+	// A bucket holds the songs with the same hash in a band, or with the same group and
+	// title when band is -1.
+	type bucket struct {
+		band        int
+		hash        uint64
+		group, song string
+	}
+
+	buckets := map[bucket][]int{}
+	for i := range songs {
+		s := &songs[i]
+		for b, hash := range s.Signature().BandKeys() {
+			k := bucket{band: b, hash: hash}
+			buckets[k] = append(buckets[k], i)
+		}
+		if s.GroupKey != "" || s.SongKey != "" {
+			k := bucket{band: -1, group: s.GroupKey, song: s.SongKey}
+			buckets[k] = append(buckets[k], i)
+		}
+	}
+
+	parent := make([]int, len(songs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	best := make([]float64, len(songs))
+	scored := map[[2]int]bool{}
+	for _, members := range buckets {
+		for x := 0; x < len(members); x++ {
+			for y := x + 1; y < len(members); y++ {
+				i, j := members[x], members[y]
+				if scored[[2]int{i, j}] {
+					continue
+				}
+				scored[[2]int{i, j}] = true
+
+				score := Score(&songs[i], &songs[j])
+				if score < minScore {
+					continue
+				}
+				best[i], best[j] = max(best[i], score), max(best[j], score)
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	byRoot := map[int]*Cluster{}
+	var clusters []*Cluster
+	for i := range songs {
+		if best[i] == 0 {
+			continue
+		}
+		root := find(i)
+		c, ok := byRoot[root]
+		if !ok {
+			c = &Cluster{}
+			byRoot[root] = c
+			clusters = append(clusters, c)
+		}
+		c.Score = max(c.Score, best[i])
+		c.Songs = append(c.Songs, Match{ID: songs[i].ID, Group: songs[i].Group, Song: songs[i].Song, Score: best[i]})
+	}
+
+	result := make([]Cluster, len(clusters))
+	for i, c := range clusters {
+		sortMatches(c.Songs)
+		result[i] = *c
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].Songs[0].ID.String() < result[j].Songs[0].ID.String()
+	})
+	return result
+}
+
+// Bands returns the band index rows of the song's lyrics.
+func (s *Song) Bands() []Band {
+	keys := s.Signature().BandKeys()
+	bands := make([]Band, len(keys))
+	for b, hash := range keys {
+		bands[b] = Band{SongID: s.ID, Band: b, Hash: int64(hash)}
+	}
+	return bands
+}
+
+// IndexBands replaces the song's rows in the band index; tx should be a transaction.
+func IndexBands(tx *gorm.DB, s *Song) error {
+	if err := tx.Where("song_id = ?", s.ID).Delete(&Band{}).Error; err != nil {
+		return err
+	}
+	return tx.Create(s.Bands()).Error
+}
+
+// bandPairs lists the song's (band, hash) pairs for a "(band, hash) IN ?" condition.
+func bandPairs(s *Song) [][]interface{} {
+	bands := s.Bands()
+	pairs := make([][]interface{}, len(bands))
+	for i, b := range bands {
+		pairs[i] = []interface{}{b.Band, b.Hash}
+	}
+	return pairs
+}
+
+// lyricsSignature signs the lyrics by the words of their search key, so case,
+// punctuation and Cyrillic or Latin spelling do not matter.
+func lyricsSignature(text string) minhash.Signature {
+	return minhash.New(strings.Fields(translit.Key(text)))
+}
+
+// signature encodes the signature of the lyrics for storage.
+func signature(text string) []byte {
+	b, _ := lyricsSignature(text).MarshalBinary()
+	return b
+}
+
+func sortMatches(matches []Match) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID.String() < matches[j].ID.String()
+	})
+}
@@ -0,0 +1,58 @@
+package song_test
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"songs/api/resource/song"
+	testUtil "songs/util/test"
+)
+
+const testLyrics = `Теплое место, но улицы ждут отпечатков наших ног.
+Звездная пыль на сапогах. Мягкое кресло, клетчатый плед,
+не нажатый вовремя курок. Солнечный день в ослепительных снах.
+Группа крови на рукаве, мой порядковый номер на рукаве.`
+
+func newSong(group, title, text string) song.Song {
+	req := song.SongRequest{Group: group, Song: title, Text: text, ReleaseDate: "1988-01-01", Link: "https://example.com"}
+	s := req.ToModel()
+	s.ID = uuid.New()
+	return *s
+}
+
+func TestSimilar(t *testing.T) {
+	t.Parallel()
+
+	s := newSong("Кино", "Группа крови", testLyrics)
+	others := []song.Song{
+		s,
+		newSong("Kino", "Gruppa krovi", testLyrics+"\nПожелай мне удачи в бою."),
+		newSong("Кино", "Звезда по имени Солнце", "Белый снег, серый лед на растрескавшейся земле."),
+	}
+
+	matches := song.Similar(&s, others, 0.85)
+	testUtil.Equal(t, len(matches), 1)
+	testUtil.Equal(t, matches[0].ID, others[1].ID)
+}
+
+func TestClusters(t *testing.T) {
+	t.Parallel()
+
+	songs := []song.Song{
+		newSong("Кино", "Группа крови", testLyrics),
+		newSong("Кино", "Звезда по имени Солнце", "Белый снег, серый лед на растрескавшейся земле."),
+		newSong("KINO", "Gruppa krovi", testLyrics),
+		newSong("Кино", "Группа крови (live)", testLyrics),
+	}
+
+	clusters := song.Clusters(songs, 0.85)
+	testUtil.Equal(t, len(clusters), 1)
+	testUtil.Equal(t, len(clusters[0].Songs), 3)
+	testUtil.Equal(t, clusters[0].Score, 1.0)
+	for _, m := range clusters[0].Songs {
+		if m.ID == songs[1].ID {
+			t.Fatalf("unrelated song %s in cluster", m.Song)
+		}
+	}
+}
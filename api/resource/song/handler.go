@@ -2,49 +2,73 @@ package song
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"gorm.io/gorm"
 	"net/http"
+	"net/url"
+	"regexp"
+	"songs/api/resource/common/decode"
 	e "songs/api/resource/common/err"
 	l "songs/api/resource/common/log"
+	"songs/api/resource/common/render"
+	"songs/pkg/chordpro"
 	"songs/pkg/pagination"
+	"songs/pkg/translit"
 	ctxUtil "songs/util/ctx"
 	validatorUtil "songs/util/validator"
+	"strconv"
+	"strings"
 )
 
 type API struct {
 	logger     *zerolog.Logger
 	validator  *validator.Validate
 	repository *Repository
+	duplicates DuplicateCheck
 }
 
-func New(logger *zerolog.Logger, validator *validator.Validate, db *gorm.DB) *API {
+func New(logger *zerolog.Logger, validator *validator.Validate, db *gorm.DB, duplicates DuplicateCheck) *API {
 	return &API{
 		logger:     logger,
 		validator:  validator,
 		repository: NewRepository(db, logger),
+		duplicates: duplicates,
 	}
 }
 
 // List godoc
 //
 //	@summary		List songs
-//	@description	List songs with pagination and optional filters.
+//	@description	List songs with pagination and optional filters. With facets, the response also counts the songs per
+//	@description	group, release year or genre, applying every filter but the facet's own one.
 //	@tags			songs
 //	@accept			json
 //	@produce		json
 //	@param			page		query		int					false	"Page number (default is 1)"
 //	@param			pageSize	query		int					false	"Number of items per page (default is 10, max is 100)"
-//	@param			group		query		string				false	"Group name"
-//	@param			song		query		string				false	"Song name"
+//	@param			group		query		string				false	"Group name, in Cyrillic or Latin letters"
+//	@param			song		query		string				false	"Song name, in Cyrillic or Latin letters"
 //	@param			text		query		string				false	"Text to search within song lyrics"
 //	@param			releaseDate	query		string				false	"Release date"
+//	@param			year		query		string				false	"Release year"
+//	@param			language	query		string				false	"Detected language of the lyrics, e.g. ru"
 //	@param			link		query		string				false	"Song link"
-//	@success		200			{object}	pagination.Pages	"Paginated list of songs"
-//	@failure		500			{object}	err.Error			"Internal server error"
+//	@param			genre		query		[]string			false	"Genre slugs; subgenres match too"	collectionFormat(multi)
+//	@param			genre_match	query		string				false	"Whether songs need all genres or any (default)"	Enums(all, any)
+//	@param			tag			query		[]string			false	"Tags"	collectionFormat(multi)
+//	@param			tag_match	query		string				false	"Whether songs need all tags or any (default)"	Enums(all, any)
+//	@param			lyricist	query		string				false	"Lyricist ID or name"
+//	@param			composer	query		string				false	"Composer ID or name"
+//	@param			producer	query		string				false	"Producer ID or name"
+//	@param			featured_artist	query	string				false	"Featured artist ID or name"
+//	@param			facets		query		[]string			false	"Facets to count, each ignoring its own filter"	collectionFormat(csv)	Enums(group, year, genre)
+//	@success		200			{object}	FacetedPages		"Paginated list of songs, with facet counts when asked for"
+//	@failure		400			{object}	err.Problem			"Invalid filter"
+//	@failure		500			{object}	err.Problem			"Internal server error"
 //	@router			/ [get]
 func (a *API) List(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
@@ -56,44 +80,46 @@ func (a *API) List(w http.ResponseWriter, r *http.Request) {
 	a.logger.Debug().Str(l.KeyReqID, reqID).Int("page", pages.Page).Int("perPage", pages.PerPage).Msg("Pagination parameters retrieved")
 
 	// Get filter parameters
-	filters := map[string]interface{}{}
-	if group := r.URL.Query().Get("group"); group != "" {
-		filters["group_name"] = group
-		a.logger.Debug().Str(l.KeyReqID, reqID).Str("group_name", group).Msg("Filter added: group_name")
-	}
-	if song := r.URL.Query().Get("song"); song != "" {
-		filters["song_name"] = song
-		a.logger.Debug().Str(l.KeyReqID, reqID).Str("song_name", song).Msg("Filter added: song_name")
-	}
-	if text := r.URL.Query().Get("text"); text != "" {
-		filters["text"] = text
-		a.logger.Debug().Str(l.KeyReqID, reqID).Str("text", text).Msg("Filter added: text")
-	}
-	if releaseDate := r.URL.Query().Get("releaseDate"); releaseDate != "" {
-		filters["release_date"] = releaseDate
-		a.logger.Debug().Str(l.KeyReqID, reqID).Str("release_date", releaseDate).Msg("Filter added: release_date")
+	filters, err := Filters(r.URL.Query())
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
 	}
-	if link := r.URL.Query().Get("link"); link != "" {
-		filters["link"] = link
-		a.logger.Debug().Str(l.KeyReqID, reqID).Str("link", link).Msg("Filter added: link")
+	a.logger.Debug().Str(l.KeyReqID, reqID).Interface("filters", filters).Msg("Filters parsed")
+
+	facets, err := ParseFacets(r.URL.Query())
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
 	}
 
 	// Call the repository's List method with pagination and filters
 	page, err := a.repository.List(pages.Page, pages.PerPage, filters)
 	if err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to retrieve paginated songs from repository")
-		e.ServerError(w, e.RespDBDataAccessFailure)
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
 		return
 	}
 
+	var body interface{} = page
+	if len(facets) > 0 {
+		counts, err := a.repository.Facets(filters, facets)
+		if err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to count facets")
+			e.ServerError(w, r, e.RespDBDataAccessFailure)
+			return
+		}
+		body = FacetedPages{Pages: page, Facets: counts}
+	}
+
 	// Set the link header for pagination
 	w.Header().Set("Link", page.BuildLinkHeader(r.URL.String(), pagination.DefaultPageSize))
 
 	// Return the paginated response
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(page); err != nil {
+	if err := json.NewEncoder(w).Encode(body); err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
-		e.ServerError(w, e.RespJSONEncodeFailure)
+		e.ServerError(w, r, e.RespJSONEncodeFailure)
 		return
 	}
 
@@ -103,77 +129,95 @@ func (a *API) List(w http.ResponseWriter, r *http.Request) {
 // Create godoc
 //
 //	@summary		Create song
-//	@description	Create song
+//	@description	Create song. A song that likely duplicates a stored one is created with a Warning header naming them,
+//	@description	or rejected with 409, as configured; force skips the check.
 //	@tags			songs
 //	@accept			json
 //	@produce		json
 //	@success		201
-//	@failure		400	{object}	err.Error
-//	@failure		422	{object}	err.Errors
-//	@failure		500	{object}	err.Error
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		409	{object}	err.Problem
+//	@failure		422	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
 //	@router			/ [post]
 //	@param			song	body	SongRequest	true	"The song details for creation"
+//	@param			force	query	bool		false	"Create the song even if it likely duplicates another"
 //
-// The Song struct requires the following fields:
-// - Group (string): Name of the group or artist (required).
-// - Song (string): Title of the song (required).
-// - Text (string): Lyrics or text of the song (required).
+// The SongRequest struct requires the following fields, unknown fields are rejected:
+// - Group (string): Name of the group or artist (required, at most 255 characters).
+// - Song (string): Title of the song (required, at most 255 characters).
+// - Text (string): Lyrics or text of the song (required unless ChordPro is given).
 // - ReleaseDate (string): Release date of the song in YYYY-MM-DD format (required).
-// - Link (string): URL link related to the song (required).
+// - Link (string): URL link related to the song (required, at most 255 characters).
+// - ChordPro (string): Lyrics with inline [chords] and {directives}; Text is derived from it (optional).
 func (a *API) Create(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
 
 	a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Create function started")
 
-	song := &Song{}
+	req := &SongRequest{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
+		return
+	}
 
-	if err := json.NewDecoder(r.Body).Decode(song); err != nil {
-		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to decode JSON")
-		e.BadRequest(w, e.RespJSONDecodeFailure)
+	if err := req.ApplyChordPro(); err != nil {
+		a.logger.Debug().Str(l.KeyReqID, reqID).Err(err).Msg("Invalid ChordPro lyrics")
+		e.ValidationErrors(w, r, []validatorUtil.InvalidParam{{Name: "chordpro", Reason: err.Error()}})
 		return
 	}
 
-	if err := a.validator.Struct(song); err != nil {
-		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
+	song := req.ToModel()
+	song.ID = uuid.New()
+
+	if a.duplicates.Mode != DuplicatesOff && r.URL.Query().Get("force") != "true" {
+		others, err := a.repository.Candidates(song)
 		if err != nil {
-			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to encode validation errors to JSON")
-			e.ServerError(w, e.RespJSONEncodeFailure)
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to look for duplicates")
+			e.ServerError(w, r, e.RespDBDataAccessFailure)
 			return
 		}
 
-		a.logger.Debug().Str(l.KeyReqID, reqID).Msgf("Validation errors: %s", respBody)
-
-		e.ValidationErrors(w, respBody)
-		return
+		if matches := Similar(song, others, a.duplicates.MinScore); len(matches) > 0 {
+			a.logger.Info().Str(l.KeyReqID, reqID).Str("duplicate_of", matches[0].ID.String()).Float64("score", matches[0].Score).Msg("Likely duplicate song")
+			if a.duplicates.Mode == DuplicatesReject {
+				e.Conflict(w, r, e.RespDuplicateSong.WithDetail(describeMatches(matches)))
+				return
+			}
+			w.Header().Set("Warning", fmt.Sprintf("299 - %q", describeMatches(matches)))
+		}
 	}
 
-	song.ID = uuid.New()
-
 	a.logger.Debug().Str(l.KeyReqID, reqID).Msgf("Creating new song: %+v", song)
 
 	song, err := a.repository.Create(song)
 	if err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("")
-		e.ServerError(w, e.RespDBDataInsertFailure)
+		e.ServerError(w, r, e.RespDBDataInsertFailure)
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", song.ID.String()).Msg("New song created")
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", song.ID.String()).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("New song created")
 	w.WriteHeader(http.StatusCreated)
 }
 
 // Read godoc
 //
 //	@summary		Read song
-//	@description	Read song
+//	@description	Read song. The representation follows the Accept header.
 //	@tags			songs
 //	@accept			json
-//	@produce		json
-//	@param			id	path		string	true	"Song ID"
-//	@success		200	{object}	Song
-//	@failure		400	{object}	err.Error
-//	@failure		404
-//	@failure		500	{object}	err.Error
+//	@produce		json,xml,application/yaml,plain,text/markdown
+//	@param			id		path		string	true	"Song ID"
+//	@param			lang	query		string	false	"BCP-47 language of the lyrics; falls back to the original"
+//	@param			translit	query	string	false	"Render the lyrics in Latin letters, informally or by ISO 9"	Enums(latin, iso9)
+//	@success		200		{object}	Song
+//	@failure		400		{object}	err.Problem
+//	@failure		404		{object}	err.Problem
+//	@failure		406		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
 //	@router			/{id} [get]
 func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
@@ -183,30 +227,52 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 
 	if err != nil {
-		e.BadRequest(w, e.RespInvalidURLParamID)
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
 		return
 	}
 
 	a.logger.Debug().Str(l.KeyReqID, reqID).Msgf("Parsed ID: %s", id.String())
 
+	lang, ok := queryLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	scheme, ok := queryTranslit(w, r)
+	if !ok {
+		return
+	}
+
 	song, err := a.repository.Read(id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Song not found")
-			w.WriteHeader(http.StatusNotFound)
+			e.NotFound(w, r, e.RespSongNotFound)
 			return
 		}
 
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to access the song in the database")
-		e.ServerError(w, e.RespDBDataAccessFailure)
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
 		return
 	}
 
+	if lang != "" {
+		if err := a.repository.Localize(song, lang); err != nil {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to access the song lyrics in the database")
+			e.ServerError(w, r, e.RespDBDataAccessFailure)
+			return
+		}
+	}
+
+	if scheme != nil {
+		song.Transliterate(scheme)
+	}
+
 	a.logger.Debug().Str(l.KeyReqID, reqID).Msgf("Retrieved song: %+v", song)
 
-	if err := json.NewEncoder(w).Encode(song); err != nil {
-		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to encode song DTO to JSON")
-		e.ServerError(w, e.RespJSONEncodeFailure)
+	setContentLanguage(w, song.Language)
+	if err := render.Respond(w, r, http.StatusOK, song); err != nil {
+		a.logger.Warn().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to render song")
 		return
 	}
 
@@ -216,16 +282,19 @@ func (a *API) Read(w http.ResponseWriter, r *http.Request) {
 //	 GetLyrics godoc
 //
 //		@summary		Get song lyrics
-//		@description	Get lyrics for a specific song and group
+//		@description	Get lyrics for a specific song and group. The representation follows the Accept header.
 //		@tags			songs
 //		@accept			json
-//		@produce		json
-//		@param			group	query		string	true	"Group name"
-//		@param			song	query		string	true	"Song name"
-//		@success		200		{object}	DTO     "Successfully retrieved the song lyrics"
-//		@failure		400		{object}	err.Error
-//		@failure		404
-//		@failure		500	{object}	err.Error
+//		@produce		json,xml,application/yaml,plain,text/markdown
+//		@param			group	query		string	true	"Group name, in Cyrillic or Latin letters"
+//		@param			song	query		string	true	"Song name, in Cyrillic or Latin letters"
+//		@param			lang	query		string	false	"BCP-47 language of the lyrics; falls back to the original"
+//		@param			translit	query	string	false	"Render the lyrics in Latin letters, informally or by ISO 9"	Enums(latin, iso9)
+//		@success		200		{object}	Verses	"Successfully retrieved the song lyrics"
+//		@failure		400		{object}	err.Problem
+//		@failure		404		{object}	err.Problem
+//		@failure		406		{object}	err.Problem
+//		@failure		500		{object}	err.Problem
 //		@router			/info [get]
 func (a *API) Info(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
@@ -239,7 +308,17 @@ func (a *API) Info(w http.ResponseWriter, r *http.Request) {
 	// Validate song parameters (e.g., ensure they are not empty)
 	if group == "" || song == "" {
 		a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Invalid query parameters: group or song is empty")
-		e.BadRequest(w, e.RespInvalidURLParamID)
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail("group and song are required"))
+		return
+	}
+
+	lang, ok := queryLanguage(w, r)
+	if !ok {
+		return
+	}
+
+	scheme, ok := queryTranslit(w, r)
+	if !ok {
 		return
 	}
 
@@ -247,31 +326,122 @@ func (a *API) Info(w http.ResponseWriter, r *http.Request) {
 
 	pages.PerPage = 4
 	// Fetch the song dto based on the group and song name
-	dto, err := a.repository.GetLyrics(group, song, pages.Page, pages.PerPage)
+	dto, err := a.repository.GetLyrics(group, song, lang, pages.Page, pages.PerPage)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Song not found in the repository")
-			w.WriteHeader(http.StatusNotFound)
+			e.NotFound(w, r, e.RespSongNotFound)
 			return
 		}
 
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to fetch song")
-		e.ServerError(w, e.RespDBDataAccessFailure)
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
 		return
 	}
 
 	a.logger.Debug().Str(l.KeyReqID, reqID).Msgf("Retrieved DTO: %+v", dto)
 
-	// Return the dto as JSON response
-	if err := json.NewEncoder(w).Encode(dto); err != nil {
-		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to encode dto to JSON")
-		e.ServerError(w, e.RespJSONEncodeFailure)
+	if s, ok := dto.Items.(*Song); ok {
+		if scheme != nil {
+			s.Transliterate(scheme)
+		}
+		setContentLanguage(w, s.Language)
+	}
+	if err := render.Respond(w, r, http.StatusOK, Verses{Pages: *dto}); err != nil {
+		a.logger.Warn().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to render lyrics")
 		return
 	}
 
 	a.logger.Info().Str(l.KeyReqID, reqID).Msg("Response successfully encoded and sent")
 }
 
+// Chords godoc
+//
+//	@summary		Read chords
+//	@description	Render the song's ChordPro lyrics as plain text with chords above the lines, optionally transposed.
+//	@tags			songs
+//	@produce		plain
+//	@param			id			path		string	true	"Song ID"
+//	@param			transpose	query		int		false	"Semitones to transpose by, e.g. +2 or -3"
+//	@param			notation	query		string	false	"Spelling of transposed chords"	Enums(sharp, flat)
+//	@param			format		query		string	false	"text (default) or chordpro"	Enums(text, chordpro)
+//	@success		200			{string}	string	"Chord sheet"
+//	@failure		400			{object}	err.Problem
+//	@failure		404			{object}	err.Problem
+//	@failure		500			{object}	err.Problem
+//	@router			/{id}/chords [get]
+func (a *API) Chords(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Chords function started")
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
+		return
+	}
+
+	// A literal "+2" arrives as " 2" since "+" encodes a space in query strings.
+	semitones := 0
+	if v := strings.TrimSpace(r.URL.Query().Get("transpose")); v != "" {
+		if semitones, err = strconv.Atoi(v); err != nil {
+			e.BadRequest(w, r, e.RespInvalidQuery.WithDetail("transpose must be a whole number of semitones"))
+			return
+		}
+	}
+
+	notation, err := chordpro.ParseNotation(r.URL.Query().Get("notation"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "text" && format != "chordpro" {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail("format must be text or chordpro"))
+		return
+	}
+
+	song, err := a.repository.Read(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			e.NotFound(w, r, e.RespSongNotFound)
+			return
+		}
+
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to access the song in the database")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+	if song.ChordPro == "" {
+		e.NotFound(w, r, e.RespChordsNotFound)
+		return
+	}
+
+	doc, err := chordpro.Parse(strings.NewReader(song.ChordPro))
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Stored ChordPro lyrics are invalid")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+	if semitones != 0 || notation != chordpro.NotationAuto {
+		doc.Transpose(semitones, notation)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if format == "chordpro" {
+		err = doc.Format(w)
+	} else {
+		err = doc.Render(w)
+	}
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to write chords")
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Int("transpose", semitones).Msg("Chords sent")
+}
+
 // Update godoc
 //
 //	@summary		Update song
@@ -280,12 +450,15 @@ func (a *API) Info(w http.ResponseWriter, r *http.Request) {
 //	@accept			json
 //	@produce		json
 //	@param			id		path	string	true	"Song ID"
-//	@param			body	body	Form	true	"Song form"
+//	@param			body	body	SongRequest	true	"Song details"
 //	@success		200
-//	@failure		400	{object}	err.Error
-//	@failure		404
-//	@failure		422	{object}	err.Errors
-//	@failure		500	{object}	err.Error
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		422	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
 //	@router			/{id} [put]
 func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
@@ -295,34 +468,26 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
 		a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Invalid UUID in URL parameter")
-		e.BadRequest(w, e.RespInvalidURLParamID)
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
 		return
 	}
 
 	a.logger.Debug().Str(l.KeyReqID, reqID).Str("id", id.String()).Msg("Parsed ID from URL parameter")
 
-	song := &Song{}
-	if err := json.NewDecoder(r.Body).Decode(song); err != nil {
-		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to decode JSON request body")
-		e.BadRequest(w, e.RespJSONDecodeFailure)
+	req := &SongRequest{}
+	if !decode.Validated(w, r, a.logger, a.validator, req) {
 		return
 	}
 
-	a.logger.Debug().Str(l.KeyReqID, reqID).Msgf("Decoded song: %+v", song)
-
-	if err := a.validator.Struct(song); err != nil {
-		respBody, err := json.Marshal(validatorUtil.ToErrResponse(err))
-		if err != nil {
-			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to encode validation error response to JSON")
-			e.ServerError(w, e.RespJSONEncodeFailure)
-			return
-		}
+	a.logger.Debug().Str(l.KeyReqID, reqID).Msgf("Decoded song: %+v", req)
 
-		a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Validation errors occurred")
-		e.ValidationErrors(w, respBody)
+	if err := req.ApplyChordPro(); err != nil {
+		a.logger.Debug().Str(l.KeyReqID, reqID).Err(err).Msg("Invalid ChordPro lyrics")
+		e.ValidationErrors(w, r, []validatorUtil.InvalidParam{{Name: "chordpro", Reason: err.Error()}})
 		return
 	}
 
+	song := req.ToModel()
 	song.ID = id
 
 	a.logger.Debug().Str(l.KeyReqID, reqID).Msgf("Updating song: %+v", song)
@@ -330,16 +495,16 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 	rows, err := a.repository.Update(song)
 	if err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to update song in the repository")
-		e.ServerError(w, e.RespDBDataUpdateFailure)
+		e.ServerError(w, r, e.RespDBDataUpdateFailure)
 		return
 	}
 	if rows == 0 {
 		a.logger.Debug().Str(l.KeyReqID, reqID).Msg("No rows affected; song not found")
-		w.WriteHeader(http.StatusNotFound)
+		e.NotFound(w, r, e.RespSongNotFound)
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Msg("Song updated successfully")
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Song updated successfully")
 }
 
 // Delete godoc
@@ -351,9 +516,12 @@ func (a *API) Update(w http.ResponseWriter, r *http.Request) {
 //	@produce		json
 //	@param			id	path	string	true	"Song ID"
 //	@success		200
-//	@failure		400	{object}	err.Error
-//	@failure		404
-//	@failure		500	{object}	err.Error
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
 //	@router			/{id} [delete]
 func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 	reqID := ctxUtil.RequestID(r.Context())
@@ -363,7 +531,7 @@ func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
 		a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Invalid UUID in URL parameter")
-		e.BadRequest(w, e.RespInvalidURLParamID)
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
 		return
 	}
 
@@ -372,14 +540,172 @@ func (a *API) Delete(w http.ResponseWriter, r *http.Request) {
 	rows, err := a.repository.Delete(id)
 	if err != nil {
 		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to delete song from the repository")
-		e.ServerError(w, e.RespDBDataRemoveFailure)
+		e.ServerError(w, r, e.RespDBDataRemoveFailure)
 		return
 	}
 	if rows == 0 {
 		a.logger.Debug().Str(l.KeyReqID, reqID).Msg("No rows affected; song not found for deletion")
-		w.WriteHeader(http.StatusNotFound)
+		e.NotFound(w, r, e.RespSongNotFound)
 		return
 	}
 
-	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Msg("Song deleted successfully")
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Song deleted successfully")
+}
+
+// queryLanguage reads the optional lang parameter in canonical form, answering 400 itself
+// when it is not a valid BCP-47 tag.
+func queryLanguage(w http.ResponseWriter, r *http.Request) (string, bool) {
+	lang := r.URL.Query().Get("lang")
+	if lang == "" {
+		return "", true
+	}
+
+	lang, err := ParseLanguage(lang)
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidLanguage.WithDetail(err.Error()))
+		return "", false
+	}
+	return lang, true
+}
+
+// describeMatches names the most likely duplicates by ID and score.
+func describeMatches(matches []Match) string {
+	if len(matches) > maxDescribedMatches {
+		matches = matches[:maxDescribedMatches]
+	}
+
+	described := make([]string, len(matches))
+	for i, m := range matches {
+		described[i] = fmt.Sprintf("%s (%.2f)", m.ID, m.Score)
+	}
+	return "likely duplicate of " + strings.Join(described, ", ")
+}
+
+// queryTranslit reads the transliteration scheme from the translit parameter; nil means
+// the lyrics are served as they are.
+func queryTranslit(w http.ResponseWriter, r *http.Request) (*translit.Scheme, bool) {
+	name := r.URL.Query().Get("translit")
+	if name == "" {
+		return nil, true
+	}
+
+	scheme, ok := translit.Schemes[name]
+	if !ok {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail("translit must be latin or iso9"))
+		return nil, false
+	}
+	return scheme, true
+}
+
+func setContentLanguage(w http.ResponseWriter, lang string) {
+	if lang != "" {
+		w.Header().Set("Content-Language", lang)
+	}
+}
+
+var yearPattern = regexp.MustCompile(`^[0-9]{4}$`)
+
+// Filters maps the List query parameters to the filters the repository applies. Genres
+// and tags take several values, repeated or comma-separated, and genre_match or tag_match
+// chooses whether a song needs all of them or any (the default). Credit roles such as
+// lyricist take a person's ID or name.
+func Filters(q url.Values) (map[string]interface{}, error) {
+	params := []struct{ param, column string }{
+		{"group", "group_name"},
+		{"song", "song_name"},
+		{"text", "text"},
+		{"releaseDate", "release_date"},
+		{"link", "link"},
+	}
+
+	filters := map[string]interface{}{}
+	for _, p := range params {
+		if v := q.Get(p.param); v != "" {
+			filters[p.column] = v
+		}
+	}
+
+	for _, role := range CreditRoles {
+		if v := strings.TrimSpace(q.Get(role)); v != "" {
+			filters[role] = v
+		}
+	}
+
+	if v := q.Get("year"); v != "" {
+		if !yearPattern.MatchString(v) {
+			return nil, fmt.Errorf("year must have four digits")
+		}
+		filters["year"] = v
+	}
+
+	if v := q.Get("language"); v != "" {
+		lang, err := ParseLanguage(v)
+		if err != nil {
+			return nil, err
+		}
+		filters["language"] = lang
+	}
+
+	terms := []struct {
+		param     string
+		normalize func(string) string
+	}{
+		{"genre", func(s string) string { return strings.ToLower(strings.TrimSpace(s)) }},
+		{"tag", NormalizeTag},
+	}
+	for _, t := range terms {
+		f, err := termFilter(q, t.param, t.normalize)
+		if err != nil {
+			return nil, err
+		}
+		if len(f.Values) > 0 {
+			filters[t.param] = f
+		}
+	}
+
+	return filters, nil
+}
+
+func termFilter(q url.Values, param string, normalize func(string) string) (TermFilter, error) {
+	f := TermFilter{}
+	for _, v := range q[param] {
+		for _, term := range strings.Split(v, ",") {
+			if term = normalize(term); term != "" {
+				f.Values = append(f.Values, term)
+			}
+		}
+	}
+
+	switch match := q.Get(param + "_match"); match {
+	case "", "any":
+	case "all":
+		f.All = true
+	default:
+		return f, fmt.Errorf("%s_match must be all or any", param)
+	}
+
+	return f, nil
+}
+
+// ParseFacets reads the facets to count from the facets parameter, repeated or
+// comma-separated.
+func ParseFacets(q url.Values) ([]string, error) {
+	var facets []string
+	seen := map[string]bool{}
+
+	for _, v := range q["facets"] {
+		for _, f := range strings.Split(v, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" || seen[f] {
+				continue
+			}
+			if _, ok := facetFilters[f]; !ok {
+				return nil, fmt.Errorf("unknown facet %q, expected group, year or genre", f)
+			}
+			seen[f] = true
+			facets = append(facets, f)
+		}
+	}
+
+	return facets, nil
 }
@@ -0,0 +1,68 @@
+package song_test
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	"songs/api/resource/song"
+	testUtil "songs/util/test"
+)
+
+func TestFilters(t *testing.T) {
+	t.Parallel()
+
+	q := url.Values{
+		"group":     {"Muse"},
+		"year":      {"2006"},
+		"language":  {"EN"},
+		"lyricist":  {" Matt Bellamy "},
+		"genre":     {"Rock, post-punk", " "},
+		"tag":       {"Road  Trip", "summer"},
+		"tag_match": {"all"},
+	}
+
+	filters, err := song.Filters(q)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, "Muse", filters["group_name"])
+	testUtil.Equal(t, "2006", filters["year"])
+	testUtil.Equal(t, "en", filters["language"])
+	testUtil.Equal(t, "Matt Bellamy", filters["lyricist"])
+
+	expected := map[string]song.TermFilter{
+		"genre": {Values: []string{"rock", "post-punk"}},
+		"tag":   {Values: []string{"road trip", "summer"}, All: true},
+	}
+	for param, want := range expected {
+		if got := filters[param]; !reflect.DeepEqual(got, want) {
+			t.Errorf(`%s: Expected:"%+v", Got:"%+v"`, param, want, got)
+		}
+	}
+
+	_, err = song.Filters(url.Values{"genre": {"rock"}, "genre_match": {"some"}})
+	if err == nil {
+		t.Fatal("Expected an error for an invalid genre_match")
+	}
+
+	if _, err := song.Filters(url.Values{"year": {"06"}}); err == nil {
+		t.Fatal("Expected an error for a two-digit year")
+	}
+
+	if _, err := song.Filters(url.Values{"language": {"not a tag"}}); err == nil {
+		t.Fatal("Expected an error for an invalid language")
+	}
+}
+
+func TestParseFacets(t *testing.T) {
+	t.Parallel()
+
+	facets, err := song.ParseFacets(url.Values{"facets": {"group, year", "group,genre"}})
+	testUtil.NoError(t, err)
+	if expected := []string{"group", "year", "genre"}; !reflect.DeepEqual(facets, expected) {
+		t.Fatalf(`Expected:"%v", Got:"%v"`, expected, facets)
+	}
+
+	if _, err := song.ParseFacets(url.Values{"facets": {"link"}}); err == nil {
+		t.Fatal("Expected an error for an unknown facet")
+	}
+}
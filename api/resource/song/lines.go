@@ -0,0 +1,73 @@
+package song
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"songs/pkg/translit"
+)
+
+// lineBatch is how many lines are inserted per statement when a song is indexed.
+const lineBatch = 500
+
+// Line is a line of a song's lyrics in the line index, by its search key.
+type Line struct {
+	SongID uuid.UUID `gorm:"column:song_id;primarykey"`
+	Number int       `gorm:"column:line_no;primarykey"`
+	Stanza int       `gorm:"column:stanza"`
+	Key    string    `gorm:"column:key"`
+}
+
+func (Line) TableName() string {
+	return "song_lines"
+}
+
+// SplitLines splits lyrics into lines, without trailing carriage returns.
+func SplitLines(text string) []string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, "\r")
+	}
+	return lines
+}
+
+// Lines returns the index lines of the song's lyrics. Blank lines separate stanzas and,
+// like section labels such as "[Chorus]", are not indexed.
+func (s *Song) Lines() []Line {
+	var lines []Line
+	stanza, blank := 0, true
+	for i, text := range SplitLines(s.Text) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			blank = true
+			continue
+		}
+		if blank {
+			stanza++
+			blank = false
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			continue
+		}
+
+		if key := translit.Key(text); key != "" {
+			lines = append(lines, Line{SongID: s.ID, Number: i + 1, Stanza: stanza, Key: key})
+		}
+	}
+	return lines
+}
+
+// IndexLines replaces the song's lines in the line index; tx should be a transaction.
+func IndexLines(tx *gorm.DB, s *Song) error {
+	if err := tx.Where("song_id = ?", s.ID).Delete(&Line{}).Error; err != nil {
+		return err
+	}
+
+	lines := s.Lines()
+	if len(lines) == 0 {
+		return nil
+	}
+	return tx.CreateInBatches(lines, lineBatch).Error
+}
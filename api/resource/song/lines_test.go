@@ -0,0 +1,29 @@
+package song_test
+
+import (
+	"testing"
+
+	"songs/api/resource/song"
+	testUtil "songs/util/test"
+)
+
+func TestSong_Lines(t *testing.T) {
+	t.Parallel()
+
+	s := &song.Song{Text: "[Verse]\r\nТёплое место, но улицы ждут\r\n\r\n\r\nГруппа крови на рукаве!\n  \n…\nМой порядковый номер"}
+
+	lines := s.Lines()
+	testUtil.Equal(t, len(lines), 3)
+
+	testUtil.Equal(t, lines[0].Number, 2)
+	testUtil.Equal(t, lines[0].Stanza, 1)
+	testUtil.Equal(t, lines[0].Key, "teploe mesto no ulici zdut")
+
+	testUtil.Equal(t, lines[1].Number, 5)
+	testUtil.Equal(t, lines[1].Stanza, 2)
+	testUtil.Equal(t, lines[1].Key, "grupa krovi na rukave")
+
+	// A line of punctuation only still starts a stanza but is not indexed.
+	testUtil.Equal(t, lines[2].Number, 8)
+	testUtil.Equal(t, lines[2].Stanza, 3)
+}
@@ -1,13 +1,24 @@
 package song
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/google/uuid"
+	"golang.org/x/text/language"
+
+	"songs/api/resource/common/render"
+	"songs/pkg/chordpro"
+	"songs/pkg/langdetect"
+	"songs/pkg/lyricstats"
+	"songs/pkg/pagination"
+	"songs/pkg/translit"
 )
 
 type DTO struct {
-	ReleaseDate string `json:"release_date"`
-	Text        string `json:"text"`
-	Link        string `json:"link"`
+	ReleaseDate string `json:"release_date" xml:"release_date" yaml:"release_date"`
+	Text        string `json:"text" xml:"text" yaml:"text"`
+	Link        string `json:"link" xml:"link" yaml:"link"`
 }
 
 type Form struct {
@@ -16,24 +27,218 @@ type Form struct {
 }
 
 type Song struct {
-	ID          uuid.UUID `gorm:"primarykey" json:"id"`
-	Group       string    `gorm:"column:group_name" json:"group"`
-	Song        string    `gorm:"column:song_name" json:"song"`
-	Text        string    `gorm:"column:text" json:"text"`
-	ReleaseDate string    `gorm:"column:release_date" json:"release_date"`
-	Link        string    `gorm:"column:link" json:"link"`
+	ID          uuid.UUID `gorm:"primarykey" json:"id" xml:"id" yaml:"id"`
+	Group       string    `gorm:"column:group_name" json:"group" xml:"group" yaml:"group"`
+	Song        string    `gorm:"column:song_name" json:"song" xml:"song" yaml:"song"`
+	Text        string    `gorm:"column:text" json:"text" xml:"text" yaml:"text"`
+	ReleaseDate string    `gorm:"column:release_date" json:"release_date" xml:"release_date" yaml:"release_date"`
+	Link        string    `gorm:"column:link" json:"link" xml:"link" yaml:"link"`
+	ChordPro    string    `gorm:"column:chordpro" json:"chordpro,omitempty" xml:"chordpro,omitempty" yaml:"chordpro,omitempty"`
+	// Language is detected from Text when the song is saved; Localize replaces it, and sets
+	// Translator, with those of the version it serves.
+	Language   string `gorm:"column:language" json:"language,omitempty" xml:"language,omitempty" yaml:"language,omitempty"`
+	Translator string `gorm:"-" json:"translator,omitempty" xml:"translator,omitempty" yaml:"translator,omitempty"`
+	// GroupKey and SongKey are the names' transliteration-insensitive search keys.
+	GroupKey string `gorm:"column:group_key" json:"-" xml:"-" yaml:"-"`
+	SongKey  string `gorm:"column:song_key" json:"-" xml:"-" yaml:"-"`
+	// Minhash is the encoded MinHash signature of Text, for finding duplicates.
+	Minhash []byte `gorm:"column:minhash" json:"-" xml:"-" yaml:"-"`
+	// Stats are the counts of Text's words and lines, kept for the stats endpoints.
+	Stats *lyricstats.Counts `gorm:"column:stats;serializer:json" json:"-" xml:"-" yaml:"-"`
+}
+
+// Lyrics is one language version of a song's lyrics. The original's text is the song's
+// own Text; translations carry theirs.
+type Lyrics struct {
+	SongID     uuid.UUID `gorm:"column:song_id;primarykey" json:"-" xml:"-" yaml:"-"`
+	Language   string    `gorm:"column:language;primarykey" json:"language" xml:"language" yaml:"language"`
+	IsOriginal bool      `gorm:"column:is_original" json:"is_original" xml:"is_original" yaml:"is_original"`
+	Text       string    `gorm:"column:text" json:"text" xml:"text" yaml:"text"`
+	Translator string    `gorm:"column:translator" json:"translator,omitempty" xml:"translator,omitempty" yaml:"translator,omitempty"`
+}
+
+func (Lyrics) TableName() string {
+	return "song_lyrics"
 }
 
 type SongRequest struct {
-	Group       string `json:"group" binding:"required"`
-	Song        string `json:"song" binding:"required"`
-	Text        string `json:"text" binding:"required"`
-	ReleaseDate string `json:"release_date" binding:"required"`
-	Link        string `json:"link" binding:"required"`
+	Group       string `json:"group" form:"required,max=255"`
+	Song        string `json:"song" form:"required,max=255"`
+	Text        string `json:"text" form:"required_without=ChordPro,excluded_with=ChordPro"`
+	ReleaseDate string `json:"release_date" form:"required,datetime=2006-01-02"`
+	Link        string `json:"link" form:"required,url,max=255"`
+	// ChordPro holds lyrics with inline chords; Text is then derived from it.
+	ChordPro string `json:"chordpro,omitempty"`
 }
 
 type Songs []*Song
 
+// Roles people can be credited with on a song.
+const (
+	RoleLyricist       = "lyricist"
+	RoleComposer       = "composer"
+	RoleProducer       = "producer"
+	RoleFeaturedArtist = "featured_artist"
+)
+
+// CreditRoles lists the credit roles in the order credits are shown.
+var CreditRoles = []string{RoleLyricist, RoleComposer, RoleProducer, RoleFeaturedArtist}
+
+// TermFilter matches songs by genres or tags: songs with any of the values, or with all of
+// them when All is set.
+type TermFilter struct {
+	Values []string
+	All    bool
+}
+
+// FacetValue is one value of a facet with the number of songs having it. Name is the
+// display name of a genre.
+type FacetValue struct {
+	Value string `json:"value"`
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count"`
+}
+
+// Facets maps each requested facet to its values.
+type Facets map[string][]FacetValue
+
+// FacetedPages is a page of songs with facet counts.
+type FacetedPages struct {
+	*pagination.Pages
+	Facets Facets `json:"facets"`
+}
+
+// facetFilters maps each facet to the filter it ignores when counting, so picking one
+// value still shows the counts of the others.
+var facetFilters = map[string]string{
+	"group": "group_name",
+	"year":  "year",
+	"genre": "genre",
+}
+
+// Verses is a page of one song's lyrics, as returned by Info.
+type Verses struct {
+	pagination.Pages `yaml:",inline"`
+}
+
+// PlainText returns the song's lyrics.
+func (s *Song) PlainText() string {
+	return s.Text
+}
+
+// Markdown renders the song with its title as a heading and one lyric line per line.
+func (s *Song) Markdown() string {
+	var b strings.Builder
+
+	b.WriteString("# " + render.EscapeMarkdown(s.Song) + "\n\n")
+
+	details := []string{"**" + render.EscapeMarkdown(s.Group) + "**"}
+	if s.ReleaseDate != "" {
+		details = append(details, s.ReleaseDate)
+	}
+	if s.Link != "" {
+		details = append(details, "[link](<"+s.Link+">)")
+	}
+	b.WriteString(strings.Join(details, " · ") + "\n\n")
+	b.WriteString(render.MarkdownLines(s.Text))
+
+	return b.String()
+}
+
+func (v Verses) song() *Song {
+	s, _ := v.Items.(*Song)
+	if s == nil {
+		return &Song{}
+	}
+	return s
+}
+
+func (v Verses) PlainText() string {
+	return v.song().PlainText()
+}
+
+func (v Verses) Markdown() string {
+	return v.song().Markdown()
+}
+
+// Localize replaces the song's text with its version in lang, falling back to the
+// original when there is none, and records the language served when it is known.
+// Translations carry no chords, so ChordPro is cleared for them.
+func (s *Song) Localize(versions []Lyrics, lang string) {
+	v := MatchLyrics(versions, lang)
+	if v == nil {
+		v = OriginalLyrics(versions)
+	}
+	if v == nil {
+		return
+	}
+
+	s.Language = v.Language
+	if !v.IsOriginal {
+		s.Text = v.Text
+		s.Translator = v.Translator
+		s.ChordPro = ""
+	}
+}
+
+// ParseLanguage checks a BCP-47 language tag and returns it in canonical form, so "EN-us"
+// becomes "en-US".
+func ParseLanguage(tag string) (string, error) {
+	t, err := language.Parse(tag)
+	if err != nil {
+		return "", fmt.Errorf("invalid language tag %q", tag)
+	}
+	return t.String(), nil
+}
+
+// MatchLyrics picks the version in lang: the exact tag, or else one in the same base
+// language, preferring the bare one, so "en-GB" finds "en" and "en" finds "en-US". It
+// returns nil when no version matches.
+func MatchLyrics(versions []Lyrics, lang string) *Lyrics {
+	want, err := language.Parse(lang)
+	if err != nil {
+		return nil
+	}
+	base, _ := want.Base()
+
+	var match *Lyrics
+	for i := range versions {
+		v := &versions[i]
+		if v.Language == want.String() {
+			return v
+		}
+
+		tag, err := language.Parse(v.Language)
+		if err != nil {
+			continue
+		}
+		if b, _ := tag.Base(); b != base {
+			continue
+		}
+		if match == nil || tag.String() == base.String() {
+			match = v
+		}
+	}
+
+	return match
+}
+
+// OriginalLyrics returns the original version, or nil when its language is not recorded.
+func OriginalLyrics(versions []Lyrics) *Lyrics {
+	for i := range versions {
+		if versions[i].IsOriginal {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+// NormalizeTag lower-cases a tag and collapses its whitespace, so tags differing only in
+// case or spacing are the same tag.
+func NormalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
 func (s *Song) ToDto() *DTO {
 	return &DTO{
 		ReleaseDate: s.ReleaseDate,
@@ -51,6 +256,53 @@ func (songs Songs) ToDto() []*DTO {
 	return dtos
 }
 
+func (r *SongRequest) ToModel() *Song {
+	return &Song{
+		Group:       r.Group,
+		Song:        r.Song,
+		Text:        r.Text,
+		ReleaseDate: r.ReleaseDate,
+		Link:        r.Link,
+		ChordPro:    r.ChordPro,
+		Language:    langdetect.Detect(r.Text),
+		GroupKey:    translit.Key(r.Group),
+		SongKey:     translit.Key(r.Song),
+		Minhash:     signature(r.Text),
+		Stats:       lyricstats.Count(r.Text),
+	}
+}
+
+// Transliterate renders the song's lyrics, with and without chords, in Latin letters.
+func (s *Song) Transliterate(scheme *translit.Scheme) {
+	s.Text = scheme.String(s.Text)
+	s.ChordPro = scheme.String(s.ChordPro)
+}
+
+// Counts returns the stats of the song's lyrics, counting them when the song was stored
+// without stats.
+func (s *Song) Counts() *lyricstats.Counts {
+	if s.Stats != nil {
+		return s.Stats
+	}
+	return lyricstats.Count(s.Text)
+}
+
+// ApplyChordPro parses the ChordPro lyrics, if any, and fills Text with the lyrics
+// stripped of chords, so search and /info never see chord markup.
+func (r *SongRequest) ApplyChordPro() error {
+	if r.ChordPro == "" {
+		return nil
+	}
+
+	doc, err := chordpro.Parse(strings.NewReader(r.ChordPro))
+	if err != nil {
+		return err
+	}
+	r.Text = doc.Lyrics()
+
+	return nil
+}
+
 func (f *Form) ToModel() *Song {
 
 	return &Song{
@@ -0,0 +1,173 @@
+package song_test
+
+import (
+	"strings"
+	"testing"
+
+	"songs/api/resource/song"
+	"songs/util/validator"
+)
+
+func validSongRequest() song.SongRequest {
+	return song.SongRequest{
+		Group:       "Muse",
+		Song:        "Supermassive Black Hole",
+		Text:        "Ooh baby, don't you know I suffer?",
+		ReleaseDate: "2006-07-16",
+		Link:        "https://www.youtube.com/watch?v=Xsp3_a-PMTw",
+	}
+}
+
+func TestSongRequest_Validation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		modify   func(r *song.SongRequest)
+		expected string
+	}{
+		{name: "group required", modify: func(r *song.SongRequest) { r.Group = "" }, expected: "group is a required field"},
+		{name: "group max", modify: func(r *song.SongRequest) { r.Group = strings.Repeat("a", 256) }, expected: "group must be a maximum of 255 in length"},
+		{name: "song required", modify: func(r *song.SongRequest) { r.Song = "" }, expected: "song is a required field"},
+		{name: "song max", modify: func(r *song.SongRequest) { r.Song = strings.Repeat("a", 256) }, expected: "song must be a maximum of 255 in length"},
+		{name: "text required", modify: func(r *song.SongRequest) { r.Text = "" }, expected: "text is a required field"},
+		{name: "text excluded with chordpro", modify: func(r *song.SongRequest) { r.ChordPro = "[G]Ooh baby" }, expected: "text is an excluded field"},
+		{name: "release date required", modify: func(r *song.SongRequest) { r.ReleaseDate = "" }, expected: "release_date is a required field"},
+		{name: "release date format", modify: func(r *song.SongRequest) { r.ReleaseDate = "16.07.2006" }, expected: "release_date must be a valid date"},
+		{name: "link required", modify: func(r *song.SongRequest) { r.Link = "" }, expected: "link is a required field"},
+		{name: "link url", modify: func(r *song.SongRequest) { r.Link = "youtube" }, expected: "link must be a valid URL"},
+		{name: "link max", modify: func(r *song.SongRequest) { r.Link = "https://example.com/" + strings.Repeat("a", 236) }, expected: "link must be a maximum of 255 in length"},
+	}
+
+	vr := validator.New()
+
+	if err := vr.Struct(validSongRequest()); err != nil {
+		t.Fatalf("valid request rejected: %v", err)
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := validSongRequest()
+			tc.modify(&req)
+
+			err := vr.Struct(req)
+			if errResp := validator.ToErrResponse(err); errResp == nil || len(errResp.Errors) != 1 {
+				t.Fatalf(`Expected:"{[%v]}", Got:"%v"`, tc.expected, errResp)
+			} else if errResp.Errors[0] != tc.expected {
+				t.Fatalf(`Expected:"%v", Got:"%v"`, tc.expected, errResp.Errors[0])
+			}
+		})
+	}
+}
+
+func TestSongRequest_ApplyChordPro(t *testing.T) {
+	t.Parallel()
+
+	req := validSongRequest()
+	req.Text = ""
+	req.ChordPro = "{title: Supermassive Black Hole}\n[Em]Ooh baby, don't you [G]know I suffer?\n"
+
+	if err := validator.New().Struct(req); err != nil {
+		t.Fatalf("valid request rejected: %v", err)
+	}
+	if err := req.ApplyChordPro(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Text != "Ooh baby, don't you know I suffer?" {
+		t.Errorf("unexpected text %q", req.Text)
+	}
+
+	req.ChordPro = "[Em unclosed"
+	if err := req.ApplyChordPro(); err == nil {
+		t.Error("expected an error for malformed ChordPro")
+	}
+}
+
+func TestSong_Markdown(t *testing.T) {
+	t.Parallel()
+
+	s := &song.Song{
+		Group:       "Muse",
+		Song:        "Starlight",
+		Text:        "Far away\nThis ship is taking me far away\n\n- Far away from the memories",
+		ReleaseDate: "2006-09-04",
+		Link:        "https://example.com/starlight",
+	}
+
+	expected := "# Starlight\n\n**Muse** · 2006-09-04 · [link](<https://example.com/starlight>)\n\n" +
+		"Far away  \nThis ship is taking me far away\n\n\\- Far away from the memories"
+	if got := s.Markdown(); got != expected {
+		t.Errorf("unexpected markdown:\n%s", got)
+	}
+}
+
+func TestMatchLyrics(t *testing.T) {
+	t.Parallel()
+
+	versions := []song.Lyrics{
+		{Language: "ru", IsOriginal: true},
+		{Language: "en-US", Text: "US"},
+		{Language: "en", Text: "EN"},
+		{Language: "pt-BR", Text: "BR"},
+	}
+
+	tests := []struct {
+		lang     string
+		expected string
+	}{
+		{lang: "en-US", expected: "en-US"},
+		{lang: "en-GB", expected: "en"},
+		{lang: "en", expected: "en"},
+		{lang: "pt", expected: "pt-BR"},
+		{lang: "ru-RU", expected: "ru"},
+		{lang: "de", expected: ""},
+	}
+
+	for _, tc := range tests {
+		got := ""
+		if v := song.MatchLyrics(versions, tc.lang); v != nil {
+			got = v.Language
+		}
+		if got != tc.expected {
+			t.Errorf(`%s: Expected:"%s", Got:"%s"`, tc.lang, tc.expected, got)
+		}
+	}
+}
+
+func TestSong_Localize(t *testing.T) {
+	t.Parallel()
+
+	versions := []song.Lyrics{
+		{Language: "ru", IsOriginal: true, Text: "Группа крови"},
+		{Language: "en", Text: "Blood type", Translator: "J. Doe"},
+	}
+
+	s := &song.Song{Text: "Группа крови", ChordPro: "[Am]Группа крови"}
+	s.Localize(versions, "en")
+	if s.Text != "Blood type" || s.Language != "en" || s.Translator != "J. Doe" || s.ChordPro != "" {
+		t.Fatalf("translation not applied: %+v", s)
+	}
+
+	s = &song.Song{Text: "Группа крови"}
+	s.Localize(versions, "de")
+	if s.Text != "Группа крови" || s.Language != "ru" || s.Translator != "" {
+		t.Fatalf("original not kept: %+v", s)
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	t.Parallel()
+
+	lang, err := song.ParseLanguage("EN-us")
+	if err != nil || lang != "en-US" {
+		t.Fatalf(`Expected:"en-US", Got:"%s" (%v)`, lang, err)
+	}
+
+	if _, err := song.ParseLanguage("not a tag"); err == nil {
+		t.Fatal("Expected an error for an invalid tag")
+	}
+}
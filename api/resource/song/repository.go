@@ -1,19 +1,35 @@
 package song
 
 import (
+	"database/sql"
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 	"gorm.io/gorm"
 	"songs/pkg/pagination"
+	"songs/pkg/translit"
+	"sort"
 	"strings"
 )
 
+// FacetLimit caps the number of values returned per facet.
+const FacetLimit = 50
+
+// groupCondition matches songs of the group with search key @key under any of its names:
+// the group itself, the group an alias of that name stands for, and the group's aliases.
+const groupCondition = `(group_key = @key
+	OR group_key IN (SELECT group_key FROM group_aliases WHERE alias_key = @key)
+	OR group_key IN (SELECT alias_key FROM group_aliases WHERE group_key = @key
+		OR group_key IN (SELECT group_key FROM group_aliases WHERE alias_key = @key)))`
+
 type Repository struct {
 	db     *gorm.DB
 	logger *zerolog.Logger
 }
 
+// NewRepository returns a repository over db. db may be a transaction: writes that need
+// one then run in a savepoint of it, so callers such as the seeder can save many songs
+// atomically.
 func NewRepository(db *gorm.DB, l *zerolog.Logger) *Repository {
 	return &Repository{
 		db:     db,
@@ -27,21 +43,8 @@ func (r *Repository) List(page, pageSize int, filters map[string]interface{}) (*
 
 	r.logger.Debug().Msgf("List called with page: %d, pageSize: %d, filters: %+v", page, pageSize, filters)
 
-	// Create a base query
-	query := r.db.Model(&Song{})
-
-	// Apply filters to the query
-	for key, value := range filters {
-		if key == "text" {
-			query = query.Where("text LIKE ?", "%"+value.(string)+"%")
-
-			r.logger.Debug().Msgf("Applying filter: %s LIKE %s", key, value)
-		} else {
-			query = query.Where(fmt.Sprintf("%s = ?", key), value)
-
-			r.logger.Debug().Msgf("Applying filter: %s = %v", key, value)
-		}
-	}
+	// Create a base query with the filters applied
+	query := r.filter(r.db.Model(&Song{}), filters)
 
 	// Get the total count of records matching the filters
 	if err := query.Count(&total).Error; err != nil {
@@ -62,15 +65,232 @@ func (r *Repository) List(page, pageSize int, filters map[string]interface{}) (*
 	return pages, nil
 }
 
-func (r *Repository) GetLyrics(group, song string, page, pageSize int) (*pagination.Pages, error) {
-	r.logger.Debug().Msgf("GetLyrics called with group: %s, song: %s, page: %d, pageSize: %d", group, song, page, pageSize)
+// Find returns up to limit songs matching the filters, ordered by group and title.
+func (r *Repository) Find(filters map[string]interface{}, limit int) ([]Song, error) {
+	var songs []Song
+
+	query := r.filter(r.db.Model(&Song{}), filters)
+	if err := query.Order("group_name, song_name").Limit(limit).Find(&songs).Error; err != nil {
+		return nil, err
+	}
+
+	return songs, nil
+}
+
+// ReadMany returns the songs with the given IDs in the order the IDs are listed. IDs
+// without a song are skipped.
+func (r *Repository) ReadMany(ids []uuid.UUID) ([]Song, error) {
+	var found []Song
+	if err := r.db.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]Song, len(found))
+	for _, s := range found {
+		byID[s.ID] = s
+	}
+
+	songs := make([]Song, 0, len(found))
+	for _, id := range ids {
+		if s, ok := byID[id]; ok {
+			songs = append(songs, s)
+		}
+	}
+
+	return songs, nil
+}
+
+// Filtered returns a query over the songs matching the filters, for use as a subquery.
+func (r *Repository) Filtered(filters map[string]interface{}) *gorm.DB {
+	return r.filter(r.db.Model(&Song{}), filters)
+}
+
+func (r *Repository) filter(query *gorm.DB, filters map[string]interface{}) *gorm.DB {
+	for key, value := range filters {
+		switch key {
+		case "text":
+			query = query.Where("text LIKE ?", "%"+value.(string)+"%")
+
+			r.logger.Debug().Msgf("Applying filter: %s LIKE %s", key, value)
+		case "genre":
+			query = termCondition(query, genreCondition, value.(TermFilter))
+
+			r.logger.Debug().Msgf("Applying filter: %s in %+v", key, value)
+		case "tag":
+			query = termCondition(query, tagCondition, value.(TermFilter))
+
+			r.logger.Debug().Msgf("Applying filter: %s in %+v", key, value)
+		case "group_name":
+			query = query.Where(groupCondition, sql.Named("key", translit.Key(value.(string))))
+
+			r.logger.Debug().Msgf("Applying filter: %s matches %s or its aliases", key, value)
+		case "song_name":
+			query = query.Where("song_key = ?", translit.Key(value.(string)))
+
+			r.logger.Debug().Msgf("Applying filter: %s matches %s", key, value)
+		case "year":
+			query = query.Where("LEFT(release_date, 4) = ?", value)
+
+			r.logger.Debug().Msgf("Applying filter: %s = %v", key, value)
+		case RoleLyricist, RoleComposer, RoleProducer, RoleFeaturedArtist:
+			query = creditCondition(query, key, value.(string))
+
+			r.logger.Debug().Msgf("Applying filter: %s = %v", key, value)
+		default:
+			query = query.Where(fmt.Sprintf("%s = ?", key), value)
+
+			r.logger.Debug().Msgf("Applying filter: %s = %v", key, value)
+		}
+	}
+
+	return query
+}
+
+// Facets counts the songs per value of each facet. Every facet applies all filters but
+// its own; the counts of all facets come from a single query.
+func (r *Repository) Facets(filters map[string]interface{}, facets []string) (Facets, error) {
+	r.logger.Debug().Msgf("Facets called with facets: %v, filters: %+v", facets, filters)
+
+	branches := make([]interface{}, len(facets))
+	for i, facet := range facets {
+		songs := r.filter(r.db.Model(&Song{}), without(filters, facetFilters[facet]))
+		branches[i] = r.facetQuery(facet, songs)
+	}
+
+	var rows []struct {
+		Facet string
+		FacetValue
+	}
+	sql := strings.TrimSuffix(strings.Repeat("(?) UNION ALL ", len(branches)), " UNION ALL ")
+	if err := r.db.Raw(sql, branches...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(Facets, len(facets))
+	for _, f := range facets {
+		result[f] = []FacetValue{}
+	}
+	for _, row := range rows {
+		result[row.Facet] = append(result[row.Facet], row.FacetValue)
+	}
+	for facet, values := range result {
+		sortFacet(facet, values)
+	}
+
+	return result, nil
+}
+
+// facetQuery counts the given songs per value of the facet, keeping the FacetLimit
+// largest counts, or the latest years.
+func (r *Repository) facetQuery(facet string, songs *gorm.DB) *gorm.DB {
+	switch facet {
+	case "year":
+		return songs.
+			Select("'year' AS facet, LEFT(release_date, 4) AS value, '' AS name, COUNT(*) AS count").
+			Where("release_date <> ''").
+			Group("LEFT(release_date, 4)").
+			Order("value DESC").
+			Limit(FacetLimit)
+	case "genre":
+		return r.db.Table("(?) AS tree", r.db.Raw(genreClosure)).
+			Select("'genre' AS facet, g.slug AS value, g.name AS name, COUNT(DISTINCT sg.song_id) AS count").
+			Joins("JOIN song_genres AS sg ON sg.genre_id = tree.genre").
+			Joins("JOIN genres AS g ON g.id = tree.ancestor").
+			Where("sg.song_id IN (?)", songs.Select("id")).
+			Group("g.slug, g.name").
+			Order("count DESC, value").
+			Limit(FacetLimit)
+	default:
+		return songs.
+			Select("'group' AS facet, group_name AS value, '' AS name, COUNT(*) AS count").
+			Group("group_name").
+			Order("count DESC, value").
+			Limit(FacetLimit)
+	}
+}
+
+// genreClosure pairs every genre with itself and each of its subgenres, so a song counts
+// towards its genres' ancestors too.
+const genreClosure = `WITH RECURSIVE tree AS (
+		SELECT id AS ancestor, id AS genre FROM genres
+		UNION
+		SELECT tree.ancestor, g.id FROM tree JOIN genres AS g ON g.parent_id = tree.genre
+	) SELECT ancestor, genre FROM tree`
+
+// sortFacet orders years newest first and other values by count, as UNION ALL does not
+// keep the order of its branches.
+func sortFacet(facet string, values []FacetValue) {
+	sort.SliceStable(values, func(i, j int) bool {
+		if facet == "year" {
+			return values[i].Value > values[j].Value
+		}
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+}
+
+func without(filters map[string]interface{}, key string) map[string]interface{} {
+	rest := make(map[string]interface{}, len(filters))
+	for k, v := range filters {
+		if k != key {
+			rest[k] = v
+		}
+	}
+	return rest
+}
+
+// genreCondition matches songs in any of the genres or their subgenres.
+const genreCondition = `id IN (SELECT sg.song_id FROM song_genres AS sg WHERE sg.genre_id IN (
+	WITH RECURSIVE sub AS (
+		SELECT id FROM genres WHERE slug IN ?
+		UNION SELECT g.id FROM genres AS g JOIN sub ON g.parent_id = sub.id
+	) SELECT id FROM sub))`
+
+// tagCondition matches songs with any of the tags.
+const tagCondition = `id IN (SELECT st.song_id FROM song_tags AS st JOIN tags AS t ON t.id = st.tag_id WHERE t.name IN ?)`
+
+// creditCondition matches songs crediting the person, given by ID or by name ignoring
+// case, with the role.
+func creditCondition(query *gorm.DB, role, person string) *gorm.DB {
+	const credits = `id IN (SELECT sc.song_id FROM song_credits AS sc JOIN people AS p ON p.id = sc.person_id WHERE sc.role = ? AND `
+
+	if id, err := uuid.Parse(person); err == nil {
+		return query.Where(credits+`p.id = ?)`, role, id)
+	}
+	return query.Where(credits+`LOWER(p.name) = LOWER(?))`, role, person)
+}
+
+func termCondition(query *gorm.DB, condition string, f TermFilter) *gorm.DB {
+	if !f.All {
+		return query.Where(condition, f.Values)
+	}
+
+	for _, v := range f.Values {
+		query = query.Where(condition, []string{v})
+	}
+	return query
+}
+
+// GetLyrics returns a page of the song's lyric lines. When lang is set the lines come
+// from the song's version in that language, or from the original when it has none.
+func (r *Repository) GetLyrics(group, song, lang string, page, pageSize int) (*pagination.Pages, error) {
+	r.logger.Debug().Msgf("GetLyrics called with group: %s, song: %s, lang: %s, page: %d, pageSize: %d", group, song, lang, page, pageSize)
 
 	s := &Song{}
 
-	if err := r.db.Where("group_name = ? AND song_name = ?", group, song).First(s).Error; err != nil {
+	query := r.db.Where(groupCondition, sql.Named("key", translit.Key(group))).Where("song_key = ?", translit.Key(song))
+	if err := query.First(s).Error; err != nil {
 		return nil, err
 	}
 
+	if lang != "" {
+		if err := r.Localize(s, lang); err != nil {
+			return nil, err
+		}
+	}
+
 	// Split the lyrics by newline character to get individual verses
 	allVerses := strings.Split(s.Text, "\n")
 	total := len(allVerses)
@@ -102,10 +322,114 @@ func (r *Repository) GetLyrics(group, song string, page, pageSize int) (*paginat
 	return pages, nil
 }
 
+// SyncLanguage sets the song's language to that of its recorded original, if any, as an
+// original set by hand overrides the detected language.
+func SyncLanguage(tx *gorm.DB, id uuid.UUID) error {
+	return tx.Exec(`UPDATE songs SET language = l.language FROM song_lyrics AS l
+		WHERE l.song_id = songs.id AND l.is_original AND songs.id = ?`, id).Error
+}
+
+// Versions returns the song's language versions, the original first, then by language.
+func (r *Repository) Versions(songID uuid.UUID) ([]Lyrics, error) {
+	versions := []Lyrics{}
+	err := r.db.Table("song_lyrics AS l").
+		Select("l.song_id, l.language, l.is_original, COALESCE(l.text, s.text, '') AS text, l.translator").
+		Joins("JOIN songs AS s ON s.id = l.song_id").
+		Where("l.song_id = ?", songID).
+		Order("l.is_original DESC, l.language").
+		Scan(&versions).Error
+
+	return versions, err
+}
+
+// Localize loads the song's versions and switches its text to the one in lang.
+func (r *Repository) Localize(s *Song, lang string) error {
+	versions, err := r.Versions(s.ID)
+	if err != nil {
+		return err
+	}
+
+	s.Localize(versions, lang)
+	return nil
+}
+
+// fingerprint selects what duplicate detection needs of a song: names, search keys and
+// the lyrics signature. Lyrics are read only for songs stored without a signature, those
+// saved before signatures and indexed by the bands backfill run after migrating.
+const fingerprint = "id, group_name, song_name, group_key, song_key, minhash, " +
+	"CASE WHEN COALESCE(LENGTH(minhash), 0) = 0 THEN text ELSE '' END AS text"
+
+// Candidates returns the songs that may duplicate s: those sharing a band hash of its
+// lyrics, or its group and title.
+func (r *Repository) Candidates(s *Song) ([]Song, error) {
+	cond := r.db.Where("id IN (SELECT song_id FROM song_bands WHERE (band, hash) IN ?)", bandPairs(s))
+	if s.GroupKey != "" || s.SongKey != "" {
+		cond = cond.Or("group_key = ? AND song_key = ?", s.GroupKey, s.SongKey)
+	}
+
+	songs := []Song{}
+	err := r.db.Model(&Song{}).Select(fingerprint).Where(cond).Where("id <> ?", s.ID).Order("id").Find(&songs).Error
+
+	return songs, err
+}
+
+// ClusterCandidates returns the songs that may duplicate another song: those sharing a
+// band hash or their group and title with one.
+func (r *Repository) ClusterCandidates() ([]Song, error) {
+	songs := []Song{}
+	err := r.db.Model(&Song{}).
+		Select(fingerprint).
+		Where(`EXISTS (SELECT 1 FROM song_bands AS b JOIN song_bands AS o
+			ON o.band = b.band AND o.hash = b.hash AND o.song_id <> b.song_id WHERE b.song_id = songs.id)`).
+		Or(`(group_key <> '' OR song_key <> '') AND EXISTS (SELECT 1 FROM songs AS o
+			WHERE o.group_key = songs.group_key AND o.song_key = songs.song_key AND o.id <> songs.id)`).
+		Order("id").
+		Find(&songs).Error
+
+	return songs, err
+}
+
+// Stats returns the song with its stats. Lyrics are read only when it was stored without
+// stats.
+func (r *Repository) Stats(id uuid.UUID) (*Song, error) {
+	s := &Song{}
+	err := r.db.Model(&Song{}).
+		Select("id, stats, CASE WHEN stats IS NULL THEN text ELSE '' END AS text").
+		Where("id = ?", id).
+		First(s).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GroupStats returns the songs of the group under any of its names with their group name
+// and stats. Lyrics are read only for songs stored without stats.
+func (r *Repository) GroupStats(group string) ([]Song, error) {
+	songs := []Song{}
+	err := r.db.Model(&Song{}).
+		Select("id, group_name, stats, CASE WHEN stats IS NULL THEN text ELSE '' END AS text").
+		Where(groupCondition, sql.Named("key", translit.Key(group))).
+		Order("id").
+		Find(&songs).Error
+
+	return songs, err
+}
+
 func (r *Repository) Create(song *Song) (*Song, error) {
 	r.logger.Debug().Msgf("Attempting to create a new song: %+v", song)
 
-	if err := r.db.Create(song).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(song).Error; err != nil {
+			return err
+		}
+		if err := IndexBands(tx, song); err != nil {
+			return err
+		}
+		return IndexLines(tx, song)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -125,13 +449,28 @@ func (r *Repository) Read(id uuid.UUID) (*Song, error) {
 func (r *Repository) Update(song *Song) (int64, error) {
 	r.logger.Debug().Msgf("Attempting to update song with ID: %d, data: %+v", song.ID, song)
 
-	result := r.db.Model(&Song{}).
-		Select("Group", "Song", "Text", "ReleaseDate", "Link").
-		Where("id = ?", song.ID).
-		Updates(song)
+	var rows int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Song{}).
+			Select("Group", "Song", "Text", "ReleaseDate", "Link", "ChordPro", "Language", "GroupKey", "SongKey", "Minhash", "Stats").
+			Where("id = ?", song.ID).
+			Updates(song)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
 
-	r.logger.Debug().Msgf("Successfully updated song with ID: %d, rows affected: %d", song.ID, result.RowsAffected)
-	return result.RowsAffected, result.Error
+		rows = result.RowsAffected
+		if err := SyncLanguage(tx, song.ID); err != nil {
+			return err
+		}
+		if err := IndexBands(tx, song); err != nil {
+			return err
+		}
+		return IndexLines(tx, song)
+	})
+
+	r.logger.Debug().Msgf("Successfully updated song with ID: %d, rows affected: %d", song.ID, rows)
+	return rows, err
 }
 
 func (r *Repository) Delete(id uuid.UUID) (int64, error) {
@@ -2,33 +2,39 @@ package song_test
 
 import (
 	"testing"
-	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 
-	"songs/api/resource/book"
+	"songs/api/resource/song"
 	mockDB "songs/mock/db"
 	testUtil "songs/util/test"
 )
 
+var testLogger = zerolog.Nop()
+
 func TestRepository_List(t *testing.T) {
 	t.Parallel()
 
 	db, mock, err := mockDB.NewMockDB()
 	testUtil.NoError(t, err)
 
-	repo := book.NewRepository(db)
+	repo := song.NewRepository(db, &testLogger)
+
+	mock.ExpectQuery("^SELECT count\\(\\*\\) FROM \"songs\"").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 
-	mockRows := sqlmock.NewRows([]string{"id", "title", "author"}).
-		AddRow(uuid.New(), "Book1", "Author1").
-		AddRow(uuid.New(), "Book2", "Author2")
+	mockRows := sqlmock.NewRows([]string{"id", "group_name", "song_name"}).
+		AddRow(uuid.New(), "Group1", "Song1").
+		AddRow(uuid.New(), "Group2", "Song2")
 
-	mock.ExpectQuery("^SELECT (.+) FROM \"books\"").WillReturnRows(mockRows)
+	mock.ExpectQuery("^SELECT (.+) FROM \"songs\"").WillReturnRows(mockRows)
 
-	books, err := repo.List()
+	pages, err := repo.List(1, 10, map[string]interface{}{})
 	testUtil.NoError(t, err)
-	testUtil.Equal(t, len(books), 2)
+	testUtil.Equal(t, pages.TotalCount, 2)
+	testUtil.Equal(t, len(pages.Items.([]song.Song)), 2)
 }
 
 func TestRepository_Create(t *testing.T) {
@@ -37,17 +43,28 @@ func TestRepository_Create(t *testing.T) {
 	db, mock, err := mockDB.NewMockDB()
 	testUtil.NoError(t, err)
 
-	repo := book.NewRepository(db)
+	repo := song.NewRepository(db, &testLogger)
 
 	id := uuid.New()
 	mock.ExpectBegin()
-	mock.ExpectExec("^INSERT INTO \"books\" ").
-		WithArgs(id, "Title", "Author", mockDB.AnyTime{}, "", "", mockDB.AnyTime{}, mockDB.AnyTime{}, nil).
+	mock.ExpectExec("^INSERT INTO \"songs\" ").
+		WithArgs(id, "Group", "Song", "Text", "2006-07-16", "https://example.com", "", "", "", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^DELETE FROM "song_bands" WHERE song_id = \$1`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO "song_bands" \("song_id","band","hash"\) VALUES`).
+		WillReturnResult(sqlmock.NewResult(0, 16))
+	mock.ExpectExec(`^DELETE FROM "song_lines" WHERE song_id = \$1`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO "song_lines" \("song_id","line_no","stanza","key"\) VALUES \(\$1,\$2,\$3,\$4\)`).
+		WithArgs(id, 1, 1, "text").
+		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit()
 
-	book := &book.Book{ID: id, Title: "Title", Author: "Author", PublishedDate: time.Now()}
-	_, err = repo.Create(book)
+	s := &song.Song{ID: id, Group: "Group", Song: "Song", Text: "Text", ReleaseDate: "2006-07-16", Link: "https://example.com"}
+	_, err = repo.Create(s)
 	testUtil.NoError(t, err)
 }
 
@@ -57,19 +74,19 @@ func TestRepository_Read(t *testing.T) {
 	db, mock, err := mockDB.NewMockDB()
 	testUtil.NoError(t, err)
 
-	repo := book.NewRepository(db)
+	repo := song.NewRepository(db, &testLogger)
 
 	id := uuid.New()
-	mockRows := sqlmock.NewRows([]string{"id", "title", "author"}).
-		AddRow(id, "Book1", "Author1")
+	mockRows := sqlmock.NewRows([]string{"id", "group_name", "song_name"}).
+		AddRow(id, "Group1", "Song1")
 
-	mock.ExpectQuery("^SELECT (.+) FROM \"books\" WHERE (.+)").
+	mock.ExpectQuery("^SELECT (.+) FROM \"songs\" WHERE (.+)").
 		WithArgs(id, 1).
 		WillReturnRows(mockRows)
 
-	book, err := repo.Read(id)
+	s, err := repo.Read(id)
 	testUtil.NoError(t, err)
-	testUtil.Equal(t, "Book1", book.Title)
+	testUtil.Equal(t, "Song1", s.Song)
 }
 
 func TestRepository_Update(t *testing.T) {
@@ -78,20 +95,31 @@ func TestRepository_Update(t *testing.T) {
 	db, mock, err := mockDB.NewMockDB()
 	testUtil.NoError(t, err)
 
-	repo := book.NewRepository(db)
+	repo := song.NewRepository(db, &testLogger)
 
 	id := uuid.New()
-	_ = sqlmock.NewRows([]string{"id", "title", "author"}).
-		AddRow(id, "Book1", "Author1")
-
 	mock.ExpectBegin()
-	mock.ExpectExec("^UPDATE \"books\" SET").
-		WithArgs("Title", "Author", mockDB.AnyTime{}, "", "", mockDB.AnyTime{}, id).
+	mock.ExpectExec("^UPDATE \"songs\" SET").
+		WithArgs("Group", "Song", "Text", "2006-07-16", "https://example.com", "", "", "group", "song", sqlmock.AnyArg(), sqlmock.AnyArg(), id).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`^UPDATE songs SET language = l.language FROM song_lyrics AS l\s+WHERE l.song_id = songs.id AND l.is_original AND songs.id = \$1`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^DELETE FROM "song_bands" WHERE song_id = \$1`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO "song_bands" \("song_id","band","hash"\) VALUES`).
+		WillReturnResult(sqlmock.NewResult(0, 16))
+	mock.ExpectExec(`^DELETE FROM "song_lines" WHERE song_id = \$1`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`^INSERT INTO "song_lines"`).
+		WithArgs(id, 1, 1, "text").
+		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit()
 
-	book := &book.Book{ID: id, Title: "Title", Author: "Author"}
-	rows, err := repo.Update(book)
+	s := &song.Song{ID: id, Group: "Group", Song: "Song", Text: "Text", ReleaseDate: "2006-07-16", Link: "https://example.com", GroupKey: "group", SongKey: "song"}
+	rows, err := repo.Update(s)
 	testUtil.NoError(t, err)
 	testUtil.Equal(t, 1, rows)
 }
@@ -102,15 +130,12 @@ func TestRepository_Delete(t *testing.T) {
 	db, mock, err := mockDB.NewMockDB()
 	testUtil.NoError(t, err)
 
-	repo := book.NewRepository(db)
+	repo := song.NewRepository(db, &testLogger)
 
 	id := uuid.New()
-	_ = sqlmock.NewRows([]string{"id", "title", "author"}).
-		AddRow(id, "Book1", "Author1")
-
 	mock.ExpectBegin()
-	mock.ExpectExec("^UPDATE \"books\" SET \"deleted_at\"").
-		WithArgs(mockDB.AnyTime{}, id).
+	mock.ExpectExec("^DELETE FROM \"songs\" WHERE").
+		WithArgs(id).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
@@ -118,3 +143,120 @@ func TestRepository_Delete(t *testing.T) {
 	testUtil.NoError(t, err)
 	testUtil.Equal(t, 1, rows)
 }
+
+func TestRepository_ReadMany(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := song.NewRepository(db, &testLogger)
+
+	first, second, missing := uuid.New(), uuid.New(), uuid.New()
+	mockRows := sqlmock.NewRows([]string{"id", "group_name", "song_name"}).
+		AddRow(first, "Group1", "Song1").
+		AddRow(second, "Group2", "Song2")
+
+	mock.ExpectQuery("^SELECT (.+) FROM \"songs\" WHERE id IN").
+		WithArgs(second, missing, first).
+		WillReturnRows(mockRows)
+
+	songs, err := repo.ReadMany([]uuid.UUID{second, missing, first})
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 2, len(songs))
+	testUtil.Equal(t, "Song2", songs[0].Song)
+	testUtil.Equal(t, "Song1", songs[1].Song)
+}
+
+func TestRepository_Facets(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := song.NewRepository(db, &testLogger)
+
+	// The group facet ignores the group filter but keeps the year one, and the other way round.
+	mock.ExpectQuery("^\\(SELECT 'group' AS facet, (.+) WHERE LEFT\\(release_date, 4\\) = \\$1 GROUP BY \"group_name\" (.+)\\) UNION ALL "+
+		"\\(SELECT 'year' AS facet, (.+) WHERE \\(group_key = \\$3 (.+)\\) AND release_date <> '' GROUP BY LEFT\\(release_date, 4\\) (.+)\\) UNION ALL "+
+		"\\(SELECT 'genre' AS facet, (.+) FROM \\(WITH RECURSIVE tree AS (.+)\\) AS tree (.+) WHERE sg.song_id IN \\(SELECT \"id\" FROM \"songs\" WHERE (.+)\\) (.+)\\)$").
+		WithArgs("2006", song.FacetLimit, "muse", "muse", "muse", "muse", song.FacetLimit,
+			sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), song.FacetLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"facet", "value", "name", "count"}).
+			AddRow("group", "Muse", "", 2).
+			AddRow("group", "Arctic Monkeys", "", 5).
+			AddRow("year", "2003", "", 1).
+			AddRow("year", "2006", "", 2).
+			AddRow("genre", "rock", "Rock", 2))
+
+	filters := map[string]interface{}{"group_name": "Muse", "year": "2006"}
+	facets, err := repo.Facets(filters, []string{"group", "year", "genre"})
+	testUtil.NoError(t, err)
+
+	testUtil.Equal(t, "Arctic Monkeys", facets["group"][0].Value)
+	testUtil.Equal(t, 5, facets["group"][0].Count)
+	testUtil.Equal(t, "2006", facets["year"][0].Value)
+	testUtil.Equal(t, "Rock", facets["genre"][0].Name)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_GroupStats(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := song.NewRepository(db, &testLogger)
+
+	mock.ExpectQuery(`^SELECT id, group_name, stats, CASE WHEN stats IS NULL THEN text ELSE '' END AS text FROM "songs" WHERE \(group_key = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "group_name", "stats", "text"}).
+			AddRow(uuid.New(), "Кино", `{"songs":1,"words":4,"lines":1,"frequencies":{"группа":1,"крови":1,"на":1,"рукаве":1}}`, "").
+			AddRow(uuid.New(), "Kino", nil, "Пачка сигарет"))
+
+	songs, err := repo.GroupStats("KINO")
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, len(songs), 2)
+	testUtil.Equal(t, songs[0].Counts().Words, 4)
+	testUtil.Equal(t, songs[1].Counts().Words, 2)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_Candidates(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := song.NewRepository(db, &testLogger)
+
+	s := &song.Song{ID: uuid.New(), Text: "Группа крови на рукаве", GroupKey: "kino", SongKey: "grupa krovi"}
+	other := uuid.New()
+	mock.ExpectQuery(`^SELECT id, group_name, song_name, group_key, song_key, minhash, (.+) FROM "songs" ` +
+		`WHERE \(id IN \(SELECT song_id FROM song_bands WHERE \(band, hash\) IN \(\(\$1,\$2\),(.+)\)\) OR \(group_key = \$33 AND song_key = \$34\)\) AND id <> \$35 ORDER BY id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "group_name", "song_name", "group_key", "song_key", "minhash", "text"}).
+			AddRow(other, "Кино", "Группа крови", "kino", "grupa krovi", nil, "Группа крови на рукаве"))
+
+	candidates, err := repo.Candidates(s)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 1, len(candidates))
+	testUtil.Equal(t, other, candidates[0].ID)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepository_ClusterCandidates(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	repo := song.NewRepository(db, &testLogger)
+
+	mock.ExpectQuery(`^SELECT (.+) FROM "songs" WHERE \(EXISTS \(SELECT 1 FROM song_bands AS b JOIN song_bands AS o\s+ON o.band = b.band AND o.hash = b.hash (.+)\)\) ` +
+		`OR \(\(group_key <> '' OR song_key <> ''\) AND EXISTS \(SELECT 1 FROM songs AS o\s+WHERE o.group_key = songs.group_key (.+)\)\) ORDER BY id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	candidates, err := repo.ClusterCandidates()
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, 0, len(candidates))
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,4 @@
+package stats
+
+// ArtistName exposes artist to the tests.
+var ArtistName = artist
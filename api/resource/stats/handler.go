@@ -0,0 +1,144 @@
+package stats
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/common/decode"
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/api/resource/common/render"
+	"songs/api/resource/song"
+	"songs/pkg/lyricstats"
+	ctxUtil "songs/util/ctx"
+)
+
+const (
+	defaultTopWords = 20
+	maxTopWords     = 100
+)
+
+type API struct {
+	logger *zerolog.Logger
+	songs  *song.Repository
+}
+
+func New(logger *zerolog.Logger, db *gorm.DB) *API {
+	return &API{
+		logger: logger,
+		songs:  song.NewRepository(db, logger),
+	}
+}
+
+// Song godoc
+//
+//	@summary		Song lyric stats
+//	@description	Count the words, unique words, lines and stanzas of the song's lyrics, how many lines and stanzas
+//	@description	repeat an earlier one, such as choruses, and the most frequent words other than Russian and English
+//	@description	stop words. Counts are stored when the song is saved.
+//	@tags			stats
+//	@produce		json
+//	@param			id	path		string	true	"Song ID"
+//	@param			top	query		int		false	"Number of most frequent words (default is 20, max is 100)"
+//	@success		200	{object}	SongStats
+//	@failure		400	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@router			/{id}/stats [get]
+func (a *API) Song(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Song function started")
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
+		return
+	}
+
+	top, err := decode.QueryInt(r, "top", defaultTopWords, 0, maxTopWords)
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+
+	s, err := a.songs.Stats(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			e.NotFound(w, r, e.RespSongNotFound)
+		} else {
+			a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to access the song in the database")
+			e.ServerError(w, r, e.RespDBDataAccessFailure)
+		}
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", s.ID.String()).Msg("Song stats retrieved")
+	render.WriteJSON(w, r, a.logger, http.StatusOK, SongStats{ID: s.ID, Stats: s.Counts().Stats(top)})
+}
+
+// Artist godoc
+//
+//	@summary		Artist lyric stats
+//	@description	Sum the lyric stats of all songs of the artist, found under any spelling or alias of the name.
+//	@description	Repeated lines and stanzas count within each song.
+//	@tags			stats
+//	@produce		json
+//	@param			name	path		string	true	"Artist (group) name"
+//	@param			top		query		int		false	"Number of most frequent words (default is 20, max is 100)"
+//	@success		200		{object}	ArtistStats
+//	@failure		400		{object}	err.Problem
+//	@failure		404		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@router			/artists/{name}/stats [get]
+func (a *API) Artist(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	a.logger.Debug().Str(l.KeyReqID, reqID).Msg("Artist function started")
+
+	name := strings.TrimSpace(chi.URLParam(r, "name"))
+
+	top, err := decode.QueryInt(r, "top", defaultTopWords, 0, maxTopWords)
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+
+	songs, err := a.songs.GroupStats(name)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to read artist stats")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+	if len(songs) == 0 {
+		e.NotFound(w, r, e.RespGroupNotFound)
+		return
+	}
+
+	total := &lyricstats.Counts{}
+	for i := range songs {
+		total.Add(songs[i].Counts())
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("artist", name).Int("songs", len(songs)).Msg("Artist stats retrieved")
+	render.WriteJSON(w, r, a.logger, http.StatusOK, ArtistStats{Artist: artist(songs), Stats: total.Stats(top)})
+}
+
+// artist names the artist by the group name most of the songs are stored under.
+func artist(songs []song.Song) string {
+	counts := map[string]int{}
+	best := ""
+	for _, s := range songs {
+		counts[s.Group]++
+		if n := counts[s.Group]; n > counts[best] || n == counts[best] && s.Group < best {
+			best = s.Group
+		}
+	}
+	return best
+}
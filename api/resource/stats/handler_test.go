@@ -0,0 +1,126 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	e "songs/api/resource/common/err"
+	"songs/api/resource/song"
+	"songs/api/resource/stats"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+var testLogger = zerolog.Nop()
+
+func newRouter(t *testing.T) (http.Handler, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	api := stats.New(&testLogger, db)
+	r := chi.NewRouter()
+	r.Get("/{id}/stats", api.Song)
+	r.Get("/artists/{name}/stats", api.Artist)
+
+	return r, mock
+}
+
+func serve(h http.Handler, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+	return w
+}
+
+func problemType(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	var p e.Problem
+	testUtil.NoError(t, json.NewDecoder(w.Body).Decode(&p))
+	return p.Type
+}
+
+func TestAPI_Song(t *testing.T) {
+	t.Parallel()
+
+	h, mock := newRouter(t)
+
+	id := uuid.New()
+	mock.ExpectQuery(`^SELECT id, stats, CASE WHEN stats IS NULL THEN text ELSE '' END AS text FROM "songs" WHERE id = \$1`).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "stats", "text"}).AddRow(id, nil, "Ночь короче дня\nНочь короче дня"))
+
+	w := serve(h, "/"+id.String()+"/stats?top=1")
+	testUtil.Equal(t, http.StatusOK, w.Code)
+
+	var body stats.SongStats
+	testUtil.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	testUtil.Equal(t, id, body.ID)
+	testUtil.Equal(t, 2, body.Lines)
+	testUtil.Equal(t, 1, body.RepeatedLines)
+	testUtil.Equal(t, 1, len(body.TopWords))
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPI_Song_NotFound(t *testing.T) {
+	t.Parallel()
+
+	h, mock := newRouter(t)
+
+	mock.ExpectQuery(`^SELECT (.+) FROM "songs" WHERE id = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "stats", "text"}))
+
+	w := serve(h, "/"+uuid.New().String()+"/stats")
+	testUtil.Equal(t, http.StatusNotFound, w.Code)
+	testUtil.Equal(t, e.RespSongNotFound.Type, problemType(t, w))
+}
+
+func TestAPI_Song_BadRequest(t *testing.T) {
+	t.Parallel()
+
+	h, _ := newRouter(t)
+
+	for _, target := range []string{"/not-an-id/stats", "/" + uuid.New().String() + "/stats?top=101", "/" + uuid.New().String() + "/stats?top=-1"} {
+		w := serve(h, target)
+		testUtil.Equal(t, http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestAPI_Artist_NotFound(t *testing.T) {
+	t.Parallel()
+
+	h, mock := newRouter(t)
+
+	mock.ExpectQuery(`^SELECT id, group_name, stats, (.+) FROM "songs"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "group_name", "stats", "text"}))
+
+	w := serve(h, "/artists/Nobody/stats")
+	testUtil.Equal(t, http.StatusNotFound, w.Code)
+	testUtil.Equal(t, e.RespGroupNotFound.Type, problemType(t, w))
+}
+
+func TestArtistName(t *testing.T) {
+	t.Parallel()
+
+	songs := func(groups ...string) []song.Song {
+		s := make([]song.Song, len(groups))
+		for i, g := range groups {
+			s[i].Group = g
+		}
+		return s
+	}
+
+	testUtil.Equal(t, "Кино", stats.ArtistName(songs("Kino", "Кино", "Кино")))
+	// A tie goes to the name first in byte order, whatever the order of the songs.
+	testUtil.Equal(t, "Kino", stats.ArtistName(songs("Кино", "Kino")))
+	testUtil.Equal(t, "Kino", stats.ArtistName(songs("Kino", "Кино")))
+	testUtil.Equal(t, "", stats.ArtistName(nil))
+}
@@ -0,0 +1,19 @@
+package stats
+
+import (
+	"github.com/google/uuid"
+
+	"songs/pkg/lyricstats"
+)
+
+// SongStats are the stats of one song's lyrics.
+type SongStats struct {
+	ID uuid.UUID `json:"id"`
+	lyricstats.Stats
+}
+
+// ArtistStats are the stats of all of an artist's lyrics taken together.
+type ArtistStats struct {
+	Artist string `json:"artist"`
+	lyricstats.Stats
+}
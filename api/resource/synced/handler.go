@@ -0,0 +1,276 @@
+package synced
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/api/resource/song"
+	"songs/pkg/lrc"
+	ctxUtil "songs/util/ctx"
+)
+
+const (
+	ContentTypeLRC = "text/plain; charset=utf-8"
+
+	maxLRCSize = 1 << 20
+)
+
+type API struct {
+	logger     *zerolog.Logger
+	repository *Repository
+	songs      *song.Repository
+}
+
+func New(logger *zerolog.Logger, db *gorm.DB) *API {
+	return &API{
+		logger:     logger,
+		repository: NewRepository(db, logger),
+		songs:      song.NewRepository(db, logger),
+	}
+}
+
+// ReadLRC godoc
+//
+//	@summary		Read synced lyrics
+//	@description	Read the song's time-synced lyrics as an LRC document, including enhanced word timestamps.
+//	@tags			lyrics
+//	@produce		plain
+//	@param			id	path		string	true	"Song ID"
+//	@success		200	{string}	string	"LRC document"
+//	@failure		400	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@router			/{id}/lyrics.lrc [get]
+func (a *API) ReadLRC(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	s, doc, ok := a.load(w, r)
+	if !ok {
+		return
+	}
+
+	if _, ok := doc.Tags["ar"]; !ok {
+		doc.Tags["ar"] = s.Group
+	}
+	if _, ok := doc.Tags["ti"]; !ok {
+		doc.Tags["ti"] = s.Song
+	}
+
+	w.Header().Set("Content-Type", ContentTypeLRC)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": s.Group + " - " + s.Song + ".lrc"}))
+	if err := doc.Format(w); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to write LRC document")
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Msg("Synced lyrics sent")
+}
+
+// UpdateLRC godoc
+//
+//	@summary		Replace synced lyrics
+//	@description	Store an LRC document as the song's time-synced lyrics. The [offset:] tag is applied to every timestamp.
+//	@tags			lyrics
+//	@accept			plain
+//	@produce		json
+//	@param			id		path	string	true	"Song ID"
+//	@param			body	body	string	true	"LRC document"
+//	@success		200
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/{id}/lyrics.lrc [put]
+func (a *API) UpdateLRC(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	a.logger.Debug().Str(l.KeyReqID, reqID).Msg("UpdateLRC function started")
+
+	id, ok := a.songID(w, r)
+	if !ok {
+		return
+	}
+
+	doc, err := lrc.Parse(http.MaxBytesReader(w, r.Body, maxLRCSize))
+	if err != nil {
+		a.logger.Debug().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to parse LRC document")
+		e.BadRequest(w, r, e.RespInvalidLRC.WithDetail(err.Error()))
+		return
+	}
+	if len(doc.Lines) == 0 {
+		e.BadRequest(w, r, e.RespInvalidLRC.WithDetail("document has no timed lines"))
+		return
+	}
+
+	if _, err := a.songs.Read(id); err != nil {
+		a.songError(w, r, err)
+		return
+	}
+
+	if err := a.repository.Save(id, doc.String()); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to save synced lyrics")
+		e.ServerError(w, r, e.RespDBDataUpdateFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Int("lines", len(doc.Lines)).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Synced lyrics updated")
+}
+
+// DeleteLRC godoc
+//
+//	@summary		Delete synced lyrics
+//	@description	Remove the song's time-synced lyrics. The song itself is kept.
+//	@tags			lyrics
+//	@produce		json
+//	@param			id	path	string	true	"Song ID"
+//	@success		200
+//	@failure		400	{object}	err.Problem
+//	@failure		401	{object}	err.Problem
+//	@failure		403	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/{id}/lyrics.lrc [delete]
+func (a *API) DeleteLRC(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := a.songID(w, r)
+	if !ok {
+		return
+	}
+
+	rows, err := a.repository.Delete(id)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to delete synced lyrics")
+		e.ServerError(w, r, e.RespDBDataRemoveFailure)
+		return
+	}
+	if rows == 0 {
+		e.NotFound(w, r, e.RespSyncedNotFound)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Synced lyrics deleted")
+}
+
+// Active godoc
+//
+//	@summary		Active lyric line
+//	@description	Return the line shown at a playback position, the word being sung for enhanced LRC, and the next line.
+//	@tags			lyrics
+//	@produce		json
+//	@param			id	path		string	true	"Song ID"
+//	@param			at	query		string	true	"Playback position in seconds (83.45) or mm:ss.xx (01:23.45)"
+//	@success		200	{object}	ActiveLine
+//	@failure		400	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@router			/{id}/lyrics/active [get]
+func (a *API) Active(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	at, err := parsePosition(r.URL.Query().Get("at"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+
+	_, doc, ok := a.load(w, r)
+	if !ok {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(NewActiveLine(doc, at)); err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to encode active line to JSON")
+		e.ServerError(w, r, e.RespJSONEncodeFailure)
+		return
+	}
+}
+
+// load reads the song and its parsed synced lyrics, answering with a problem when either is missing.
+func (a *API) load(w http.ResponseWriter, r *http.Request) (*song.Song, *lrc.Lyrics, bool) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := a.songID(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+
+	s, err := a.songs.Read(id)
+	if err != nil {
+		a.songError(w, r, err)
+		return nil, nil, false
+	}
+
+	lyrics, err := a.repository.Read(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			e.NotFound(w, r, e.RespSyncedNotFound)
+			return nil, nil, false
+		}
+
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to access synced lyrics in the database")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return nil, nil, false
+	}
+
+	doc, err := lrc.Parse(strings.NewReader(lyrics.LRC))
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Stored LRC document is invalid")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return nil, nil, false
+	}
+
+	return s, doc, true
+}
+
+func (a *API) songID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
+
+func (a *API) songError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		e.NotFound(w, r, e.RespSongNotFound)
+		return
+	}
+
+	a.logger.Error().Str(l.KeyReqID, ctxUtil.RequestID(r.Context())).Err(err).Msg("Failed to access the song in the database")
+	e.ServerError(w, r, e.RespDBDataAccessFailure)
+}
+
+// parsePosition accepts seconds with an optional fraction or an LRC style mm:ss.xx timestamp.
+func parsePosition(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, errors.New("at is required")
+	}
+	if strings.Contains(s, ":") {
+		return lrc.ParseTimestamp(s)
+	}
+
+	sec, err := strconv.ParseFloat(s, 64)
+	if err != nil || sec < 0 {
+		return 0, errors.New("at must be a non-negative number of seconds or mm:ss.xx")
+	}
+
+	return time.Duration(sec * float64(time.Second)), nil
+}
@@ -0,0 +1,81 @@
+package synced
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"songs/pkg/lrc"
+)
+
+// Lyrics holds a song's time-synced lyrics as a normalized LRC document.
+type Lyrics struct {
+	SongID    uuid.UUID `gorm:"primarykey;column:song_id"`
+	LRC       string    `gorm:"column:lrc"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (Lyrics) TableName() string {
+	return "song_synced_lyrics"
+}
+
+// ActiveLine is the line shown at a playback position, together with the line that follows.
+type ActiveLine struct {
+	At   int64    `json:"at_ms"`
+	Line *LineDTO `json:"line"`
+	Next *LineDTO `json:"next"`
+}
+
+type LineDTO struct {
+	Index      int       `json:"index"`
+	Start      int64     `json:"start_ms"`
+	End        *int64    `json:"end_ms,omitempty"`
+	Text       string    `json:"text"`
+	Words      []WordDTO `json:"words,omitempty"`
+	ActiveWord *int      `json:"active_word,omitempty"`
+}
+
+type WordDTO struct {
+	Start int64  `json:"start_ms"`
+	Text  string `json:"text"`
+}
+
+func toLineDTO(doc *lrc.Lyrics, i int) *LineDTO {
+	if i < 0 || i >= len(doc.Lines) {
+		return nil
+	}
+
+	line := doc.Lines[i]
+	dto := &LineDTO{
+		Index: i,
+		Start: line.Start.Milliseconds(),
+		Text:  line.Text,
+	}
+	if i+1 < len(doc.Lines) {
+		end := doc.Lines[i+1].Start.Milliseconds()
+		dto.End = &end
+	}
+	for _, w := range line.Words {
+		dto.Words = append(dto.Words, WordDTO{Start: w.Start.Milliseconds(), Text: w.Text})
+	}
+
+	return dto
+}
+
+// NewActiveLine finds the line shown at the playback position in doc.
+func NewActiveLine(doc *lrc.Lyrics, at time.Duration) *ActiveLine {
+	i := doc.Active(at)
+
+	res := &ActiveLine{
+		At:   at.Milliseconds(),
+		Line: toLineDTO(doc, i),
+		Next: toLineDTO(doc, i+1),
+	}
+	if res.Line != nil && len(res.Line.Words) > 0 {
+		if w := doc.Lines[i].ActiveWord(at); w >= 0 {
+			res.Line.ActiveWord = &w
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,36 @@
+package synced_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"songs/api/resource/synced"
+	"songs/pkg/lrc"
+	testUtil "songs/util/test"
+)
+
+func TestNewActiveLine(t *testing.T) {
+	t.Parallel()
+
+	doc, err := lrc.Parse(strings.NewReader("[00:01.00]First\n[00:05.00]<00:05.00>Second <00:06.00>line\n[00:09.00]Third\n"))
+	testUtil.NoError(t, err)
+
+	before := synced.NewActiveLine(doc, 500*time.Millisecond)
+	if before.Line != nil || before.Next == nil || before.Next.Text != "First" {
+		t.Errorf("unexpected result before the first line: %+v", before)
+	}
+
+	mid := synced.NewActiveLine(doc, 6500*time.Millisecond)
+	testUtil.Equal(t, mid.At, int64(6500))
+	testUtil.Equal(t, mid.Line.Index, 1)
+	testUtil.Equal(t, mid.Line.Text, "Second line")
+	testUtil.Equal(t, *mid.Line.End, int64(9000))
+	testUtil.Equal(t, *mid.Line.ActiveWord, 1)
+	testUtil.Equal(t, mid.Next.Text, "Third")
+
+	last := synced.NewActiveLine(doc, time.Minute)
+	if last.Line.End != nil || last.Next != nil {
+		t.Errorf("expected the last line to be open ended: %+v", last)
+	}
+}
@@ -0,0 +1,50 @@
+package synced
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository struct {
+	db     *gorm.DB
+	logger *zerolog.Logger
+}
+
+func NewRepository(db *gorm.DB, l *zerolog.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: l,
+	}
+}
+
+func (r *Repository) Read(songID uuid.UUID) (*Lyrics, error) {
+	lyrics := &Lyrics{}
+	if err := r.db.Where("song_id = ?", songID).First(lyrics).Error; err != nil {
+		return nil, err
+	}
+
+	return lyrics, nil
+}
+
+// Save stores the document, replacing the song's previous synced lyrics.
+func (r *Repository) Save(songID uuid.UUID, doc string) error {
+	r.logger.Debug().Msgf("Saving synced lyrics for song with ID: %s", songID.String())
+
+	lyrics := &Lyrics{SongID: songID, LRC: doc, UpdatedAt: time.Now()}
+
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "song_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"lrc", "updated_at"}),
+	}).Create(lyrics).Error
+}
+
+func (r *Repository) Delete(songID uuid.UUID) (int64, error) {
+	r.logger.Debug().Msgf("Attempting to delete synced lyrics for song with ID: %s", songID.String())
+
+	result := r.db.Where("song_id = ?", songID).Delete(&Lyrics{})
+	return result.RowsAffected, result.Error
+}
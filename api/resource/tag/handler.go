@@ -0,0 +1,237 @@
+package tag
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/common/decode"
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/api/resource/common/render"
+	"songs/api/resource/song"
+	ctxUtil "songs/util/ctx"
+	validatorUtil "songs/util/validator"
+)
+
+const (
+	defaultCountsLimit = 50
+	maxCountsLimit     = 500
+
+	defaultAutocompleteLimit = 10
+	maxAutocompleteLimit     = 50
+)
+
+type API struct {
+	logger     *zerolog.Logger
+	validator  *validator.Validate
+	repository *Repository
+	songs      *song.Repository
+}
+
+func New(logger *zerolog.Logger, validator *validator.Validate, db *gorm.DB) *API {
+	return &API{
+		logger:     logger,
+		validator:  validator,
+		repository: NewRepository(db, logger),
+		songs:      song.NewRepository(db, logger),
+	}
+}
+
+// List godoc
+//
+//	@summary		List tag counts
+//	@description	List the most used tags with their song counts. The song filters of GET / narrow the songs counted, for faceted navigation.
+//	@tags			tags
+//	@produce		json
+//	@param			limit		query		int			false	"Number of tags (default is 50, max is 500)"
+//	@param			group		query		string		false	"Group name"
+//	@param			song		query		string		false	"Song name"
+//	@param			text		query		string		false	"Text to search within song lyrics"
+//	@param			releaseDate	query		string		false	"Release date"
+//	@param			year		query		string		false	"Release year"
+//	@param			language	query		string		false	"Detected language of the lyrics, e.g. ru"
+//	@param			link		query		string		false	"Song link"
+//	@param			genre		query		[]string	false	"Genre slugs; subgenres match too"	collectionFormat(multi)
+//	@param			genre_match	query		string		false	"Whether songs need all genres or any (default)"	Enums(all, any)
+//	@param			tag			query		[]string	false	"Tags"	collectionFormat(multi)
+//	@param			tag_match	query		string		false	"Whether songs need all tags or any (default)"	Enums(all, any)
+//	@success		200			{array}		Count
+//	@failure		400			{object}	err.Problem
+//	@failure		500			{object}	err.Problem
+//	@router			/tags [get]
+func (a *API) List(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	limit, err := decode.QueryInt(r, "limit", defaultCountsLimit, 1, maxCountsLimit)
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+
+	filters, err := song.Filters(r.URL.Query())
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+
+	var songs *gorm.DB
+	if len(filters) > 0 {
+		songs = a.songs.Filtered(filters)
+	}
+
+	counts, err := a.repository.Counts(songs, limit)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to count tags")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	render.WriteJSON(w, r, a.logger, http.StatusOK, counts)
+}
+
+// Autocomplete godoc
+//
+//	@summary		Autocomplete tags
+//	@description	Suggest the most used tags starting with the given text.
+//	@tags			tags
+//	@produce		json
+//	@param			q		query		string	true	"Beginning of the tag"
+//	@param			limit	query		int		false	"Number of suggestions (default is 10, max is 50)"
+//	@success		200		{array}		Count
+//	@failure		400		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@router			/tags/autocomplete [get]
+func (a *API) Autocomplete(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	prefix := song.NormalizeTag(r.URL.Query().Get("q"))
+	if prefix == "" {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail("q is required"))
+		return
+	}
+
+	limit, err := decode.QueryInt(r, "limit", defaultAutocompleteLimit, 1, maxAutocompleteLimit)
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidQuery.WithDetail(err.Error()))
+		return
+	}
+
+	counts, err := a.repository.Autocomplete(prefix, limit)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to autocomplete tags")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	render.WriteJSON(w, r, a.logger, http.StatusOK, counts)
+}
+
+// SongTags godoc
+//
+//	@summary		List song tags
+//	@description	List a song's tags in alphabetical order.
+//	@tags			tags
+//	@produce		json
+//	@param			id	path		string	true	"Song ID"
+//	@success		200	{array}		string
+//	@failure		400	{object}	err.Problem
+//	@failure		404	{object}	err.Problem
+//	@failure		500	{object}	err.Problem
+//	@router			/{id}/tags [get]
+func (a *API) SongTags(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := a.song(w, r)
+	if !ok {
+		return
+	}
+
+	tags, err := a.repository.SongTags(id)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to retrieve song tags")
+		e.ServerError(w, r, e.RespDBDataAccessFailure)
+		return
+	}
+
+	render.WriteJSON(w, r, a.logger, http.StatusOK, tags)
+}
+
+// SetSongTags godoc
+//
+//	@summary		Set song tags
+//	@description	Replace a song's tags. Tags are lower-cased with their whitespace collapsed, and new tags are created as needed.
+//	@tags			tags
+//	@accept			json
+//	@produce		json
+//	@param			id		path		string		true	"Song ID"
+//	@param			body	body		SongRequest	true	"Tags"
+//	@success		200		{array}		string
+//	@failure		400		{object}	err.Problem
+//	@failure		401		{object}	err.Problem
+//	@failure		403		{object}	err.Problem
+//	@failure		404		{object}	err.Problem
+//	@failure		422		{object}	err.Problem
+//	@failure		500		{object}	err.Problem
+//	@security		BearerAuth
+//	@router			/{id}/tags [put]
+func (a *API) SetSongTags(w http.ResponseWriter, r *http.Request) {
+	reqID := ctxUtil.RequestID(r.Context())
+
+	id, ok := a.song(w, r)
+	if !ok {
+		return
+	}
+
+	req := &SongRequest{}
+	if err := decode.JSON(r, req); err != nil {
+		a.logger.Debug().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to decode JSON")
+		e.BadRequest(w, r, e.RespJSONDecodeFailure.WithDetail(err.Error()))
+		return
+	}
+
+	req.Normalize()
+	if err := a.validator.Struct(req); err != nil {
+		params := validatorUtil.ToInvalidParams(err, ctxUtil.Language(r.Context()))
+
+		a.logger.Debug().Str(l.KeyReqID, reqID).Msgf("Validation errors: %+v", params)
+		e.ValidationErrors(w, r, params)
+		return
+	}
+
+	tags, err := a.repository.SetSongTags(id, req.Tags)
+	if err != nil {
+		a.logger.Error().Str(l.KeyReqID, reqID).Err(err).Msg("Failed to set song tags")
+		e.ServerError(w, r, e.RespDBDataUpdateFailure)
+		return
+	}
+
+	a.logger.Info().Str(l.KeyReqID, reqID).Str("id", id.String()).Strs("tags", tags).Str(l.KeyAPIKey, ctxUtil.APIKeyName(r.Context())).Str(l.KeySubject, ctxUtil.Subject(r.Context())).Msg("Song tags set")
+	render.WriteJSON(w, r, a.logger, http.StatusOK, tags)
+}
+
+// song parses the song ID from the URL and checks the song exists.
+func (a *API) song(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		e.BadRequest(w, r, e.RespInvalidURLParamID)
+		return uuid.Nil, false
+	}
+
+	if _, err := a.songs.Read(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			e.NotFound(w, r, e.RespSongNotFound)
+		} else {
+			a.logger.Error().Str(l.KeyReqID, ctxUtil.RequestID(r.Context())).Err(err).Msg("Failed to access the song in the database")
+			e.ServerError(w, r, e.RespDBDataAccessFailure)
+		}
+		return uuid.Nil, false
+	}
+
+	return id, true
+}
@@ -0,0 +1,58 @@
+package tag
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+
+	"songs/api/resource/song"
+)
+
+type Tag struct {
+	ID   uuid.UUID `gorm:"primarykey"`
+	Name string    `gorm:"column:name"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+type SongTag struct {
+	SongID uuid.UUID `gorm:"column:song_id;primarykey"`
+	TagID  uuid.UUID `gorm:"column:tag_id;primarykey"`
+}
+
+func (SongTag) TableName() string {
+	return "song_tags"
+}
+
+// Count is a tag with the number of songs carrying it.
+type Count struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+type SongRequest struct {
+	Tags []string `json:"tags" form:"max=50,dive,required,max=64"`
+}
+
+// Normalize normalizes every tag and drops empty and repeated ones, keeping the first
+// mention's order.
+func (r *SongRequest) Normalize() {
+	seen := map[string]bool{}
+	tags := make([]string, 0, len(r.Tags))
+
+	for _, t := range r.Tags {
+		if t = song.NormalizeTag(t); t != "" && !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+
+	r.Tags = tags
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,25 @@
+package tag_test
+
+import (
+	"reflect"
+	"testing"
+
+	"songs/api/resource/tag"
+	"songs/util/validator"
+)
+
+func TestSongRequest_Normalize(t *testing.T) {
+	t.Parallel()
+
+	req := tag.SongRequest{Tags: []string{"Road  Trip", " ", "summer", "road trip", "SUMMER "}}
+	req.Normalize()
+
+	expected := []string{"road trip", "summer"}
+	if !reflect.DeepEqual(req.Tags, expected) {
+		t.Fatalf(`Expected:"%v", Got:"%v"`, expected, req.Tags)
+	}
+
+	if err := validator.New().Struct(req); err != nil {
+		t.Fatalf("valid request rejected: %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package tag
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository struct {
+	db     *gorm.DB
+	logger *zerolog.Logger
+}
+
+func NewRepository(db *gorm.DB, l *zerolog.Logger) *Repository {
+	return &Repository{
+		db:     db,
+		logger: l,
+	}
+}
+
+// Counts returns the most used tags with their song counts. When songs is not nil only
+// the songs it selects are counted.
+func (r *Repository) Counts(songs *gorm.DB, limit int) ([]Count, error) {
+	query := r.counts()
+	if songs != nil {
+		query = query.Where("st.song_id IN (?)", songs.Select("id"))
+	}
+
+	counts := []Count{}
+	err := query.Limit(limit).Scan(&counts).Error
+	return counts, err
+}
+
+// Autocomplete returns the most used tags starting with prefix.
+func (r *Repository) Autocomplete(prefix string, limit int) ([]Count, error) {
+	counts := []Count{}
+	err := r.counts().
+		Where("t.name LIKE ?", escapeLike(prefix)+"%").
+		Limit(limit).
+		Scan(&counts).Error
+
+	return counts, err
+}
+
+func (r *Repository) counts() *gorm.DB {
+	return r.db.Table("song_tags AS st").
+		Select("t.name AS tag, COUNT(*) AS count").
+		Joins("JOIN tags AS t ON t.id = st.tag_id").
+		Group("t.name").
+		Order("count DESC, t.name")
+}
+
+// SongTags returns the song's tags in alphabetical order.
+func (r *Repository) SongTags(songID uuid.UUID) ([]string, error) {
+	tags := []string{}
+	err := r.db.Table("tags AS t").
+		Joins("JOIN song_tags AS st ON st.tag_id = t.id").
+		Where("st.song_id = ?", songID).
+		Order("t.name").
+		Pluck("t.name", &tags).Error
+
+	return tags, err
+}
+
+// SetSongTags replaces the song's tags, creating tags that do not exist yet. The names
+// must already be normalized.
+func (r *Repository) SetSongTags(songID uuid.UUID, names []string) ([]string, error) {
+	r.logger.Debug().Msgf("Setting tags of song %s: %v", songID, names)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("song_id = ?", songID).Delete(&SongTag{}).Error; err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+
+		tags := make([]Tag, len(names))
+		for i, name := range names {
+			tags[i] = Tag{ID: uuid.New(), Name: name}
+		}
+		if err := tx.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&tags).Error; err != nil {
+			return err
+		}
+
+		var ids []uuid.UUID
+		if err := tx.Model(&Tag{}).Where("name IN ?", names).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+
+		rows := make([]SongTag, len(ids))
+		for i, id := range ids {
+			rows[i] = SongTag{SongID: songID, TagID: id}
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+	return sorted, nil
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"songs/api/resource/apikey"
+	e "songs/api/resource/common/err"
+	"songs/util/auth"
+	ctxUtil "songs/util/ctx"
+)
+
+const authorizationHeaderKey = "Authorization"
+
+type KeyAuthenticator interface {
+	Authenticate(raw string) (*apikey.Key, error)
+}
+
+type TokenVerifier interface {
+	Verify(token string) (*auth.Identity, error)
+}
+
+// Authenticate resolves the caller from `Authorization: Bearer <credential>`.
+// Credentials carrying the API key prefix are checked against keys, anything else
+// is verified as a JWT by tokens, which may be nil when JWT auth is disabled.
+// Requests without credentials pass through anonymously; RequireRole decides
+// whether that is acceptable for the route.
+func Authenticate(keys KeyAuthenticator, tokens TokenVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := bearerToken(r)
+			if token == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+
+			if apikey.IsKey(token) {
+				key, err := keys.Authenticate(token)
+				switch {
+				case errors.Is(err, apikey.ErrExpiredKey):
+					e.Unauthorized(w, r, e.RespKeyExpired)
+					return
+				case errors.Is(err, apikey.ErrInvalidKey):
+					e.Unauthorized(w, r, e.RespUnauthorized)
+					return
+				case err != nil:
+					e.ServerError(w, r, e.RespAuthFailure)
+					return
+				}
+
+				ctx = ctxUtil.SetAPIKeyName(ctx, key.Name)
+				ctx = ctxUtil.SetRole(ctx, keyRole(key).String())
+			} else {
+				if tokens == nil {
+					e.Unauthorized(w, r, e.RespUnauthorized)
+					return
+				}
+
+				identity, err := tokens.Verify(token)
+				if err != nil {
+					e.Unauthorized(w, r, e.RespUnauthorized)
+					return
+				}
+
+				ctx = ctxUtil.SetSubject(ctx, identity.Subject)
+				ctx = ctxUtil.SetRole(ctx, identity.Role.String())
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequireRole rejects callers whose role is lower than role:
+// anonymous callers get 401, authenticated ones 403.
+func RequireRole(role auth.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := ctxUtil.Role(r.Context())
+
+			current, _ := auth.ParseRole(name)
+			if current < role {
+				if name == "" {
+					e.Unauthorized(w, r, e.RespUnauthorized)
+				} else {
+					e.Forbidden(w, r, e.RespForbidden)
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func keyRole(key *apikey.Key) auth.Role {
+	switch {
+	case key.HasScope(apikey.ScopeAdmin):
+		return auth.RoleAdmin
+	case key.HasScope(apikey.ScopeWrite):
+		return auth.RoleEditor
+	case key.HasScope(apikey.ScopeRead):
+		return auth.RoleViewer
+	default:
+		return auth.RoleAnonymous
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get(authorizationHeaderKey)
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
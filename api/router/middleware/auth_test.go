@@ -0,0 +1,128 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"songs/api/resource/apikey"
+	"songs/api/router/middleware"
+	"songs/util/auth"
+	ctxUtil "songs/util/ctx"
+)
+
+type testKeyAuthenticator map[string]*apikey.Key
+
+func (a testKeyAuthenticator) Authenticate(raw string) (*apikey.Key, error) {
+	key, ok := a[raw]
+	if !ok {
+		return nil, apikey.ErrInvalidKey
+	}
+	if key.Expired(time.Now()) {
+		return nil, apikey.ErrExpiredKey
+	}
+
+	return key, nil
+}
+
+type testTokenVerifier map[string]*auth.Identity
+
+func (v testTokenVerifier) Verify(token string) (*auth.Identity, error) {
+	identity, ok := v[token]
+	if !ok {
+		return nil, auth.ErrInvalidToken
+	}
+
+	return identity, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	expired := time.Now().Add(-time.Hour)
+	keys := testKeyAuthenticator{
+		"songs_writer":  {Name: "writer", Scopes: "read write"},
+		"songs_reader":  {Name: "reader", Scopes: "read"},
+		"songs_admin":   {Name: "admin", Scopes: "admin"},
+		"songs_expired": {Name: "expired", Scopes: "write", ExpiresAt: &expired},
+	}
+	tokens := testTokenVerifier{
+		"jwt-viewer": {Subject: "alice", Role: auth.RoleViewer},
+		"jwt-editor": {Subject: "bob", Role: auth.RoleEditor},
+		"jwt-none":   {Subject: "carol", Role: auth.RoleAnonymous},
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		role    auth.Role
+		status  int
+		keyName string
+		subject string
+	}{
+		{name: "anonymous on public route", role: auth.RoleAnonymous, status: http.StatusOK},
+		{name: "anonymous on viewer route", role: auth.RoleViewer, status: http.StatusUnauthorized},
+		{name: "read key on viewer route", token: "songs_reader", role: auth.RoleViewer, status: http.StatusOK, keyName: "reader"},
+		{name: "invalid key on public route", token: "songs_nope", role: auth.RoleAnonymous, status: http.StatusUnauthorized},
+		{name: "anonymous on editor route", role: auth.RoleEditor, status: http.StatusUnauthorized},
+		{name: "invalid key on editor route", token: "songs_nope", role: auth.RoleEditor, status: http.StatusUnauthorized},
+		{name: "expired key on editor route", token: "songs_expired", role: auth.RoleEditor, status: http.StatusUnauthorized},
+		{name: "read key on editor route", token: "songs_reader", role: auth.RoleEditor, status: http.StatusForbidden},
+		{name: "write key on editor route", token: "songs_writer", role: auth.RoleEditor, status: http.StatusOK, keyName: "writer"},
+		{name: "admin key on editor route", token: "songs_admin", role: auth.RoleEditor, status: http.StatusOK, keyName: "admin"},
+		{name: "write key on admin route", token: "songs_writer", role: auth.RoleAdmin, status: http.StatusForbidden},
+		{name: "invalid jwt", token: "jwt-nope", role: auth.RoleAnonymous, status: http.StatusUnauthorized},
+		{name: "viewer jwt on viewer route", token: "jwt-viewer", role: auth.RoleViewer, status: http.StatusOK, subject: "alice"},
+		{name: "viewer jwt on editor route", token: "jwt-viewer", role: auth.RoleEditor, status: http.StatusForbidden},
+		{name: "editor jwt on editor route", token: "jwt-editor", role: auth.RoleEditor, status: http.StatusOK, subject: "bob"},
+		{name: "jwt without roles on viewer route", token: "jwt-none", role: auth.RoleViewer, status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, _ := http.NewRequest(http.MethodGet, "/", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			var keyName, subject string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				keyName = ctxUtil.APIKeyName(r.Context())
+				subject = ctxUtil.Subject(r.Context())
+			})
+
+			w := httptest.NewRecorder()
+			h := middleware.Authenticate(keys, tokens)(middleware.RequireRole(tt.role)(next))
+			h.ServeHTTP(w, r)
+
+			if status := w.Result().StatusCode; status != tt.status {
+				t.Fatalf("Wrong status code: got %v want %v", status, tt.status)
+			}
+			if keyName != tt.keyName {
+				t.Fatalf("Wrong api key name: got %q want %q", keyName, tt.keyName)
+			}
+			if subject != tt.subject {
+				t.Fatalf("Wrong subject: got %q want %q", subject, tt.subject)
+			}
+		})
+	}
+}
+
+func TestAuthenticateWithoutTokenVerifier(t *testing.T) {
+	t.Parallel()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer some.jwt.token")
+
+	w := httptest.NewRecorder()
+	middleware.Authenticate(testKeyAuthenticator{}, nil)(http.HandlerFunc(testHandlerFunc())).ServeHTTP(w, r)
+
+	if status := w.Result().StatusCode; status != http.StatusUnauthorized {
+		t.Fatalf("Wrong status code: got %v want %v", status, http.StatusUnauthorized)
+	}
+}
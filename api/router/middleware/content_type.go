@@ -4,7 +4,7 @@ import "net/http"
 
 const (
 	HeaderKeyContentType       = "Content-Type"
-	HeaderValueContentTypeJSON = "application/json;charset=utf8"
+	HeaderValueContentTypeJSON = "application/json; charset=utf-8"
 )
 
 func ContentTypeJSON(next http.Handler) http.Handler {
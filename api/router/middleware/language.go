@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	ctxUtil "songs/util/ctx"
+	"songs/util/i18n"
+)
+
+const acceptLanguageHeaderKey = "Accept-Language"
+
+// Language stores the best available language for the Accept-Language header in the request context.
+func Language(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.Negotiate(r.Header.Get(acceptLanguageHeaderKey))
+
+		ctx := ctxUtil.SetLanguage(r.Context(), lang)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
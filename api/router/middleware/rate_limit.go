@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	e "songs/api/resource/common/err"
+	l "songs/api/resource/common/log"
+	"songs/pkg/ratelimit"
+	ctxUtil "songs/util/ctx"
+)
+
+const (
+	HeaderKeyRateLimitLimit     = "RateLimit-Limit"
+	HeaderKeyRateLimitRemaining = "RateLimit-Remaining"
+	HeaderKeyRateLimitReset     = "RateLimit-Reset"
+	HeaderKeyRateLimitPolicy    = "RateLimit-Policy"
+	HeaderKeyRetryAfter         = "Retry-After"
+
+	forwardedForHeaderKey = "X-Forwarded-For"
+)
+
+// RateLimit enforces limit per client within the named route group. Clients are
+// identified by API key, then JWT subject, then IP address. X-Forwarded-For is
+// only honoured for requests arriving through one of the trusted proxies.
+// Store failures are logged and the request is let through.
+func RateLimit(store ratelimit.Store, group string, limit ratelimit.Limit, trustedProxies []*net.IPNet, logger *zerolog.Logger) func(http.Handler) http.Handler {
+	policy := strconv.Itoa(limit.Requests) + ";w=" + strconv.Itoa(int(limit.Period.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := group + ":" + clientKey(r, trustedProxies)
+
+			res, err := store.Take(r.Context(), key, limit)
+			if err != nil {
+				logger.Error().Str(l.KeyReqID, ctxUtil.RequestID(r.Context())).Err(err).Msg("Rate limit store failure")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set(HeaderKeyRateLimitPolicy, policy)
+			h.Set(HeaderKeyRateLimitLimit, strconv.Itoa(res.Limit))
+			h.Set(HeaderKeyRateLimitRemaining, strconv.Itoa(res.Remaining))
+			h.Set(HeaderKeyRateLimitReset, strconv.Itoa(int(res.Reset.Seconds())))
+
+			if !res.Allowed {
+				h.Set(HeaderKeyRetryAfter, strconv.Itoa(int(res.RetryAfter.Seconds())))
+				e.TooManyRequests(w, r, e.RespTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// AuthRateLimit enforces limit per client IP address on requests carrying credentials,
+// ahead of Authenticate, so guessing API keys or tokens is throttled before any of them
+// reaches the key store. Requests without credentials pass through.
+func AuthRateLimit(store ratelimit.Store, limit ratelimit.Limit, trustedProxies []*net.IPNet, logger *zerolog.Logger) func(http.Handler) http.Handler {
+	limited := RateLimit(store, "auth", limit, trustedProxies, logger)
+
+	return func(next http.Handler) http.Handler {
+		checked := limited(next)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if bearerToken(r) == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			checked.ServeHTTP(w, r)
+		})
+	}
+}
+
+func clientKey(r *http.Request, trustedProxies []*net.IPNet) string {
+	if name := ctxUtil.APIKeyName(r.Context()); name != "" {
+		return "key:" + name
+	}
+	if subject := ctxUtil.Subject(r.Context()); subject != "" {
+		return "sub:" + subject
+	}
+
+	return "ip:" + clientIP(r, trustedProxies)
+}
+
+// clientIP walks X-Forwarded-For from the nearest hop backwards while the hops are
+// trusted proxies, and returns the first address that is not.
+func clientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	ip := remoteIP(r.RemoteAddr)
+	if !isTrusted(ip, trustedProxies) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values(forwardedForHeaderKey), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+
+		ip = hop
+		if !isTrusted(hop, trustedProxies) {
+			break
+		}
+	}
+
+	return ip
+}
+
+func remoteIP(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+
+	return host
+}
+
+func isTrusted(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, n := range trustedProxies {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
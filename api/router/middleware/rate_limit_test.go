@@ -0,0 +1,111 @@
+package middleware_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"songs/api/router/middleware"
+	"songs/pkg/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	t.Parallel()
+
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+	logger := zerolog.Nop()
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+	h := middleware.RateLimit(ratelimit.NewMemoryStore(), "test", limit, []*net.IPNet{trusted}, &logger)(http.HandlerFunc(testHandlerFunc()))
+
+	send := func(remoteAddr, forwardedFor string) *http.Response {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		status       int
+		remaining    string
+	}{
+		{name: "first request", remoteAddr: "192.0.2.1:1234", status: http.StatusOK, remaining: "1"},
+		{name: "second request", remoteAddr: "192.0.2.1:1234", status: http.StatusOK, remaining: "0"},
+		{name: "over the limit", remoteAddr: "192.0.2.1:1234", status: http.StatusTooManyRequests, remaining: "0"},
+		{name: "spoofed header from untrusted peer", remoteAddr: "192.0.2.1:1234", forwardedFor: "198.51.100.7", status: http.StatusTooManyRequests, remaining: "0"},
+		{name: "client behind trusted proxy", remoteAddr: "10.0.0.1:1234", forwardedFor: "192.0.2.1, 10.0.0.2", status: http.StatusTooManyRequests, remaining: "0"},
+		{name: "other client behind trusted proxy", remoteAddr: "10.0.0.1:1234", forwardedFor: "198.51.100.7", status: http.StatusOK, remaining: "1"},
+	}
+
+	// Cases share one bucket store and must run in order.
+	for _, tt := range tests {
+		resp := send(tt.remoteAddr, tt.forwardedFor)
+
+		if resp.StatusCode != tt.status {
+			t.Fatalf("%s: Wrong status code: got %v want %v", tt.name, resp.StatusCode, tt.status)
+		}
+		if remaining := resp.Header.Get(middleware.HeaderKeyRateLimitRemaining); remaining != tt.remaining {
+			t.Fatalf("%s: Wrong remaining: got %v want %v", tt.name, remaining, tt.remaining)
+		}
+		if limit := resp.Header.Get(middleware.HeaderKeyRateLimitLimit); limit != "2" {
+			t.Fatalf("%s: Wrong limit: got %v want 2", tt.name, limit)
+		}
+
+		retryAfter := resp.Header.Get(middleware.HeaderKeyRetryAfter)
+		if tt.status == http.StatusTooManyRequests && retryAfter == "" {
+			t.Fatalf("%s: Retry-After header is missing", tt.name)
+		}
+		if tt.status == http.StatusOK && retryAfter != "" {
+			t.Fatalf("%s: unexpected Retry-After header %v", tt.name, retryAfter)
+		}
+	}
+}
+
+func TestAuthRateLimit(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	limit := ratelimit.Limit{Requests: 1, Period: time.Minute}
+	h := middleware.AuthRateLimit(ratelimit.NewMemoryStore(), limit, nil, &logger)(http.HandlerFunc(testHandlerFunc()))
+
+	send := func(token string) int {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "192.0.2.1:1234"
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Result().StatusCode
+	}
+
+	tests := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{name: "first guess", token: "sk_guess1", status: http.StatusOK},
+		{name: "second guess with another key", token: "sk_guess2", status: http.StatusTooManyRequests},
+		{name: "another token", token: "eyJhbGciOiJIUzI1NiJ9", status: http.StatusTooManyRequests},
+		{name: "no credentials", status: http.StatusOK},
+	}
+
+	// Cases share one bucket store and must run in order.
+	for _, tt := range tests {
+		if status := send(tt.token); status != tt.status {
+			t.Fatalf("%s: Wrong status code: got %v want %v", tt.name, status, tt.status)
+		}
+	}
+}
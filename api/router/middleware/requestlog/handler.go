@@ -51,6 +51,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Referer:           r.Referer(),
 		Proto:             r.Proto,
 		RemoteIP:          ipFromHostPort(r.RemoteAddr),
+		APIKey:            ctxUtil.APIKeyName(r.Context()),
+		Subject:           ctxUtil.Subject(r.Context()),
 	}
 
 	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
@@ -88,6 +90,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Str("proto", le.Proto).
 		Str("remote_ip", le.RemoteIP).
 		Str("server_ip", le.ServerIP).
+		Str("api_key", le.APIKey).
+		Str("subject", le.Subject).
 		Int("status", le.Status).
 		Int64("resp_header_size", le.ResponseHeaderSize).
 		Int64("resp_body_size", le.ResponseBodySize).
@@ -33,6 +33,8 @@ type logEntry struct {
 	UserAgent         string
 	Referer           string
 	Proto             string
+	APIKey            string
+	Subject           string
 
 	RemoteIP string
 	ServerIP string
@@ -6,7 +6,25 @@ import (
 	"github.com/rs/zerolog"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"gorm.io/gorm"
+	"net/http"
+	"songs/api/resource/apikey"
+	e "songs/api/resource/common/err"
+	"songs/api/resource/duplicate"
+	"songs/api/resource/genre"
+	"songs/api/resource/group"
+	"songs/api/resource/lookup"
+	"songs/api/resource/lyrics"
+	"songs/api/resource/person"
+	"songs/api/resource/playlist"
+	"songs/api/resource/sheet"
 	"songs/api/resource/song"
+	"songs/api/resource/stats"
+	"songs/api/resource/synced"
+	"songs/api/resource/tag"
+	"songs/config"
+	"songs/pkg/ratelimit"
+	"songs/util/auth"
+	"sort"
 
 	"songs/api/resource/health"
 	"songs/api/router/middleware"
@@ -14,26 +32,168 @@ import (
 	_ "songs/docs"
 )
 
-func New(l *zerolog.Logger, v *validator.Validate, db *gorm.DB) *chi.Mux {
+func New(c *config.Conf, l *zerolog.Logger, v *validator.Validate, db *gorm.DB, tokens middleware.TokenVerifier) *chi.Mux {
 	r := chi.NewRouter()
 
+	r.Use(middleware.RequestID)
+	r.Use(middleware.Language)
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		e.NotFound(w, r, e.RespRouteNotFound)
+	})
+	r.MethodNotAllowed(methodNotAllowed(r))
+
 	r.Get("/health", health.Read)
 
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 
 	r.Route("/v1", func(r chi.Router) {
-		r.Use(middleware.RequestID)
+		authLimit, readLimit, writeLimit := rateLimiters(&c.RL, l, db)
+
 		r.Use(middleware.ContentTypeJSON)
+		r.Use(authLimit)
+		r.Use(middleware.Authenticate(apikey.NewRepository(db, l), tokens))
+
+		viewer := r.With(readLimit, middleware.RequireRole(auth.RoleViewer))
+		if c.Auth.PublicReads {
+			viewer = r.With(readLimit)
+		}
+		editor := r.With(writeLimit, middleware.RequireRole(auth.RoleEditor))
+
+		songAPI := song.New(l, v, db, song.DuplicateCheck{Mode: c.Dup.OnCreate, MinScore: c.Dup.MinScore})
+		viewer.Method("GET", "/", requestlog.NewHandler(songAPI.List, l))
+		viewer.Method("GET", "/{id}", requestlog.NewHandler(songAPI.Read, l))
+		editor.Method("POST", "/", requestlog.NewHandler(songAPI.Create, l))
+		editor.Method("PUT", "/{id}", requestlog.NewHandler(songAPI.Update, l))
+		editor.Method("DELETE", "/{id}", requestlog.NewHandler(songAPI.Delete, l))
+		viewer.Method("GET", "/info", requestlog.NewHandler(songAPI.Info, l))
+		viewer.Method("GET", "/{id}/chords", requestlog.NewHandler(songAPI.Chords, l))
+
+		syncedAPI := synced.New(l, db)
+		viewer.Method("GET", "/{id}/lyrics.lrc", requestlog.NewHandler(syncedAPI.ReadLRC, l))
+		viewer.Method("GET", "/{id}/lyrics/active", requestlog.NewHandler(syncedAPI.Active, l))
+		editor.Method("PUT", "/{id}/lyrics.lrc", requestlog.NewHandler(syncedAPI.UpdateLRC, l))
+		editor.Method("DELETE", "/{id}/lyrics.lrc", requestlog.NewHandler(syncedAPI.DeleteLRC, l))
+
+		genreAPI := genre.New(l, v, db)
+		viewer.Method("GET", "/genres", requestlog.NewHandler(genreAPI.List, l))
+		editor.Method("POST", "/genres", requestlog.NewHandler(genreAPI.Create, l))
+		editor.Method("PUT", "/genres/{slug}", requestlog.NewHandler(genreAPI.Update, l))
+		editor.Method("DELETE", "/genres/{slug}", requestlog.NewHandler(genreAPI.Delete, l))
+		viewer.Method("GET", "/{id}/genres", requestlog.NewHandler(genreAPI.SongGenres, l))
+		editor.Method("PUT", "/{id}/genres", requestlog.NewHandler(genreAPI.SetSongGenres, l))
+
+		tagAPI := tag.New(l, v, db)
+		viewer.Method("GET", "/tags", requestlog.NewHandler(tagAPI.List, l))
+		viewer.Method("GET", "/tags/autocomplete", requestlog.NewHandler(tagAPI.Autocomplete, l))
+		viewer.Method("GET", "/{id}/tags", requestlog.NewHandler(tagAPI.SongTags, l))
+		editor.Method("PUT", "/{id}/tags", requestlog.NewHandler(tagAPI.SetSongTags, l))
+
+		lyricsAPI := lyrics.New(l, v, db)
+		viewer.Method("GET", "/{id}/lyrics", requestlog.NewHandler(lyricsAPI.List, l))
+		viewer.Method("GET", "/{id}/lyrics/side-by-side", requestlog.NewHandler(lyricsAPI.SideBySide, l))
+		editor.Method("PUT", "/{id}/lyrics/{lang}", requestlog.NewHandler(lyricsAPI.Set, l))
+		editor.Method("DELETE", "/{id}/lyrics/{lang}", requestlog.NewHandler(lyricsAPI.Delete, l))
+
+		personAPI := person.New(l, v, db)
+		viewer.Method("GET", "/people", requestlog.NewHandler(personAPI.List, l))
+		editor.Method("POST", "/people", requestlog.NewHandler(personAPI.Create, l))
+		viewer.Method("GET", "/people/{id}", requestlog.NewHandler(personAPI.Read, l))
+		editor.Method("PUT", "/people/{id}", requestlog.NewHandler(personAPI.Update, l))
+		editor.Method("DELETE", "/people/{id}", requestlog.NewHandler(personAPI.Delete, l))
+		viewer.Method("GET", "/{id}/credits", requestlog.NewHandler(personAPI.SongCredits, l))
+		editor.Method("PUT", "/{id}/credits", requestlog.NewHandler(personAPI.SetSongCredits, l))
+
+		groupAPI := group.New(l, v, db)
+		viewer.Method("GET", "/groups/aliases", requestlog.NewHandler(groupAPI.Aliases, l))
+		editor.Method("PUT", "/groups/aliases", requestlog.NewHandler(groupAPI.SetAlias, l))
+		editor.Method("DELETE", "/groups/aliases/{key}", requestlog.NewHandler(groupAPI.DeleteAlias, l))
+		editor.Method("POST", "/groups/merge", requestlog.NewHandler(groupAPI.Merge, l))
+		viewer.Method("GET", "/groups/duplicates", requestlog.NewHandler(groupAPI.Duplicates, l))
+
+		duplicateAPI := duplicate.New(l, v, db, c.Dup.MinScore)
+		viewer.Method("GET", "/duplicates", requestlog.NewHandler(duplicateAPI.List, l))
+		editor.Method("POST", "/duplicates/merge", requestlog.NewHandler(duplicateAPI.Merge, l))
+		viewer.Method("GET", "/{id}/revisions", requestlog.NewHandler(duplicateAPI.Revisions, l))
+
+		lookupAPI := lookup.New(l, db)
+		viewer.Method("GET", "/lookup", requestlog.NewHandler(lookupAPI.Lookup, l))
+
+		statsAPI := stats.New(l, db)
+		viewer.Method("GET", "/{id}/stats", requestlog.NewHandler(statsAPI.Song, l))
+		viewer.Method("GET", "/artists/{name}/stats", requestlog.NewHandler(statsAPI.Artist, l))
+
+		playlistAPI := playlist.New(l, v, db)
+		viewer.Method("GET", "/playlists", requestlog.NewHandler(playlistAPI.List, l))
+		viewer.Method("GET", "/playlists/{id}", requestlog.NewHandler(playlistAPI.Read, l))
+		viewer.Method("GET", "/playlists/{id}/items", requestlog.NewHandler(playlistAPI.Items, l))
+		editor.Method("POST", "/playlists", requestlog.NewHandler(playlistAPI.Create, l))
+		editor.Method("PUT", "/playlists/{id}", requestlog.NewHandler(playlistAPI.Update, l))
+		editor.Method("DELETE", "/playlists/{id}", requestlog.NewHandler(playlistAPI.Delete, l))
+		editor.Method("POST", "/playlists/{id}/items", requestlog.NewHandler(playlistAPI.AddItem, l))
+		editor.Method("PATCH", "/playlists/{id}/items/{itemID}", requestlog.NewHandler(playlistAPI.MoveItem, l))
+		editor.Method("DELETE", "/playlists/{id}/items/{itemID}", requestlog.NewHandler(playlistAPI.RemoveItem, l))
 
-		songAPI := song.New(l, v, db)
-		r.Method("GET", "/", requestlog.NewHandler(songAPI.List, l))
-		r.Method("GET", "/{id}", requestlog.NewHandler(songAPI.Read, l))
-		r.Method("POST", "/", requestlog.NewHandler(songAPI.Create, l))
-		r.Method("PUT", "/{id}", requestlog.NewHandler(songAPI.Update, l))
-		r.Method("DELETE", "/{id}", requestlog.NewHandler(songAPI.Delete, l))
-		r.Method("GET", "/info", requestlog.NewHandler(songAPI.Info, l))
+		sheetAPI := sheet.New(l, db)
+		viewer.Method("GET", "/{id}/sheet", requestlog.NewHandler(sheetAPI.Sheet, l))
+		viewer.Method("GET", "/songbook", requestlog.NewHandler(sheetAPI.Songbook, l))
 
 	})
 
 	return r
 }
+
+// methodNotAllowed answers with the methods the path does support in the Allow header,
+// which chi only sets for its built-in 405 handler.
+func methodNotAllowed(mux *chi.Mux) http.HandlerFunc {
+	methods := []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, m := range methods {
+			if mux.Match(chi.NewRouteContext(), m, r.URL.Path) {
+				w.Header().Add("Allow", m)
+			}
+		}
+
+		e.MethodNotAllowed(w, r, e.RespMethodNotAllowed)
+	}
+}
+
+// Methods lists every HTTP method the router serves, plus OPTIONS for preflight requests.
+func Methods(mux *chi.Mux) []string {
+	seen := map[string]bool{http.MethodOptions: true}
+	methods := []string{http.MethodOptions}
+
+	_ = chi.Walk(mux, func(method, _ string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !seen[method] {
+			seen[method] = true
+			methods = append(methods, method)
+		}
+		return nil
+	})
+
+	sort.Strings(methods)
+	return methods
+}
+
+// rateLimiters returns the limiter for credentials, which runs before authentication and
+// counts per IP address, and those for reads and writes, which count per client.
+func rateLimiters(c *config.ConfRateLimit, l *zerolog.Logger, db *gorm.DB) (auth, read, write func(http.Handler) http.Handler) {
+	if !c.Enabled {
+		noop := func(next http.Handler) http.Handler { return next }
+		return noop, noop, noop
+	}
+
+	var store ratelimit.Store
+	switch c.Store {
+	case "postgres":
+		store = ratelimit.NewPostgresStore(db)
+	default:
+		store = ratelimit.NewMemoryStore()
+	}
+
+	l.Info().Str("store", c.Store).Str("read", c.Read.String()).Str("write", c.Write.String()).Str("auth", c.Auth.String()).Msg("Rate limiting enabled")
+
+	return middleware.AuthRateLimit(store, c.Auth, c.TrustedProxies, l),
+		middleware.RateLimit(store, "read", c.Read, c.TrustedProxies, l),
+		middleware.RateLimit(store, "write", c.Write, c.TrustedProxies, l)
+}
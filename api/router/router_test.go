@@ -0,0 +1,89 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	e "songs/api/resource/common/err"
+	"songs/api/router"
+	"songs/config"
+	"songs/util/validator"
+)
+
+func TestRouter_Problems(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	r := router.New(&config.Conf{Auth: config.ConfAuth{PublicReads: true}}, &logger, validator.New(), nil, nil)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		typ    string
+		allow  string
+		lang   string
+		title  string
+	}{
+		{name: "unknown route", method: http.MethodGet, path: "/v1/unknown/path", status: http.StatusNotFound, typ: e.RespRouteNotFound.Type},
+		{name: "unsupported method", method: http.MethodPatch, path: "/v1/info", status: http.StatusMethodNotAllowed, typ: e.RespMethodNotAllowed.Type, allow: http.MethodGet},
+		{name: "localized title", method: http.MethodGet, path: "/v1/unknown/path", status: http.StatusNotFound, typ: e.RespRouteNotFound.Type, lang: "ru-RU,ru;q=0.9", title: "маршрут не найден"},
+		{name: "missing credentials", method: http.MethodDelete, path: "/v1/7c0b4b52-2c8e-4c3c-9a43-1f4f1d0e2a6b", status: http.StatusUnauthorized, typ: e.RespUnauthorized.Type},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-Request-ID", "test-request")
+			if tt.lang != "" {
+				req.Header.Set("Accept-Language", tt.lang)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			resp := w.Result()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("Wrong status code: got %v want %v", resp.StatusCode, tt.status)
+			}
+			if contentType := resp.Header.Get("Content-Type"); contentType != e.ContentTypeProblem {
+				t.Fatalf("Wrong content type: got %v want %v", contentType, e.ContentTypeProblem)
+			}
+			if allow := resp.Header.Get("Allow"); allow != tt.allow {
+				t.Fatalf("Wrong allow header: got %v want %v", allow, tt.allow)
+			}
+
+			var p e.Problem
+			if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if tt.title != "" && p.Title != tt.title {
+				t.Fatalf("Wrong title: got %v want %v", p.Title, tt.title)
+			}
+			if p.Type != tt.typ || p.Status != tt.status || p.Instance != tt.path || p.RequestID != "test-request" {
+				t.Fatalf("Wrong problem: %+v", p)
+			}
+		})
+	}
+}
+
+func TestMethods(t *testing.T) {
+	t.Parallel()
+
+	logger := zerolog.Nop()
+	r := router.New(&config.Conf{}, &logger, validator.New(), nil, nil)
+
+	got := strings.Join(router.Methods(r), ",")
+	if want := "DELETE,GET,OPTIONS,PATCH,POST,PUT"; got != want {
+		t.Errorf("expected methods %s, got %s", want, got)
+	}
+}
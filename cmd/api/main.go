@@ -1,33 +1,37 @@
 package main
 
 import (
-	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/go-chi/chi/v5"
 	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/rs/cors"
 	"github.com/rs/zerolog"
-	gormPostgres "gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	gormlogger "gorm.io/gorm/logger"
 	"net/http"
 	"songs/api/router"
+	"songs/api/router/middleware"
 	"songs/config"
+	"songs/db"
+	"songs/db/backfill"
+	"songs/pkg/origin"
+	"songs/util/auth"
 	"songs/util/logger"
 	"songs/util/validator"
-	"strconv"
+	"strings"
 )
 
-const fmtDBString = "host=%s user=%s password=%s dbname=%s port=%d sslmode=disable"
-
 //	@title			Songs Library
 //	@version		1.0
 //	@description	This is test project for Effective Mobile
 //
 // @host		localhost:8080
 // @basePath	/v1
+//
+// @securityDefinitions.apikey	BearerAuth
+// @in							header
+// @name						Authorization
+// @description				API key issued with cmd/apikey, sent as "Bearer <key>".
 func main() {
 	c := config.New()
 	l := logger.New(c.Server.Debug)
@@ -35,22 +39,32 @@ func main() {
 
 	l.Info().Msg("Starting Songs Library server")
 
-	db, err := setupDatabase(c, l)
+	gdb, err := db.New(&c.DB, l)
 	if err != nil {
 		l.Fatal().Err(err).Msg("DB connection setup failure")
 		return
 	}
 
 	if c.DB.AutoMigrate {
-		if err := runMigrations(c, l); err != nil {
+		if err := runMigrations(c, l, gdb); err != nil {
 			l.Fatal().Err(err).Msg("Migrations setup failure")
 			return
 		}
 	}
 
-	r := router.New(l, v, db)
+	tokens, err := setupTokenVerifier(c, l)
+	if err != nil {
+		l.Fatal().Err(err).Msg("JWT verifier setup failure")
+		return
+	}
+
+	r := router.New(c, l, v, gdb, tokens)
 
-	handler := setupCors(c, r, l)
+	handler, err := setupCors(c, r, l)
+	if err != nil {
+		l.Fatal().Err(err).Msg("CORS setup failure")
+		return
+	}
 
 	l.Info().Msgf("Starting server on port %d", c.Server.Port)
 	s := &http.Server{
@@ -67,80 +81,80 @@ func main() {
 	}
 }
 
-func setupDatabase(c *config.Conf, l *zerolog.Logger) (*gorm.DB, error) {
-	l.Debug().Msg("Setting up database connection...")
-	dbString := fmt.Sprintf(fmtDBString, c.DB.Host, c.DB.Username, c.DB.Password, c.DB.DBName, c.DB.Port)
-	l.Debug().Str("DB Connection String", dbString).Msg("Database connection string formatted")
-
-	var logLevel gormlogger.LogLevel
-	if c.DB.Debug {
-		logLevel = gormlogger.Info
-		l.Debug().Msg("Database debug mode is ON")
-	} else {
-		logLevel = gormlogger.Error
-		l.Debug().Msg("Database debug mode is OFF")
+func runMigrations(c *config.Conf, l *zerolog.Logger, gdb *gorm.DB) error {
+	m, err := db.NewMigrate(&c.DB)
+	if err != nil {
+		return err
 	}
+	defer m.Close()
 
-	db, err := gorm.Open(gormPostgres.Open(dbString), &gorm.Config{Logger: gormlogger.Default.LogMode(logLevel)})
-	if err != nil {
-		l.Error().Err(err).Msg("Failed to connect to the database")
-		return nil, err
+	l.Info().Msg("Running database migrations...")
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	l.Info().Msg("Database connection successfully established")
-	return db, nil
+	l.Info().Msg("Migrations applied successfully")
+	return backfill.Upgrade(gdb, l)
 }
 
-func runMigrations(c *config.Conf, l *zerolog.Logger) error {
-	dsn := fmt.Sprintf(fmtDBString, c.DB.Host, c.DB.Username, c.DB.Password, c.DB.DBName, c.DB.Port)
-	l.Debug().Str("dsn", "****").Msg("Connecting to the database for migration")
-
-	conn, err := sql.Open("postgres", dsn)
-	if err != nil {
-
-		return fmt.Errorf("failed to open database connection: %w", err)
+func setupTokenVerifier(c *config.Conf, l *zerolog.Logger) (middleware.TokenVerifier, error) {
+	if !c.Auth.JWTEnabled() {
+		l.Info().Msg("JWT authentication is disabled")
+		return nil, nil
 	}
-	defer conn.Close()
 
-	driver, err := postgres.WithInstance(conn, &postgres.Config{})
-	if err != nil {
-		return fmt.Errorf("failed to create migrate driver: %w", err)
+	roleMap := make(map[string]auth.Role, len(c.Auth.JWTRoleMap))
+	for _, pair := range c.Auth.JWTRoleMap {
+		claim, name, _ := strings.Cut(pair, "=")
+		role, ok := auth.ParseRole(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown role %q in AUTH_JWT_ROLE_MAP", name)
+		}
+		roleMap[claim] = role
 	}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://db/migrations",
-		"postgres", driver)
+	verifier, err := auth.NewVerifier(auth.Config{
+		Secret:      c.Auth.JWTSecret,
+		JWKSFile:    c.Auth.JWTJWKSFile,
+		JWKSURL:     c.Auth.JWTJWKSURL,
+		JWKSRefresh: c.Auth.JWTJWKSRefresh,
+		Issuer:      c.Auth.JWTIssuer,
+		Audience:    c.Auth.JWTAudience,
+		RolesClaim:  c.Auth.JWTRolesClaim,
+		RoleMap:     roleMap,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
-	}
-
-	l.Info().Msg("Running database migrations...")
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to run migrations: %w", err)
+		return nil, err
 	}
 
-	l.Info().Msg("Migrations applied successfully")
-	return nil
+	l.Info().Str("roles_claim", c.Auth.JWTRolesClaim).Msg("JWT authentication setup completed successfully")
+	return verifier, nil
 }
 
-func setupCors(c *config.Conf, r http.Handler, l *zerolog.Logger) http.Handler {
-	var origin string
-	if c.FR.Port == 80 {
-		origin = fmt.Sprintf("http://%s", c.FR.Host)
-	} else {
-		origin = fmt.Sprintf("http://%s:%s", c.FR.Host, strconv.Itoa(c.FR.Port))
+func setupCors(c *config.Conf, r *chi.Mux, l *zerolog.Logger) (http.Handler, error) {
+	origins := c.Origins()
+	matcher, err := origin.Compile(origins)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := c.CORS.AllowedMethods
+	if len(methods) == 0 {
+		methods = router.Methods(r)
 	}
 
-	l.Debug().Str("origin", origin).Msg("Setting up CORS for the origin")
+	l.Debug().Strs("origins", origins).Strs("methods", methods).Msg("Setting up CORS")
 
 	corsHandler := cors.New(cors.Options{
-		AllowedOrigins:   []string{origin},
-		AllowCredentials: true,
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowOriginFunc:  matcher.Match,
+		AllowCredentials: c.CORS.AllowCredentials,
+		AllowedMethods:   methods,
+		AllowedHeaders:   c.CORS.AllowedHeaders,
+		ExposedHeaders:   c.CORS.ExposedHeaders,
+		MaxAge:           int(c.CORS.MaxAge.Seconds()),
 	})
 
-	l.Info().Str("origin", origin).Msg("CORS setup completed successfully")
+	l.Info().Strs("origins", origins).Msg("CORS setup completed successfully")
 
-	return corsHandler.Handler(r)
+	return corsHandler.Handler(r), nil
 }
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"songs/api/resource/apikey"
+	"songs/config"
+	"songs/db"
+	"songs/util/logger"
+)
+
+const usage = `Usage: apikey <command> [flags]
+
+Commands:
+  create  -name NAME [-scopes "write"] [-ttl 720h]   mint a new key and print it once
+  revoke  -name NAME                                 revoke an active key
+  list                                               list all keys
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Arguments belong to the subcommands, so settings come from the file, env and defaults only.
+	c, err := config.Load(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%s\n", err)
+		os.Exit(1)
+	}
+	l := logger.New(c.Server.Debug)
+
+	gdb, err := db.New(&c.DB, l)
+	if err != nil {
+		l.Fatal().Err(err).Msg("DB connection setup failure")
+		return
+	}
+
+	repo := apikey.NewRepository(gdb, l)
+
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "create":
+		err = create(repo, args)
+	case "revoke":
+		err = revoke(repo, args)
+	case "list":
+		err = list(repo)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		l.Error().Err(err).Msg("apikey command failed")
+		os.Exit(1)
+	}
+}
+
+func create(repo *apikey.Repository, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	name := fs.String("name", "", "unique key name, shown in logs")
+	scopes := fs.String("scopes", apikey.ScopeWrite, "space or comma separated scopes: read, write, admin")
+	ttl := fs.Duration("ttl", 0, "key lifetime, zero means the key never expires")
+	fs.Parse(args)
+
+	if *name == "" {
+		return fmt.Errorf("-name is required")
+	}
+
+	raw, prefix, err := apikey.Generate()
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	key := &apikey.Key{
+		ID:        uuid.New(),
+		Name:      *name,
+		Prefix:    prefix,
+		Hash:      apikey.Hash(raw),
+		Scopes:    strings.Join(strings.FieldsFunc(*scopes, isScopeSeparator), " "),
+		CreatedAt: time.Now(),
+	}
+	if *ttl > 0 {
+		expiresAt := key.CreatedAt.Add(*ttl)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if _, err := repo.Create(key); err != nil {
+		return fmt.Errorf("failed to store key: %w", err)
+	}
+
+	fmt.Printf("Created api key %q with scopes %q.\n", key.Name, key.Scopes)
+	fmt.Println("Store it now, it will not be shown again:")
+	fmt.Println(raw)
+
+	return nil
+}
+
+func revoke(repo *apikey.Repository, args []string) error {
+	fs := flag.NewFlagSet("revoke", flag.ExitOnError)
+	name := fs.String("name", "", "name of the key to revoke")
+	fs.Parse(args)
+
+	if *name == "" {
+		return fmt.Errorf("-name is required")
+	}
+
+	rows, err := repo.Revoke(*name)
+	if err != nil {
+		return fmt.Errorf("failed to revoke key: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("no active key named %q", *name)
+	}
+
+	fmt.Printf("Revoked api key %q.\n", *name)
+	return nil
+}
+
+func list(repo *apikey.Repository) error {
+	keys, err := repo.List()
+	if err != nil {
+		return fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tPREFIX\tSCOPES\tEXPIRES\tLAST USED\tSTATUS")
+	for _, k := range keys {
+		status := "active"
+		switch {
+		case k.RevokedAt != nil:
+			status = "revoked"
+		case k.Expired(time.Now()):
+			status = "expired"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", k.Name, k.Prefix, k.Scopes, formatTime(k.ExpiresAt), formatTime(k.LastUsedAt), status)
+	}
+
+	return tw.Flush()
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func isScopeSeparator(r rune) bool {
+	return r == ',' || r == ' '
+}
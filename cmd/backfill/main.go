@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/config"
+	"songs/db"
+	"songs/db/backfill"
+	"songs/util/logger"
+)
+
+const usage = `Usage: backfill <target> [-all] [-batch 500] [-dry-run]
+
+Targets:
+  language    detect the language of songs without one
+  searchkeys  compute the transliteration-insensitive search keys of songs without them
+  stats       count the lyrics of songs without stats
+  lines       index the lyric lines of songs without indexed lines
+  bands       index the lyrics' MinHash bands of songs without indexed bands, for duplicate detection
+`
+
+// target fills in one derived column for the songs saved before it existed.
+type target struct {
+	run func(db *gorm.DB, logger *zerolog.Logger, all, dryRun bool, batch int) (backfill.Result, error)
+	// all describes the -all flag and failed the error logged when the backfill fails.
+	all    string
+	failed string
+}
+
+var targets = map[string]target{
+	"language": {
+		run:    backfill.Languages,
+		all:    "detect the language of every song, not only of those without one",
+		failed: "language detection failed",
+	},
+	"searchkeys": {
+		run:    backfill.SearchKeys,
+		all:    "recompute the search keys of every song, not only of those without them",
+		failed: "search key backfill failed",
+	},
+	"stats": {
+		run:    backfill.Stats,
+		all:    "count the lyrics of every song, not only of those without stats",
+		failed: "counting lyrics failed",
+	},
+	"lines": {
+		run:    backfill.Lines,
+		all:    "index the lyric lines of every song, not only of songs without indexed lines",
+		failed: "indexing lyric lines failed",
+	},
+	"bands": {
+		run:    backfill.Bands,
+		all:    "index the bands of every song, not only of songs without indexed bands",
+		failed: "indexing bands failed",
+	},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	name, args := os.Args[1], os.Args[2:]
+	t, ok := targets[name]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	all := fs.Bool("all", false, t.all)
+	batch := fs.Int("batch", backfill.DefaultBatch, "how many songs to read at a time")
+	dryRun := fs.Bool("dry-run", false, "report what would change without writing")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage+"\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Arguments belong to the targets, so settings come from the file, env and defaults only.
+	c, err := config.Load(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%s\n", err)
+		os.Exit(1)
+	}
+	l := logger.New(c.Server.Debug)
+
+	gdb, err := db.New(&c.DB, l)
+	if err != nil {
+		l.Fatal().Err(err).Msg("DB connection setup failure")
+		return
+	}
+
+	res, err := t.run(gdb, l, *all, *dryRun, *batch)
+	if err != nil {
+		l.Error().Err(err).Msg(t.failed)
+		os.Exit(1)
+	}
+
+	verb := "Updated"
+	if *dryRun {
+		verb = "Would update"
+	}
+	fmt.Printf("Checked %d songs. %s %d.\n", res.Checked, verb, res.Updated)
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/rs/zerolog"
+
+	"songs/config"
+	"songs/db"
+	"songs/db/backfill"
+	"songs/util/logger"
+)
+
+const usage = `Usage: migrate <command> [args]
+
+Commands:
+  up [N]                       apply all pending migrations and fill what they left for
+                               existing songs, or apply only the next N
+  down N | -all                roll back the last N migrations, or all of them
+  goto VERSION                 migrate up or down to VERSION
+  force VERSION                set VERSION without running anything and clear the dirty flag
+  status                       show the current version and every known migration
+  create [-dir DIR] NAME       add an empty up/down migration pair to DIR (default db/migrations)
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	cmd, args := os.Args[1], os.Args[2:]
+
+	// create only writes files, so it works without a database.
+	if cmd == "create" {
+		if err := create(args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	c, err := config.Load(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%s\n", err)
+		os.Exit(1)
+	}
+	l := logger.New(c.Server.Debug)
+
+	m, err := db.NewMigrate(&c.DB)
+	if err != nil {
+		l.Fatal().Err(err).Msg("Migrations setup failure")
+		return
+	}
+	m.Log = &migrateLogger{l: l, verbose: c.Server.Debug}
+
+	switch cmd {
+	case "up":
+		err = up(m, args)
+	case "down":
+		err = down(m, args)
+	case "goto":
+		err = gotoVersion(m, args)
+	case "force":
+		err = force(m, args)
+	case "status":
+		err = status(m)
+	default:
+		m.Close()
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if srcErr, dbErr := m.Close(); err == nil {
+		err = errors.Join(srcErr, dbErr)
+	}
+
+	// Only a full up reaches the schema the backfills are written against.
+	if cmd == "up" && len(args) == 0 && (err == nil || errors.Is(err, migrate.ErrNoChange)) {
+		if upErr := upgrade(c, l); upErr != nil {
+			err = upErr
+		}
+	}
+
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		l.Error().Err(err).Msg("migrate command failed")
+		os.Exit(1)
+	}
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Println("No change.")
+	}
+}
+
+func up(m *migrate.Migrate, args []string) error {
+	if len(args) == 0 {
+		return m.Up()
+	}
+
+	n, err := parseSteps(args[0])
+	if err != nil {
+		return err
+	}
+	return m.Steps(n)
+}
+
+// upgrade fills the columns and indexes the migrations added for songs saved before them.
+func upgrade(c *config.Conf, l *zerolog.Logger) error {
+	gdb, err := db.New(&c.DB, l)
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := gdb.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	return backfill.Upgrade(gdb, l)
+}
+
+func down(m *migrate.Migrate, args []string) error {
+	flags := flag.NewFlagSet("down", flag.ExitOnError)
+	all := flags.Bool("all", false, "roll back every migration")
+	flags.Parse(args)
+
+	if *all {
+		return m.Down()
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("down needs the number of migrations to roll back, or -all")
+	}
+
+	n, err := parseSteps(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return m.Steps(-n)
+}
+
+func gotoVersion(m *migrate.Migrate, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("goto needs a version")
+	}
+
+	v, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid version %q", args[0])
+	}
+	return m.Migrate(uint(v))
+}
+
+func force(m *migrate.Migrate, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("force needs a version, -1 means no version")
+	}
+
+	v, err := strconv.Atoi(args[0])
+	if err != nil || v < -1 {
+		return fmt.Errorf("invalid version %q", args[0])
+	}
+	if err := m.Force(v); err != nil {
+		return err
+	}
+
+	fmt.Printf("Forced version %d.\n", v)
+	return nil
+}
+
+func status(m *migrate.Migrate) error {
+	current, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return err
+	}
+
+	src, err := db.Source()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+
+	v, err := src.First()
+	for err == nil {
+		r, name, readErr := src.ReadUp(v)
+		if readErr != nil {
+			return readErr
+		}
+		r.Close()
+
+		state := "pending"
+		switch {
+		case v == current && dirty:
+			state = "dirty"
+		case v <= current:
+			state = "applied"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", v, name, state)
+
+		v, err = src.Next(v)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return tw.Flush()
+}
+
+var nonWord = regexp.MustCompile(`[^a-z0-9]+`)
+
+func create(args []string) error {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	dir := flags.String("dir", db.MigrationsDir, "directory holding the migrations")
+	flags.Parse(args)
+
+	name := strings.Trim(nonWord.ReplaceAllString(strings.ToLower(strings.Join(flags.Args(), "_")), "_"), "_")
+	if name == "" {
+		return fmt.Errorf("create needs a migration name")
+	}
+
+	entries, err := os.ReadDir(*dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", *dir, err)
+	}
+
+	var last uint64
+	for _, e := range entries {
+		prefix, _, ok := strings.Cut(e.Name(), "_")
+		if !ok {
+			continue
+		}
+		if v, err := strconv.ParseUint(prefix, 10, 64); err == nil && v > last {
+			last = v
+		}
+	}
+
+	base := fmt.Sprintf("%d_%s", last+1, name)
+	for _, direction := range []string{"up", "down"} {
+		path := filepath.Join(*dir, base+"."+direction+".sql")
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		f.Close()
+		fmt.Println(path)
+	}
+
+	return nil
+}
+
+func parseSteps(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number of migrations %q", s)
+	}
+	return n, nil
+}
+
+// migrateLogger forwards golang-migrate progress messages to zerolog.
+type migrateLogger struct {
+	l       *zerolog.Logger
+	verbose bool
+}
+
+func (ml *migrateLogger) Printf(format string, v ...interface{}) {
+	ml.l.Info().Msg(strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (ml *migrateLogger) Verbose() bool {
+	return ml.verbose
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"songs/config"
+	"songs/db"
+	"songs/db/seed"
+	"songs/util/logger"
+	"songs/util/validator"
+)
+
+func main() {
+	file := flag.String("file", "", "JSON or YAML file with songs, the bundled fixtures are used when empty")
+	reset := flag.Bool("reset", false, "delete every song before seeding")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: seed [-file songs.yaml] [-reset]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	// Flags belong to the seed command, so settings come from the file, env and defaults only.
+	c, err := config.Load(nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%s\n", err)
+		os.Exit(1)
+	}
+	l := logger.New(c.Server.Debug)
+
+	var items []*seed.Fixture
+	if *file == "" {
+		items, err = seed.Bundled()
+	} else {
+		items, err = seed.ReadFile(*file)
+	}
+	if err != nil {
+		l.Fatal().Err(err).Msg("Failed to read fixtures")
+		return
+	}
+
+	gdb, err := db.New(&c.DB, l)
+	if err != nil {
+		l.Fatal().Err(err).Msg("DB connection setup failure")
+		return
+	}
+
+	res, err := seed.New(gdb, l, validator.New()).Run(items, *reset)
+	if err != nil {
+		l.Error().Err(err).Msg("seed failed")
+		os.Exit(1)
+	}
+
+	if *reset {
+		fmt.Printf("Deleted %d songs.\n", res.Deleted)
+	}
+	fmt.Printf("Seeded %d songs: %d created, %d updated.\n", len(items), res.Created, res.Updated)
+}
@@ -1,50 +1,228 @@
 package config
 
 import (
-	"github.com/joeshaw/envdecode"
-	"github.com/joho/godotenv"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net"
+	"os"
+	"songs/pkg/origin"
+	"songs/pkg/ratelimit"
+	"songs/util/auth"
+	"strings"
 	"time"
 )
 
+// Every setting is named by its env tag. The same name, lowercased with dashes, is its
+// command line flag (SERVER_PORT is --server-port), and config files spell it as nested
+// keys that join with underscores (server: {port: 8080}).
 type Conf struct {
 	Server ConfServer
 	DB     ConfigDB
 	FR     ConfFrontend
+	Auth   ConfAuth
+	RL     ConfRateLimit
+	CORS   ConfCORS
+	Dup    ConfDuplicates
+
+	settings    []*setting
+	printConfig bool
 }
 
 type ConfServer struct {
-	Port         int           `env:"SERVER_PORT,required"`
-	TimeoutRead  time.Duration `env:"SERVER_TIMEOUT_READ,required"`
-	TimeoutWrite time.Duration `env:"SERVER_TIMEOUT_WRITE,required"`
-	TimeoutIdle  time.Duration `env:"SERVER_TIMEOUT_IDLE,required"`
-	Debug        bool          `env:"SERVER_DEBUG,required"`
+	Port         int           `env:"SERVER_PORT" default:"8080"`
+	TimeoutRead  time.Duration `env:"SERVER_TIMEOUT_READ" default:"3s"`
+	TimeoutWrite time.Duration `env:"SERVER_TIMEOUT_WRITE" default:"5s"`
+	TimeoutIdle  time.Duration `env:"SERVER_TIMEOUT_IDLE" default:"5s"`
+	Debug        bool          `env:"SERVER_DEBUG" default:"false"`
 }
 
 type ConfigDB struct {
-	Host        string `env:"DB_HOST,required"`
-	Port        int    `env:"DB_PORT,required"`
-	Username    string `env:"DB_USER,required"`
-	Password    string `env:"DB_PASS,required"`
-	DBName      string `env:"DB_NAME,required"`
-	Debug       bool   `env:"DB_DEBUG,required"`
-	AutoMigrate bool   `env:"DB_AUTO_MIGRATE,required"`
+	Host        string `env:"DB_HOST" default:"localhost"`
+	Port        int    `env:"DB_PORT" default:"5432"`
+	Username    string `env:"DB_USER" required:"true"`
+	Password    string `env:"DB_PASS" required:"true" secret:"true"`
+	DBName      string `env:"DB_NAME" required:"true"`
+	Debug       bool   `env:"DB_DEBUG" default:"false"`
+	AutoMigrate bool   `env:"DB_AUTO_MIGRATE" default:"false"`
 }
 
 type ConfFrontend struct {
-	Port int    `env:"FRONTEND_PORT,required"`
-	Host string `env:"FRONTEND_HOST,required"`
+	Port int    `env:"FRONTEND_PORT" default:"80"`
+	Host string `env:"FRONTEND_HOST" default:"localhost"`
+}
+
+// ConfCORS configures cross-origin access. Without CORS_ALLOWED_ORIGINS the origin is built from
+// FRONTEND_HOST and FRONTEND_PORT, and without CORS_ALLOWED_METHODS it is every method routed.
+type ConfCORS struct {
+	AllowedOrigins   []string      `env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `env:"CORS_ALLOWED_HEADERS" default:"Accept;Accept-Language;Authorization;Content-Type;X-Request-ID"`
+	ExposedHeaders   []string      `env:"CORS_EXPOSED_HEADERS" default:"Link;ETag;Warning;X-Request-ID;Content-Language;Retry-After;RateLimit-Policy;RateLimit-Limit;RateLimit-Remaining;RateLimit-Reset"`
+	MaxAge           time.Duration `env:"CORS_MAX_AGE" default:"10m"`
+	AllowCredentials bool          `env:"CORS_ALLOW_CREDENTIALS" default:"true"`
+}
+
+// Origins returns the allowed origin patterns, falling back to the frontend address.
+func (c *Conf) Origins() []string {
+	if len(c.CORS.AllowedOrigins) > 0 {
+		return c.CORS.AllowedOrigins
+	}
+	if c.FR.Port == 80 {
+		return []string{fmt.Sprintf("http://%s", c.FR.Host)}
+	}
+
+	return []string{fmt.Sprintf("http://%s:%d", c.FR.Host, c.FR.Port)}
+}
+
+type ConfAuth struct {
+	PublicReads bool `env:"AUTH_PUBLIC_READS" default:"true"`
+
+	JWTSecret      string        `env:"AUTH_JWT_HS256_SECRET" secret:"true"`
+	JWTJWKSFile    string        `env:"AUTH_JWT_JWKS_FILE"`
+	JWTJWKSURL     string        `env:"AUTH_JWT_JWKS_URL"`
+	JWTJWKSRefresh time.Duration `env:"AUTH_JWT_JWKS_REFRESH" default:"1h"`
+	JWTIssuer      string        `env:"AUTH_JWT_ISSUER"`
+	JWTAudience    string        `env:"AUTH_JWT_AUDIENCE"`
+	JWTRolesClaim  string        `env:"AUTH_JWT_ROLES_CLAIM" default:"roles"`
+	JWTRoleMap     []string      `env:"AUTH_JWT_ROLE_MAP"`
+}
+
+// JWTEnabled reports whether any JWT verification key source is configured.
+func (c ConfAuth) JWTEnabled() bool {
+	return c.JWTSecret != "" || c.JWTJWKSFile != "" || c.JWTJWKSURL != ""
+}
+
+type ConfRateLimit struct {
+	Enabled        bool            `env:"RATE_LIMIT_ENABLED" default:"true"`
+	Store          string          `env:"RATE_LIMIT_STORE" default:"memory"`
+	Read           ratelimit.Limit `env:"RATE_LIMIT_READ" default:"300/1m"`
+	Write          ratelimit.Limit `env:"RATE_LIMIT_WRITE" default:"60/1m"`
+	Auth           ratelimit.Limit `env:"RATE_LIMIT_AUTH" default:"600/1m"`
+	TrustedProxies CIDRs           `env:"RATE_LIMIT_TRUSTED_PROXIES"`
+}
+
+// ConfDuplicates configures the check for likely duplicates when a song is created:
+// off, warn (create it with a Warning header) or reject (answer 409 unless ?force=true).
+type ConfDuplicates struct {
+	OnCreate string  `env:"DUPLICATES_ON_CREATE" default:"warn"`
+	MinScore float64 `env:"DUPLICATES_MIN_SCORE" default:"0.85"`
+}
+
+// CIDRs is a semicolon separated list of networks. Bare addresses are treated as single hosts.
+type CIDRs []*net.IPNet
+
+func (c *CIDRs) UnmarshalText(text []byte) error {
+	var nets CIDRs
+	for _, s := range strings.Split(string(text), ";") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil && ip.To4() == nil {
+				s += "/128"
+			} else {
+				s += "/32"
+			}
+		}
+
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return err
+		}
+		nets = append(nets, n)
+	}
+
+	*c = nets
+	return nil
 }
 
+// New loads the configuration from the command line arguments and the environment.
+// It exits after printing the configuration when --print-config is given, and on any error.
 func New() *Conf {
-	if err := godotenv.Load(); err != nil {
-		log.Fatalf("Error loading .env file")
+	c, err := Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%s", err)
+	}
+
+	if c.printConfig {
+		c.Print(os.Stdout)
+		os.Exit(0)
+	}
+
+	return c
+}
+
+// validate checks the rules that span several settings and returns every violation found.
+func (c *Conf) validate() []string {
+	var problems []string
+
+	for key, port := range map[string]int{
+		"SERVER_PORT":   c.Server.Port,
+		"DB_PORT":       c.DB.Port,
+		"FRONTEND_PORT": c.FR.Port,
+	} {
+		if port < 1 || port > 65535 {
+			problems = append(problems, fmt.Sprintf("%s must be between 1 and 65535, got %d", key, port))
+		}
+	}
+
+	for key, timeout := range map[string]time.Duration{
+		"SERVER_TIMEOUT_READ":  c.Server.TimeoutRead,
+		"SERVER_TIMEOUT_WRITE": c.Server.TimeoutWrite,
+		"SERVER_TIMEOUT_IDLE":  c.Server.TimeoutIdle,
+	} {
+		if timeout <= 0 {
+			problems = append(problems, fmt.Sprintf("%s must be positive, got %s", key, timeout))
+		}
 	}
 
-	var c Conf
-	if err := envdecode.StrictDecode(&c); err != nil {
-		log.Fatalf("Failed to decode: %s", err)
+	if c.Auth.JWTJWKSFile != "" && c.Auth.JWTJWKSURL != "" {
+		problems = append(problems, "AUTH_JWT_JWKS_FILE and AUTH_JWT_JWKS_URL are mutually exclusive")
+	}
+	if c.Auth.JWTJWKSURL != "" && c.Auth.JWTJWKSRefresh <= 0 {
+		problems = append(problems, "AUTH_JWT_JWKS_REFRESH must be positive when AUTH_JWT_JWKS_URL is set")
+	}
+	if !c.Auth.JWTEnabled() && (c.Auth.JWTIssuer != "" || c.Auth.JWTAudience != "" || len(c.Auth.JWTRoleMap) > 0) {
+		problems = append(problems, "AUTH_JWT_ISSUER, AUTH_JWT_AUDIENCE and AUTH_JWT_ROLE_MAP need AUTH_JWT_HS256_SECRET, AUTH_JWT_JWKS_FILE or AUTH_JWT_JWKS_URL")
+	}
+	for _, pair := range c.Auth.JWTRoleMap {
+		claim, name, ok := strings.Cut(pair, "=")
+		if !ok || claim == "" {
+			problems = append(problems, fmt.Sprintf("AUTH_JWT_ROLE_MAP entry %q must look like claim=role", pair))
+			continue
+		}
+		if _, ok := auth.ParseRole(name); !ok {
+			problems = append(problems, fmt.Sprintf("AUTH_JWT_ROLE_MAP entry %q has unknown role %q", pair, name))
+		}
+	}
+
+	if m, err := origin.Compile(c.Origins()); err != nil {
+		problems = append(problems, fmt.Sprintf("CORS_ALLOWED_ORIGINS: %v", err))
+	} else if m.AllowsAny() && c.CORS.AllowCredentials {
+		problems = append(problems, "CORS_ALLOWED_ORIGINS=* cannot be combined with CORS_ALLOW_CREDENTIALS=true")
+	}
+	if c.CORS.MaxAge < 0 {
+		problems = append(problems, fmt.Sprintf("CORS_MAX_AGE must not be negative, got %s", c.CORS.MaxAge))
+	}
+
+	if c.RL.Store != "memory" && c.RL.Store != "postgres" {
+		problems = append(problems, fmt.Sprintf("RATE_LIMIT_STORE must be memory or postgres, got %q", c.RL.Store))
+	}
+
+	switch c.Dup.OnCreate {
+	case "off", "warn", "reject":
+	default:
+		problems = append(problems, fmt.Sprintf("DUPLICATES_ON_CREATE must be off, warn or reject, got %q", c.Dup.OnCreate))
+	}
+	if c.Dup.MinScore <= 0 || c.Dup.MinScore > 1 {
+		problems = append(problems, fmt.Sprintf("DUPLICATES_MIN_SCORE must be greater than 0 and at most 1, got %v", c.Dup.MinScore))
 	}
 
-	return &c
+	return problems
 }
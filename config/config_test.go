@@ -0,0 +1,208 @@
+package config_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"songs/config"
+)
+
+func setRequired(t *testing.T) {
+	t.Setenv("DB_USER", "user")
+	t.Setenv("DB_PASS", "password")
+	t.Setenv("DB_NAME", "songs")
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setRequired(t)
+
+	c, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Server.Port != 8080 {
+		t.Errorf("expected default port 8080, got %d", c.Server.Port)
+	}
+	if c.Server.TimeoutRead != 3*time.Second {
+		t.Errorf("expected default read timeout 3s, got %s", c.Server.TimeoutRead)
+	}
+	if !c.Auth.PublicReads {
+		t.Error("expected public reads by default")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	setRequired(t)
+
+	file := filepath.Join(t.TempDir(), "songs.yaml")
+	data := "server:\n  port: 9000\n  timeout_read: 10s\ndb:\n  host: db.internal\nrate_limit:\n  trusted_proxies: [127.0.0.1, 10.0.0.0/8]\n"
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("SERVER_PORT", "9100")
+
+	c, err := config.Load([]string{"--server-timeout-read", "20s"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.DB.Host != "db.internal" {
+		t.Errorf("expected host from file, got %q", c.DB.Host)
+	}
+	if c.Server.Port != 9100 {
+		t.Errorf("expected env to override file, got %d", c.Server.Port)
+	}
+	if c.Server.TimeoutRead != 20*time.Second {
+		t.Errorf("expected flag to override file, got %s", c.Server.TimeoutRead)
+	}
+	if len(c.RL.TrustedProxies) != 2 {
+		t.Errorf("expected 2 trusted proxies from file list, got %d", len(c.RL.TrustedProxies))
+	}
+}
+
+func TestLoadTOML(t *testing.T) {
+	setRequired(t)
+
+	file := filepath.Join(t.TempDir(), "songs.toml")
+	data := "[server]\nport = 9200\n\n[rate_limit]\nstore = \"postgres\"\n"
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := config.Load([]string{"--config", file})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Server.Port != 9200 || c.RL.Store != "postgres" {
+		t.Errorf("expected values from toml, got port %d store %q", c.Server.Port, c.RL.Store)
+	}
+}
+
+func TestLoadReportsAllProblems(t *testing.T) {
+	t.Setenv("SERVER_PORT", "eighty")
+	t.Setenv("RATE_LIMIT_READ", "lots")
+
+	_, err := config.Load(nil)
+
+	var cErr *config.Error
+	if !errors.As(err, &cErr) {
+		t.Fatalf("expected *config.Error, got %v", err)
+	}
+
+	expected := []string{"DB_NAME is required", "DB_PASS is required", "DB_USER is required", "SERVER_PORT", "RATE_LIMIT_READ"}
+	for _, want := range expected {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected problem mentioning %q in:\n%s", want, err)
+		}
+	}
+}
+
+func TestLoadCrossFieldValidation(t *testing.T) {
+	setRequired(t)
+	t.Setenv("AUTH_JWT_JWKS_FILE", "jwks.json")
+	t.Setenv("AUTH_JWT_JWKS_URL", "https://example.com/jwks.json")
+	t.Setenv("AUTH_JWT_ROLE_MAP", "songs-admin=root")
+	t.Setenv("RATE_LIMIT_STORE", "redis")
+
+	_, err := config.Load([]string{"--server-port", "70000"})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	var cErr *config.Error
+	if !errors.As(err, &cErr) || len(cErr.Problems) != 4 {
+		t.Fatalf("expected 4 problems, got:\n%s", err)
+	}
+}
+
+func TestLoadValidatesDecodedFields(t *testing.T) {
+	setRequired(t)
+	t.Setenv("SERVER_PORT", "eighty")
+	t.Setenv("RATE_LIMIT_STORE", "redis")
+
+	_, err := config.Load(nil)
+
+	var cErr *config.Error
+	if !errors.As(err, &cErr) || len(cErr.Problems) != 2 {
+		t.Fatalf("expected 2 problems, got:\n%s", err)
+	}
+	if !strings.Contains(err.Error(), `SERVER_PORT: invalid integer "eighty"`) || !strings.Contains(err.Error(), "RATE_LIMIT_STORE") {
+		t.Errorf("expected the decoding and the validation problem, got:\n%s", err)
+	}
+}
+
+func TestLoadEmptyEnvClearsFile(t *testing.T) {
+	setRequired(t)
+
+	file := filepath.Join(t.TempDir(), "songs.yaml")
+	data := "cors:\n  allowed_methods: [GET, POST]\n"
+	if err := os.WriteFile(file, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("CORS_ALLOWED_METHODS", "")
+
+	c, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.CORS.AllowedMethods) != 0 {
+		t.Errorf("expected env to clear the methods, got %v", c.CORS.AllowedMethods)
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	setRequired(t)
+	t.Setenv("AUTH_JWT_HS256_SECRET", "top-secret")
+
+	c, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	c.Print(&buf)
+	out := buf.String()
+
+	if strings.Contains(out, "password") || strings.Contains(out, "top-secret") {
+		t.Errorf("secrets leaked:\n%s", out)
+	}
+	if !strings.Contains(out, "DB_USER=user # env") {
+		t.Errorf("expected DB_USER with its source:\n%s", out)
+	}
+	if !strings.Contains(out, "SERVER_PORT=8080 # default") {
+		t.Errorf("expected SERVER_PORT default:\n%s", out)
+	}
+}
+
+func TestOrigins(t *testing.T) {
+	setRequired(t)
+	t.Setenv("FRONTEND_HOST", "songs.local")
+	t.Setenv("FRONTEND_PORT", "3000")
+
+	c, err := config.Load(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(c.Origins(), ";"); got != "http://songs.local:3000" {
+		t.Errorf("expected origin from frontend address, got %q", got)
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://songs.example.com;https://*.preview.example.com")
+	if c, err = config.Load(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(c.Origins()) != 2 {
+		t.Errorf("expected configured origins, got %v", c.Origins())
+	}
+
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	if _, err = config.Load(nil); err == nil || !strings.Contains(err.Error(), "CORS_ALLOW_CREDENTIALS") {
+		t.Errorf("expected wildcard with credentials to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,325 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	configFileEnvKey = "CONFIG_FILE"
+	redacted         = "********"
+)
+
+type source string
+
+const (
+	sourceDefault source = "default"
+	sourceFile    source = "file"
+	sourceEnv     source = "env"
+	sourceFlag    source = "flag"
+)
+
+// setting is a single configuration value bound to a field of Conf.
+type setting struct {
+	key      string
+	def      string
+	required bool
+	secret   bool
+	field    reflect.Value
+
+	value  string
+	source source
+}
+
+// Load builds the configuration from, in increasing order of precedence: defaults,
+// an optional YAML or TOML file (--config or CONFIG_FILE), the environment including
+// an optional .env file, and command line flags. A variable set but empty clears the
+// value below it. All problems are reported together.
+func Load(args []string) (*Conf, error) {
+	c := &Conf{}
+	settings := collect(reflect.ValueOf(c).Elem())
+
+	fset := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := fset.String("config", "", "path to a YAML or TOML config file, also read from "+configFileEnvKey)
+	printConfig := fset.Bool("print-config", false, "print the effective configuration with secrets redacted and exit")
+
+	flags := make(map[string]*string, len(settings))
+	for _, s := range settings {
+		flags[s.key] = fset.String(flagName(s.key), "", "overrides "+s.key)
+	}
+
+	if err := fset.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load .env file: %w", err)
+	}
+
+	var problems []string
+
+	for _, s := range settings {
+		s.value, s.source = s.def, sourceDefault
+	}
+
+	if *configFile == "" {
+		*configFile = os.Getenv(configFileEnvKey)
+	}
+	if *configFile != "" {
+		values, err := readFile(*configFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+
+		known := make(map[string]*setting, len(settings))
+		for _, s := range settings {
+			known[s.key] = s
+		}
+		for key, value := range values {
+			s, ok := known[key]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown setting %s", *configFile, key))
+				continue
+			}
+			s.value, s.source = value, sourceFile
+		}
+	}
+
+	for _, s := range settings {
+		if value, ok := os.LookupEnv(s.key); ok {
+			s.value, s.source = value, sourceEnv
+		}
+	}
+
+	fset.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if flagName(s.key) == f.Name {
+				s.value, s.source = *flags[s.key], sourceFlag
+			}
+		}
+	})
+
+	var failed []string
+	for _, s := range settings {
+		if s.value == "" {
+			if s.required {
+				problems = append(problems, fmt.Sprintf("%s is required", s.key))
+			}
+			continue
+		}
+		if err := decode(s.field, s.value); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", s.key, err))
+			failed = append(failed, s.key)
+		}
+	}
+
+	for _, p := range c.validate() {
+		if !mentionsAny(p, failed) {
+			problems = append(problems, p)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, &Error{Problems: problems}
+	}
+
+	c.printConfig = *printConfig
+	c.settings = settings
+
+	return c, nil
+}
+
+// Error lists every problem found while loading the configuration.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Print writes the effective configuration in .env format, noting where each value came from.
+func (c *Conf) Print(w io.Writer) {
+	for _, s := range c.settings {
+		value := s.value
+		if s.secret && value != "" {
+			value = redacted
+		}
+		fmt.Fprintf(w, "%s=%s # %s\n", s.key, value, s.source)
+	}
+}
+
+func collect(v reflect.Value) []*setting {
+	var settings []*setting
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		key, ok := f.Tag.Lookup("env")
+		if !ok {
+			if f.Type.Kind() == reflect.Struct {
+				settings = append(settings, collect(v.Field(i))...)
+			}
+			continue
+		}
+
+		settings = append(settings, &setting{
+			key:      key,
+			def:      f.Tag.Get("default"),
+			required: f.Tag.Get("required") == "true",
+			secret:   f.Tag.Get("secret") == "true",
+			field:    v.Field(i),
+		})
+	}
+
+	return settings
+}
+
+func decode(f reflect.Value, value string) error {
+	if u, ok := f.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	if f.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		f.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		f.SetInt(int64(n))
+	case reflect.Float64:
+		x, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		f.SetFloat(x)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		f.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ";") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", f.Type())
+	}
+
+	return nil
+}
+
+// readFile reads a YAML or TOML file and flattens it into setting names.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		var raw map[interface{}]interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		tree = stringKeys(raw)
+	case ".toml":
+		if err := toml.Unmarshal(data, &tree); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config file type %q", ext)
+	}
+
+	values := map[string]string{}
+	flatten("", tree, values)
+
+	return values, nil
+}
+
+func flatten(prefix string, tree map[string]interface{}, values map[string]string) {
+	for k, v := range tree {
+		key := strings.ToUpper(strings.ReplaceAll(k, "-", "_"))
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		switch v := v.(type) {
+		case map[string]interface{}:
+			flatten(key, v, values)
+		case map[interface{}]interface{}:
+			flatten(key, stringKeys(v), values)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ";")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+func stringKeys(m map[interface{}]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[fmt.Sprint(k)] = v
+	}
+
+	return out
+}
+
+// mentionsAny reports whether a validation problem starts with one of keys, whose values
+// failed to decode and were already reported.
+func mentionsAny(problem string, keys []string) bool {
+	for _, key := range keys {
+		if rest, ok := strings.CutPrefix(problem, key); ok && (rest == "" || strings.ContainsRune(" :=,", rune(rest[0]))) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func flagName(key string) string {
+	return strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+}
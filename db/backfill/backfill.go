@@ -0,0 +1,79 @@
+// Package backfill fills columns that new code computes on save for rows written before it.
+package backfill
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/song"
+)
+
+// DefaultBatch is how many songs a backfill reads at a time.
+const DefaultBatch = 500
+
+// Result counts what a backfill run looked at and changed.
+type Result struct {
+	Checked int
+	Updated int
+}
+
+// upgrades are the backfills run after migrating, so songs saved before a migration get
+// what it left for code to compute.
+var upgrades = []struct {
+	name string
+	run  func(db *gorm.DB, logger *zerolog.Logger, all, dryRun bool, batch int) (Result, error)
+}{
+	{name: "search keys", run: SearchKeys},
+	{name: "bands", run: Bands},
+	{name: "lines", run: Lines},
+}
+
+// Upgrade runs the upgrade backfills for the songs that need them. It is cheap when none
+// do, so it runs after every migration.
+func Upgrade(db *gorm.DB, logger *zerolog.Logger) error {
+	for _, u := range upgrades {
+		res, err := u.run(db, logger, false, false, DefaultBatch)
+		if err != nil {
+			return fmt.Errorf("%s backfill: %w", u.name, err)
+		}
+		if res.Updated > 0 {
+			logger.Info().Int("checked", res.Checked).Int("updated", res.Updated).Msgf("Backfilled %s", u.name)
+		}
+	}
+
+	return nil
+}
+
+// eachSong calls fn with the songs matching where, reading the given columns in batches
+// ordered by ID, so rows fn leaves unchanged are not read again.
+func eachSong(db *gorm.DB, columns []string, where string, batch int, fn func(*song.Song) error) error {
+	if batch <= 0 {
+		batch = DefaultBatch
+	}
+
+	last := uuid.Nil
+	for {
+		var songs []song.Song
+		query := db.Model(&song.Song{}).Select(columns).Where("id > ?", last)
+		if where != "" {
+			query = query.Where(where)
+		}
+		if err := query.Order("id").Limit(batch).Find(&songs).Error; err != nil {
+			return err
+		}
+
+		for i := range songs {
+			if err := fn(&songs[i]); err != nil {
+				return err
+			}
+		}
+
+		if len(songs) < batch {
+			return nil
+		}
+		last = songs[len(songs)-1].ID
+	}
+}
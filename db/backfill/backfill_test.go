@@ -0,0 +1,56 @@
+package backfill_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"songs/db/backfill"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+func TestUpgrade(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	id := uuid.New()
+	mock.ExpectQuery(`^SELECT "id","group_name","song_name","group_key","song_key" FROM "songs" WHERE id > \$1 AND \(group_key = '' OR song_key = ''\) ORDER BY id LIMIT \$2`).
+		WithArgs(uuid.Nil, backfill.DefaultBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "group_name", "song_name", "group_key", "song_key"}).
+			AddRow(id, "AC/DC", "Thunderstruck", "", ""))
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "songs" SET "group_key"=\$1,"song_key"=\$2 WHERE id = \$3`).
+		WithArgs("ac dc", "thunderstruck", id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`^SELECT "id","text","minhash" FROM "songs" WHERE id > \$1 AND NOT EXISTS \(SELECT 1 FROM song_bands WHERE song_bands.song_id = songs.id\)`).
+		WithArgs(uuid.Nil, backfill.DefaultBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text", "minhash"}).
+			AddRow(id, "Thunder!", nil))
+	mock.ExpectBegin()
+	mock.ExpectExec(`^DELETE FROM "song_bands" WHERE song_id = \$1`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO "song_bands"`).
+		WillReturnResult(sqlmock.NewResult(0, 16))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`^SELECT "id","text" FROM "songs" WHERE id > \$1 AND NOT EXISTS \(SELECT 1 FROM song_lines WHERE song_lines.song_id = songs.id\)`).
+		WithArgs(uuid.Nil, backfill.DefaultBatch).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text"}).
+			AddRow(id, "Thunder!"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`^DELETE FROM "song_lines" WHERE song_id = \$1`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO "song_lines" \("song_id","line_no","stanza","key"\) VALUES \(\$1,\$2,\$3,\$4\)`).
+		WithArgs(id, 1, 1, "thunder").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	testUtil.NoError(t, backfill.Upgrade(db, &testLogger))
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,33 @@
+package backfill
+
+import (
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/song"
+)
+
+// Bands indexes the MinHash band hashes of songs with none in the band index, or of every
+// song when all is set, so duplicate detection finds them. With dryRun nothing is written.
+func Bands(db *gorm.DB, logger *zerolog.Logger, all, dryRun bool, batch int) (Result, error) {
+	where := "NOT EXISTS (SELECT 1 FROM song_bands WHERE song_bands.song_id = songs.id)"
+	if all {
+		where = ""
+	}
+
+	var res Result
+	err := eachSong(db, []string{"id", "text", "minhash"}, where, batch, func(s *song.Song) error {
+		res.Checked++
+
+		logger.Debug().Msgf("Song %s: indexing bands", s.ID)
+		res.Updated++
+		if dryRun {
+			return nil
+		}
+		return db.Transaction(func(tx *gorm.DB) error {
+			return song.IndexBands(tx, s)
+		})
+	})
+
+	return res, err
+}
@@ -0,0 +1,55 @@
+package backfill_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"songs/db/backfill"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+func TestBands(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	id := uuid.New()
+	mock.ExpectQuery(`^SELECT "id","text","minhash" FROM "songs" WHERE id > \$1 AND NOT EXISTS \(SELECT 1 FROM song_bands WHERE song_bands.song_id = songs.id\) ORDER BY id LIMIT \$2`).
+		WithArgs(uuid.Nil, 500).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text", "minhash"}).
+			AddRow(id, "Группа крови на рукаве", nil))
+	mock.ExpectBegin()
+	mock.ExpectExec(`^DELETE FROM "song_bands" WHERE song_id = \$1`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO "song_bands" \("song_id","band","hash"\) VALUES \(\$1,\$2,\$3\),(.+)`).
+		WillReturnResult(sqlmock.NewResult(0, 16))
+	mock.ExpectCommit()
+
+	res, err := backfill.Bands(db, &testLogger, false, false, 0)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, res.Checked, 1)
+	testUtil.Equal(t, res.Updated, 1)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBands_DryRun(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	mock.ExpectQuery(`^SELECT "id","text","minhash" FROM "songs" WHERE id > \$1 ORDER BY id LIMIT \$2`).
+		WithArgs(uuid.Nil, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text", "minhash"}).
+			AddRow(uuid.New(), "La la la", nil))
+
+	res, err := backfill.Bands(db, &testLogger, true, true, 2)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, res.Updated, 1)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
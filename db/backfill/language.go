@@ -0,0 +1,42 @@
+package backfill
+
+import (
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/song"
+	"songs/pkg/langdetect"
+)
+
+// recorded matches songs whose original's language was set by hand, which detection
+// must not override.
+const recorded = "EXISTS (SELECT 1 FROM song_lyrics AS l WHERE l.song_id = songs.id AND l.is_original)"
+
+// Languages detects the language of songs whose language is unknown, or of every song
+// when all is set, and stores it. Songs with a recorded original are skipped. A run can
+// be repeated safely; with dryRun nothing is written.
+func Languages(db *gorm.DB, logger *zerolog.Logger, all, dryRun bool, batch int) (Result, error) {
+	where := "language = '' AND NOT " + recorded
+	if all {
+		where = "NOT " + recorded
+	}
+
+	var res Result
+	err := eachSong(db, []string{"id", "text", "language"}, where, batch, func(s *song.Song) error {
+		res.Checked++
+
+		lang := langdetect.Detect(s.Text)
+		if lang == s.Language {
+			return nil
+		}
+
+		logger.Debug().Msgf("Song %s language: %q -> %q", s.ID, s.Language, lang)
+		res.Updated++
+		if dryRun {
+			return nil
+		}
+		return db.Model(&song.Song{}).Where("id = ?", s.ID).Update("language", lang).Error
+	})
+
+	return res, err
+}
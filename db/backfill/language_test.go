@@ -0,0 +1,42 @@
+package backfill_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"songs/db/backfill"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+var testLogger = zerolog.Nop()
+
+func TestLanguages(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	first, second := uuid.New(), uuid.New()
+	mock.ExpectQuery(`^SELECT "id","text","language" FROM "songs" WHERE id > (.+) AND \(language = '' AND NOT EXISTS \(SELECT 1 FROM song_lyrics AS l WHERE l.song_id = songs.id AND l.is_original\)\) ORDER BY id LIMIT (.+)`).
+		WithArgs(uuid.Nil, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text", "language"}).
+			AddRow(first, "Я помню чудное мгновенье, передо мной явилась ты", "").
+			AddRow(second, "La la la", ""))
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "songs" SET "language"=\$1 WHERE id = \$2`).
+		WithArgs("ru", first).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`^SELECT "id","text","language" FROM "songs" WHERE id > (.+) AND \(language = '' AND NOT EXISTS \(SELECT 1 FROM song_lyrics AS l WHERE l.song_id = songs.id AND l.is_original\)\) ORDER BY id LIMIT (.+)`).
+		WithArgs(second, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text", "language"}))
+
+	res, err := backfill.Languages(db, &testLogger, false, false, 2)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, res.Checked, 2)
+	testUtil.Equal(t, res.Updated, 1)
+}
@@ -0,0 +1,39 @@
+package backfill
+
+import (
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/song"
+)
+
+// Lines indexes the lyric lines of songs with no lines in the line index, or of every song
+// when all is set. Songs without lyrics have no lines and are checked on every run. With
+// dryRun nothing is written.
+func Lines(db *gorm.DB, logger *zerolog.Logger, all, dryRun bool, batch int) (Result, error) {
+	where := "NOT EXISTS (SELECT 1 FROM song_lines WHERE song_lines.song_id = songs.id)"
+	if all {
+		where = ""
+	}
+
+	var res Result
+	err := eachSong(db, []string{"id", "text"}, where, batch, func(s *song.Song) error {
+		res.Checked++
+
+		lines := s.Lines()
+		if len(lines) == 0 && !all {
+			return nil
+		}
+
+		logger.Debug().Msgf("Song %s: %d lines", s.ID, len(lines))
+		res.Updated++
+		if dryRun {
+			return nil
+		}
+		return db.Transaction(func(tx *gorm.DB) error {
+			return song.IndexLines(tx, s)
+		})
+	})
+
+	return res, err
+}
@@ -0,0 +1,40 @@
+package backfill_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"songs/db/backfill"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+func TestLines(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	id, empty := uuid.New(), uuid.New()
+	mock.ExpectQuery(`^SELECT "id","text" FROM "songs" WHERE id > \$1 AND NOT EXISTS \(SELECT 1 FROM song_lines WHERE song_lines.song_id = songs.id\) ORDER BY id LIMIT \$2`).
+		WithArgs(uuid.Nil, 500).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text"}).
+			AddRow(id, "Группа крови на рукаве\n\nПожелай мне удачи").
+			AddRow(empty, ""))
+	mock.ExpectBegin()
+	mock.ExpectExec(`^DELETE FROM "song_lines" WHERE song_id = \$1`).
+		WithArgs(id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^INSERT INTO "song_lines" \("song_id","line_no","stanza","key"\) VALUES \(\$1,\$2,\$3,\$4\),\(\$5,\$6,\$7,\$8\)`).
+		WithArgs(id, 1, 1, "grupa krovi na rukave", id, 3, 2, "pozelai mne udaci").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	res, err := backfill.Lines(db, &testLogger, false, false, 0)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, res.Checked, 2)
+	testUtil.Equal(t, res.Updated, 1)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,39 @@
+package backfill
+
+import (
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/song"
+	"songs/pkg/translit"
+)
+
+// SearchKeys computes the search keys of songs that have none, or of every song when all
+// is set, and stores those that changed. With dryRun nothing is written.
+func SearchKeys(db *gorm.DB, logger *zerolog.Logger, all, dryRun bool, batch int) (Result, error) {
+	where := "group_key = '' OR song_key = ''"
+	if all {
+		where = ""
+	}
+
+	var res Result
+	columns := []string{"id", "group_name", "song_name", "group_key", "song_key"}
+	err := eachSong(db, columns, where, batch, func(s *song.Song) error {
+		res.Checked++
+
+		groupKey, songKey := translit.Key(s.Group), translit.Key(s.Song)
+		if groupKey == s.GroupKey && songKey == s.SongKey {
+			return nil
+		}
+
+		logger.Debug().Msgf("Song %s search keys: %q, %q", s.ID, groupKey, songKey)
+		res.Updated++
+		if dryRun {
+			return nil
+		}
+		return db.Model(&song.Song{}).Where("id = ?", s.ID).
+			Updates(map[string]interface{}{"group_key": groupKey, "song_key": songKey}).Error
+	})
+
+	return res, err
+}
@@ -0,0 +1,32 @@
+package backfill_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"songs/db/backfill"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+func TestSearchKeys(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	first, second := uuid.New(), uuid.New()
+	mock.ExpectQuery(`^SELECT "id","group_name","song_name","group_key","song_key" FROM "songs" WHERE id > \$1 AND \(group_key = '' OR song_key = ''\) ORDER BY id LIMIT \$2`).
+		WithArgs(uuid.Nil, 500).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "group_name", "song_name", "group_key", "song_key"}).
+			AddRow(first, "Кино", "Пачка сигарет", "", "").
+			AddRow(second, "Muse", "Starlight", "muse", ""))
+
+	res, err := backfill.SearchKeys(db, &testLogger, false, true, 0)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, res.Checked, 2)
+	testUtil.Equal(t, res.Updated, 2)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,39 @@
+package backfill
+
+import (
+	"reflect"
+
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+
+	"songs/api/resource/song"
+	"songs/pkg/lyricstats"
+)
+
+// Stats counts the lyrics of songs stored without stats, or of every song when all is set,
+// and stores the counts that changed. With dryRun nothing is written.
+func Stats(db *gorm.DB, logger *zerolog.Logger, all, dryRun bool, batch int) (Result, error) {
+	where := "stats IS NULL"
+	if all {
+		where = ""
+	}
+
+	var res Result
+	err := eachSong(db, []string{"id", "text", "stats"}, where, batch, func(s *song.Song) error {
+		res.Checked++
+
+		counts := lyricstats.Count(s.Text)
+		if reflect.DeepEqual(counts, s.Stats) {
+			return nil
+		}
+
+		logger.Debug().Msgf("Song %s stats: %d words, %d lines", s.ID, counts.Words, counts.Lines)
+		res.Updated++
+		if dryRun {
+			return nil
+		}
+		return db.Model(&song.Song{}).Where("id = ?", s.ID).Select("Stats").Updates(&song.Song{Stats: counts}).Error
+	})
+
+	return res, err
+}
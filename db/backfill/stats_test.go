@@ -0,0 +1,36 @@
+package backfill_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+
+	"songs/db/backfill"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	id := uuid.New()
+	mock.ExpectQuery(`^SELECT "id","text","stats" FROM "songs" WHERE id > \$1 AND stats IS NULL ORDER BY id LIMIT \$2`).
+		WithArgs(uuid.Nil, 500).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "text", "stats"}).
+			AddRow(id, "Группа крови на рукаве\nГруппа крови на рукаве", nil))
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "songs" SET "stats"=\$1 WHERE id = \$2`).
+		WithArgs(`{"songs":1,"words":8,"lines":2,"stanzas":1,"repeated_lines":1,"repeated_stanzas":0,"frequencies":{"группа":2,"крови":2,"на":2,"рукаве":2}}`, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	res, err := backfill.Stats(db, &testLogger, false, false, 0)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, res.Checked, 1)
+	testUtil.Equal(t, res.Updated, 1)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
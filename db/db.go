@@ -0,0 +1,42 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+	gormPostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+
+	"songs/config"
+)
+
+const fmtDBString = "host=%s user=%s password=%s dbname=%s port=%d sslmode=disable"
+
+// DSN returns the PostgreSQL connection string for the given configuration.
+func DSN(c *config.ConfigDB) string {
+	return fmt.Sprintf(fmtDBString, c.Host, c.Username, c.Password, c.DBName, c.Port)
+}
+
+// New opens a gorm connection to the database described by c.
+func New(c *config.ConfigDB, l *zerolog.Logger) (*gorm.DB, error) {
+	l.Debug().Msg("Setting up database connection...")
+
+	var logLevel gormlogger.LogLevel
+	if c.Debug {
+		logLevel = gormlogger.Info
+		l.Debug().Msg("Database debug mode is ON")
+	} else {
+		logLevel = gormlogger.Error
+		l.Debug().Msg("Database debug mode is OFF")
+	}
+
+	db, err := gorm.Open(gormPostgres.Open(DSN(c)), &gorm.Config{Logger: gormlogger.Default.LogMode(logLevel)})
+	if err != nil {
+		l.Error().Err(err).Msg("Failed to connect to the database")
+		return nil, err
+	}
+
+	l.Info().Msg("Database connection successfully established")
+	return db, nil
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"songs/config"
+)
+
+// MigrationsDir is where migration files live in the source tree, relative to the module root.
+const MigrationsDir = "db/migrations"
+
+// Migrations holds the SQL migrations compiled into every binary that imports this package.
+//
+//go:embed migrations/*.sql
+var Migrations embed.FS
+
+// Source returns the embedded migrations as a golang-migrate source.
+func Source() (source.Driver, error) {
+	return iofs.New(Migrations, "migrations")
+}
+
+// NewMigrate connects to the database described by c and prepares the embedded migrations.
+// Closing the returned instance also closes the connection.
+func NewMigrate(c *config.ConfigDB) (*migrate.Migrate, error) {
+	conn, err := sql.Open("postgres", DSN(c))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
+	}
+
+	driver, err := postgres.WithInstance(conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create migrate driver: %w", err)
+	}
+
+	src, err := Source()
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,44 @@
+package db_test
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+
+	"songs/db"
+)
+
+func TestSourceEmbedsEveryMigration(t *testing.T) {
+	src, err := db.Source()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer src.Close()
+
+	var expected uint = 1
+	v, err := src.First()
+	for err == nil {
+		if v != expected {
+			t.Errorf("expected version %d, got %d", expected, v)
+		}
+		if r, _, err := src.ReadUp(v); err != nil {
+			t.Errorf("version %d has no up migration: %v", v, err)
+		} else {
+			r.Close()
+		}
+		if r, _, err := src.ReadDown(v); err != nil {
+			t.Errorf("version %d has no down migration: %v", v, err)
+		} else {
+			r.Close()
+		}
+
+		expected++
+		v, err = src.Next(v)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected == 1 {
+		t.Fatal("no migrations embedded")
+	}
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS songs_language_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS language;
@@ -0,0 +1,4 @@
+-- Language of the song's text, detected when it is saved; '' when it could not be told.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS language VARCHAR(35) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS songs_language_idx ON songs (language);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS songs_group_key_song_key_idx;
+ALTER TABLE songs DROP COLUMN IF EXISTS song_key;
+ALTER TABLE songs DROP COLUMN IF EXISTS group_key;
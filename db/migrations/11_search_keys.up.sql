@@ -0,0 +1,6 @@
+-- Transliteration-insensitive keys of the group and song names, computed when a song is
+-- saved; cmd/backfill searchkeys fills them for older rows.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS group_key VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS song_key VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS songs_group_key_song_key_idx ON songs (group_key, song_key);
@@ -0,0 +1,11 @@
+-- Alternate names of groups. Each alias, by its search key, stands for the canonical group
+-- named in group_name; aliases never point at other aliases.
+CREATE TABLE IF NOT EXISTS group_aliases (
+   alias_key VARCHAR(255) PRIMARY KEY,
+   alias VARCHAR(255) NOT NULL,
+   group_name VARCHAR(255) NOT NULL,
+   group_key VARCHAR(255) NOT NULL,
+   CHECK (alias_key <> group_key)
+);
+
+CREATE INDEX IF NOT EXISTS group_aliases_group_key_idx ON group_aliases (group_key);
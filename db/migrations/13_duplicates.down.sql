@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS song_revisions;
+ALTER TABLE songs DROP COLUMN IF EXISTS minhash;
@@ -0,0 +1,22 @@
+-- MinHash signature of the lyrics, for finding duplicate songs; NULL for songs stored
+-- before signatures, whose lyrics are signed when needed.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS minhash BYTEA;
+
+-- Earlier versions of a song. Merging duplicates records each merged song here, under the
+-- song it was merged into, before deleting it.
+CREATE TABLE IF NOT EXISTS song_revisions (
+   id UUID PRIMARY KEY,
+   song_id UUID NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+   source_id UUID NOT NULL,
+   reason VARCHAR(32) NOT NULL,
+   group_name VARCHAR(255) NOT NULL,
+   song_name VARCHAR(255) NOT NULL,
+   text TEXT NOT NULL,
+   release_date VARCHAR(10) NOT NULL,
+   link VARCHAR(255) NOT NULL,
+   chordpro TEXT NOT NULL DEFAULT '',
+   language VARCHAR(35) NOT NULL DEFAULT '',
+   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS song_revisions_song_id_idx ON song_revisions (song_id, created_at);
@@ -0,0 +1,3 @@
+-- Word and line counts of the lyrics, for the stats endpoints; NULL for songs stored before
+-- them, whose lyrics are counted when needed.
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS stats JSONB;
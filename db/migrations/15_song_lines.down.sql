@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS song_lines;
+DROP EXTENSION IF EXISTS pg_trgm;
@@ -0,0 +1,14 @@
+-- Trigram matching lets a lyric line be found despite small typos.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- The lines of each song's lyrics by their search key, for finding a song by a line. Line
+-- and stanza numbers count from 1; line numbers count blank lines too, stanza numbers do not.
+CREATE TABLE IF NOT EXISTS song_lines (
+   song_id UUID NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+   line_no INTEGER NOT NULL,
+   stanza INTEGER NOT NULL,
+   key TEXT NOT NULL,
+   PRIMARY KEY (song_id, line_no)
+);
+
+CREATE INDEX IF NOT EXISTS song_lines_key_trgm_idx ON song_lines USING GIN (key gin_trgm_ops);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS api_keys_active_name_key;
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_name_key UNIQUE (name);
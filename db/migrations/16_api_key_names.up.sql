@@ -0,0 +1,3 @@
+-- Names only need to be unique among active keys, so a revoked key's name can be reused.
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_active_name_key ON api_keys (name) WHERE revoked_at IS NULL;
@@ -0,0 +1,2 @@
+-- Nothing to undo: the detected languages were not kept.
+SELECT 1;
@@ -0,0 +1,3 @@
+-- A recorded original's language overrides the detected one.
+UPDATE songs SET language = l.language FROM song_lyrics AS l
+WHERE l.song_id = songs.id AND l.is_original AND songs.language <> l.language;
@@ -0,0 +1,10 @@
+-- The MinHash band hashes of each song's lyrics. Songs sharing a band hash, or their group
+-- and title keys, are the only candidates scored when looking for duplicates.
+CREATE TABLE IF NOT EXISTS song_bands (
+   song_id UUID NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+   band SMALLINT NOT NULL,
+   hash BIGINT NOT NULL,
+   PRIMARY KEY (song_id, band)
+);
+
+CREATE INDEX IF NOT EXISTS song_bands_band_hash_idx ON song_bands (band, hash);
@@ -0,0 +1,10 @@
+UPDATE song_revisions SET
+   text = COALESCE(text, ''),
+   release_date = LEFT(COALESCE(release_date, ''), 10),
+   link = COALESCE(link, '');
+
+ALTER TABLE song_revisions
+   ALTER COLUMN text SET NOT NULL,
+   ALTER COLUMN release_date TYPE VARCHAR(10),
+   ALTER COLUMN release_date SET NOT NULL,
+   ALTER COLUMN link SET NOT NULL;
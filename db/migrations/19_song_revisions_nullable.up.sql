@@ -0,0 +1,7 @@
+-- Revisions copy songs, so their lyrics, release date and link take what songs allow:
+-- songs stored by early versions may have none, or a longer release date.
+ALTER TABLE song_revisions
+   ALTER COLUMN text DROP NOT NULL,
+   ALTER COLUMN release_date TYPE VARCHAR(50),
+   ALTER COLUMN release_date DROP NOT NULL,
+   ALTER COLUMN link DROP NOT NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+   id UUID PRIMARY KEY,
+   name VARCHAR(255) NOT NULL UNIQUE,
+   prefix VARCHAR(16) NOT NULL,
+   key_hash VARCHAR(64) NOT NULL UNIQUE,
+   scopes VARCHAR(255) NOT NULL DEFAULT '',
+   expires_at TIMESTAMPTZ,
+   last_used_at TIMESTAMPTZ,
+   revoked_at TIMESTAMPTZ,
+   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
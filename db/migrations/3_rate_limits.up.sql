@@ -0,0 +1,6 @@
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+   key VARCHAR(255) PRIMARY KEY,
+   tokens DOUBLE PRECISION NOT NULL,
+   allowed BOOLEAN NOT NULL,
+   updated_at TIMESTAMPTZ NOT NULL
+);
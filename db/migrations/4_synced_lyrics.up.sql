@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS song_synced_lyrics (
+   song_id UUID PRIMARY KEY REFERENCES songs(id) ON DELETE CASCADE,
+   lrc TEXT NOT NULL,
+   updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS playlist_items;
+DROP FUNCTION IF EXISTS playlist_items_compact();
+DROP TABLE IF EXISTS playlists;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS playlists (
+   id UUID PRIMARY KEY,
+   name VARCHAR(255) NOT NULL,
+   description TEXT NOT NULL DEFAULT '',
+   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+   updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Positions run from 1 without gaps. The unique check is deferred so moves can shift
+-- neighbouring items one statement at a time.
+CREATE TABLE IF NOT EXISTS playlist_items (
+   id UUID PRIMARY KEY,
+   playlist_id UUID NOT NULL REFERENCES playlists(id) ON DELETE CASCADE,
+   song_id UUID NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+   position INTEGER NOT NULL CHECK (position > 0),
+   added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+   CONSTRAINT playlist_items_position_key UNIQUE (playlist_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX IF NOT EXISTS playlist_items_song_id_idx ON playlist_items (song_id);
+
+-- Deleting items, directly or through a deleted song, closes the gaps they leave.
+CREATE OR REPLACE FUNCTION playlist_items_compact() RETURNS TRIGGER AS $$
+BEGIN
+   UPDATE playlist_items AS i
+      SET position = ranked.position
+     FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY playlist_id ORDER BY position) AS position
+             FROM playlist_items
+            WHERE playlist_id IN (SELECT playlist_id FROM removed)) AS ranked
+    WHERE i.id = ranked.id AND i.position <> ranked.position;
+
+   UPDATE playlists SET updated_at = NOW() WHERE id IN (SELECT playlist_id FROM removed);
+
+   RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER playlist_items_compact
+   AFTER DELETE ON playlist_items
+   REFERENCING OLD TABLE AS removed
+   FOR EACH STATEMENT EXECUTE FUNCTION playlist_items_compact();
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS genres (
+   id UUID PRIMARY KEY,
+   slug VARCHAR(64) NOT NULL UNIQUE,
+   name VARCHAR(255) NOT NULL,
+   parent_id UUID REFERENCES genres(id),
+   CHECK (parent_id <> id)
+);
+
+CREATE INDEX IF NOT EXISTS genres_parent_id_idx ON genres (parent_id);
+
+CREATE TABLE IF NOT EXISTS song_genres (
+   song_id UUID NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+   genre_id UUID NOT NULL REFERENCES genres(id) ON DELETE CASCADE,
+   PRIMARY KEY (song_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS song_genres_genre_id_idx ON song_genres (genre_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+   id UUID PRIMARY KEY,
+   name VARCHAR(64) NOT NULL UNIQUE
+);
+
+-- Serves prefix searches for autocomplete.
+CREATE INDEX IF NOT EXISTS tags_name_prefix_idx ON tags (name varchar_pattern_ops);
+
+CREATE TABLE IF NOT EXISTS song_tags (
+   song_id UUID NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+   tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+   PRIMARY KEY (song_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS song_tags_tag_id_idx ON song_tags (tag_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS song_credits;
+DROP TABLE IF EXISTS people;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS people (
+   id UUID PRIMARY KEY,
+   name VARCHAR(255) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS people_name_idx ON people (LOWER(name));
+
+-- People with credits cannot be deleted, so licensing records never lose a rights holder.
+CREATE TABLE IF NOT EXISTS song_credits (
+   song_id UUID NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+   person_id UUID NOT NULL REFERENCES people(id),
+   role VARCHAR(32) NOT NULL CHECK (role IN ('lyricist', 'composer', 'producer', 'featured_artist')),
+   share NUMERIC(5, 2) CHECK (share BETWEEN 0 AND 100),
+   PRIMARY KEY (song_id, person_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS song_credits_person_id_idx ON song_credits (person_id, role);
@@ -0,0 +1,13 @@
+-- Language versions of a song's lyrics. The original's text stays in songs.text, so its
+-- row only records the language; translations carry their own text.
+CREATE TABLE IF NOT EXISTS song_lyrics (
+   song_id UUID NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+   language VARCHAR(35) NOT NULL,
+   is_original BOOLEAN NOT NULL DEFAULT FALSE,
+   text TEXT,
+   translator VARCHAR(255) NOT NULL DEFAULT '',
+   PRIMARY KEY (song_id, language),
+   CHECK (is_original = (text IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS song_lyrics_original_key ON song_lyrics (song_id) WHERE is_original;
@@ -0,0 +1,188 @@
+package seed
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v2"
+	"gorm.io/gorm"
+
+	"songs/api/resource/song"
+	validatorUtil "songs/util/validator"
+)
+
+//go:embed fixtures/songs.json
+var fixtures []byte
+
+// namespace derives stable song IDs from group and song names, so reseeding never duplicates rows.
+var namespace = uuid.MustParse("6f1c1a4e-7b0e-4c55-9d4f-2a6f0f3c9a11")
+
+// Fixture is a song as written in a seed file, with the same fields as the create request.
+type Fixture struct {
+	Group       string `json:"group" yaml:"group"`
+	Song        string `json:"song" yaml:"song"`
+	Text        string `json:"text" yaml:"text"`
+	ReleaseDate string `json:"release_date" yaml:"release_date"`
+	Link        string `json:"link" yaml:"link"`
+	ChordPro    string `json:"chordpro,omitempty" yaml:"chordpro"`
+}
+
+func (f *Fixture) toRequest() *song.SongRequest {
+	return &song.SongRequest{
+		Group:       f.Group,
+		Song:        f.Song,
+		Text:        f.Text,
+		ReleaseDate: f.ReleaseDate,
+		Link:        f.Link,
+		ChordPro:    f.ChordPro,
+	}
+}
+
+// Result counts what a seed run changed.
+type Result struct {
+	Deleted int64
+	Created int
+	Updated int
+}
+
+// Bundled returns the fixture set shipped with the binary.
+func Bundled() ([]*Fixture, error) {
+	return Parse(fixtures, ".json")
+}
+
+// ReadFile reads fixtures from a JSON or YAML file, picking the format by extension.
+func ReadFile(path string) ([]*Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data, filepath.Ext(path))
+}
+
+// Parse decodes a list of fixtures in the format named by ext (".json", ".yaml" or ".yml").
+func Parse(data []byte, ext string) ([]*Fixture, error) {
+	var items []*Fixture
+
+	switch strings.ToLower(ext) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&items); err != nil {
+			return nil, err
+		}
+	case ".yaml", ".yml":
+		if err := yaml.UnmarshalStrict(data, &items); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported fixture file type %q", ext)
+	}
+
+	return items, nil
+}
+
+type Seeder struct {
+	db        *gorm.DB
+	logger    *zerolog.Logger
+	validator *validator.Validate
+}
+
+func New(db *gorm.DB, l *zerolog.Logger, v *validator.Validate) *Seeder {
+	return &Seeder{
+		db:        db,
+		logger:    l,
+		validator: v,
+	}
+}
+
+// Validate checks every fixture against the create request rules and reports all failures.
+func (s *Seeder) Validate(items []*Fixture) error {
+	var problems []string
+	for i, f := range items {
+		if err := s.validator.Struct(f.toRequest()); err != nil {
+			resp := validatorUtil.ToErrResponse(err)
+			if resp == nil {
+				return err
+			}
+			problems = append(problems, fmt.Sprintf("#%d %s / %s: %s", i+1, f.Group, f.Song, strings.Join(resp.Errors, ", ")))
+			continue
+		}
+		if err := f.toRequest().ApplyChordPro(); err != nil {
+			problems = append(problems, fmt.Sprintf("#%d %s / %s: chordpro %v", i+1, f.Group, f.Song, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid fixtures:\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Run writes the fixtures in one transaction. Songs are matched by group and song name and
+// updated in place, so running it twice leaves the table unchanged. With reset every song is
+// deleted first.
+func (s *Seeder) Run(items []*Fixture, reset bool) (*Result, error) {
+	if err := s.Validate(items); err != nil {
+		return nil, err
+	}
+
+	res := &Result{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if reset {
+			result := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&song.Song{})
+			if result.Error != nil {
+				return result.Error
+			}
+			res.Deleted = result.RowsAffected
+			s.logger.Debug().Msgf("Deleted %d songs before seeding", res.Deleted)
+		}
+
+		songs := song.NewRepository(tx, s.logger)
+		for _, f := range items {
+			req := f.toRequest()
+			if err := req.ApplyChordPro(); err != nil {
+				return fmt.Errorf("%s / %s: %w", f.Group, f.Song, err)
+			}
+			m := req.ToModel()
+
+			var existing song.Song
+			err := tx.Where("group_name = ? AND song_name = ?", m.Group, m.Song).Limit(1).Find(&existing).Error
+			if err != nil {
+				return err
+			}
+
+			if existing.ID != uuid.Nil {
+				m.ID = existing.ID
+				if _, err := songs.Update(m); err != nil {
+					return err
+				}
+				res.Updated++
+				continue
+			}
+
+			m.ID = uuid.NewSHA1(namespace, []byte(m.Group+"\x00"+m.Song))
+			if _, err := songs.Create(m); err != nil {
+				return err
+			}
+			res.Created++
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().Msgf("Seeded %d songs: %d created, %d updated", len(items), res.Created, res.Updated)
+	return res, nil
+}
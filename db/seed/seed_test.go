@@ -0,0 +1,142 @@
+package seed_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"songs/db/seed"
+	mockDB "songs/mock/db"
+	testUtil "songs/util/test"
+	"songs/util/validator"
+)
+
+var testLogger = zerolog.Nop()
+
+func TestBundled(t *testing.T) {
+	t.Parallel()
+
+	items, err := seed.Bundled()
+	testUtil.NoError(t, err)
+
+	if len(items) < 200 {
+		t.Errorf("expected a few hundred songs, got %d", len(items))
+	}
+
+	groups := map[string]bool{}
+	for _, f := range items {
+		groups[f.Group] = true
+		if stanzas := strings.Count(f.Text, "\n\n") + 1; stanzas < 2 {
+			t.Errorf("%s / %s has %d stanza", f.Group, f.Song, stanzas)
+		}
+	}
+	if len(groups) < 10 {
+		t.Errorf("expected songs across many groups, got %d", len(groups))
+	}
+
+	testUtil.NoError(t, seed.New(nil, &testLogger, validator.New()).Validate(items))
+}
+
+func TestParseYAML(t *testing.T) {
+	t.Parallel()
+
+	data := []byte(`
+- group: Muse
+  song: Supermassive Black Hole
+  text: |
+    Ooh baby, don't you know I suffer?
+
+    Ooh you set my soul alight
+  release_date: "2006-07-16"
+  link: https://www.youtube.com/watch?v=Xsp3_a-PMTw
+`)
+
+	items, err := seed.Parse(data, ".yaml")
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, len(items), 1)
+	testUtil.Equal(t, items[0].ReleaseDate, "2006-07-16")
+
+	if _, err := seed.Parse([]byte("- group: Muse\n  album: Black Holes\n"), ".yml"); err == nil {
+		t.Error("expected unknown fields to be rejected")
+	}
+}
+
+func TestValidateReportsEveryFixture(t *testing.T) {
+	t.Parallel()
+
+	items := []*seed.Fixture{
+		{Group: "Muse", Song: "Uprising", Text: "They will not force us", ReleaseDate: "2009", Link: "https://example.com"},
+		{Group: "Muse", Text: "Ooh", ReleaseDate: "2006-07-16", Link: "not a link"},
+	}
+
+	err := seed.New(nil, &testLogger, validator.New()).Validate(items)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"#1", "release_date", "#2", "song", "link"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%s", want, err)
+		}
+	}
+}
+
+func TestSeeder_Run(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	items := []*seed.Fixture{
+		{Group: "Muse", Song: "Uprising", Text: "They will not force us", ReleaseDate: "2009-09-07", Link: "https://example.com/1"},
+		{Group: "Muse", Song: "Starlight", Text: "Far away", ReleaseDate: "2006-09-04", Link: "https://example.com/2"},
+	}
+	existing := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("^DELETE FROM \"songs\"").WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectQuery("^SELECT (.+) FROM \"songs\" WHERE (.+)").
+		WithArgs("Muse", "Uprising", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec("^SAVEPOINT ").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT INTO \"songs\" ").
+		WithArgs(sqlmock.AnyArg(), "Muse", "Uprising", "They will not force us", "2009-09-07", "https://example.com/1", "", "", "muse", "uprising", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("^DELETE FROM \"song_bands\" WHERE song_id = \\$1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT INTO \"song_bands\" ").WillReturnResult(sqlmock.NewResult(0, 16))
+	mock.ExpectExec("^DELETE FROM \"song_lines\" WHERE song_id = \\$1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^INSERT INTO \"song_lines\" ").
+		WithArgs(sqlmock.AnyArg(), 1, 1, "thei vil not force us").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("^SELECT (.+) FROM \"songs\" WHERE (.+)").
+		WithArgs("Muse", "Starlight", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "group_name", "song_name"}).AddRow(existing, "Muse", "Starlight"))
+	mock.ExpectExec("^SAVEPOINT ").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^UPDATE \"songs\" SET").
+		WithArgs("Muse", "Starlight", "Far away", "2006-09-04", "https://example.com/2", "", "", "muse", "starlight", sqlmock.AnyArg(), sqlmock.AnyArg(), existing).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^UPDATE songs SET language = l.language FROM song_lyrics AS l (.+) AND songs.id = \\$1").
+		WithArgs(existing).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("^DELETE FROM \"song_bands\" WHERE song_id = \\$1").
+		WithArgs(existing).
+		WillReturnResult(sqlmock.NewResult(0, 16))
+	mock.ExpectExec("^INSERT INTO \"song_bands\" ").
+		WillReturnResult(sqlmock.NewResult(0, 16))
+	mock.ExpectExec("^DELETE FROM \"song_lines\" WHERE song_id = \\$1").
+		WithArgs(existing).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("^INSERT INTO \"song_lines\" ").
+		WithArgs(existing, 1, 1, "far avai").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	res, err := seed.New(db, &testLogger, validator.New()).Run(items, true)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, res.Deleted, int64(5))
+	testUtil.Equal(t, res.Created, 1)
+	testUtil.Equal(t, res.Updated, 1)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
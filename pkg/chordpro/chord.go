@@ -0,0 +1,129 @@
+package chordpro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Notation selects how transposed chords spell the black keys.
+type Notation int
+
+const (
+	// NotationAuto follows the key of the song, or the spelling of its first altered chord.
+	NotationAuto Notation = iota
+	NotationSharp
+	NotationFlat
+)
+
+// ParseNotation accepts "sharp", "flat" and an empty string for NotationAuto.
+func ParseNotation(s string) (Notation, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return NotationAuto, nil
+	case "sharp", "#":
+		return NotationSharp, nil
+	case "flat", "b":
+		return NotationFlat, nil
+	}
+
+	return NotationAuto, fmt.Errorf("unknown notation %q, expected sharp or flat", s)
+}
+
+var (
+	sharpNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	flatNames  = [12]string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+
+	naturals = map[byte]int{'C': 0, 'D': 2, 'E': 4, 'F': 5, 'G': 7, 'A': 9, 'B': 11}
+
+	// flatKeys are the keys whose signatures are written with flats.
+	flatKeys = map[string]bool{
+		"F": true, "Bb": true, "Eb": true, "Ab": true, "Db": true, "Gb": true,
+		"Dm": true, "Gm": true, "Cm": true, "Fm": true, "Bbm": true, "Ebm": true,
+	}
+)
+
+// Chord is a chord symbol split into root, quality and an optional bass note.
+type Chord struct {
+	Root    int
+	Quality string
+	Bass    int
+	HasBass bool
+	Flat    bool
+}
+
+// ParseChord reads symbols like "C", "F#m7", "Bbmaj7/D" or "Asus4". It fails on anything
+// that does not start with a note name, such as "N.C.".
+func ParseChord(s string) (Chord, error) {
+	root, rest, flat, ok := parseNote(s)
+	if !ok {
+		return Chord{}, fmt.Errorf("invalid chord %q", s)
+	}
+
+	c := Chord{Root: root, Quality: rest, Flat: flat}
+	if i := strings.LastIndexByte(rest, '/'); i >= 0 {
+		bass, tail, bassFlat, ok := parseNote(rest[i+1:])
+		if ok && tail == "" {
+			c.Quality = rest[:i]
+			c.Bass = bass
+			c.HasBass = true
+			c.Flat = c.Flat || bassFlat
+		}
+	}
+
+	return c, nil
+}
+
+func parseNote(s string) (note int, rest string, flat, ok bool) {
+	if s == "" {
+		return 0, "", false, false
+	}
+
+	n, ok := naturals[s[0]]
+	if !ok {
+		return 0, "", false, false
+	}
+
+	rest = s[1:]
+	switch {
+	case strings.HasPrefix(rest, "#"):
+		n++
+		rest = rest[1:]
+	case strings.HasPrefix(rest, "b"):
+		n--
+		flat = true
+		rest = rest[1:]
+	}
+
+	return (n + 12) % 12, rest, flat, true
+}
+
+// Transpose moves the chord by the given number of semitones.
+func (c Chord) Transpose(semitones int) Chord {
+	c.Root = shift(c.Root, semitones)
+	if c.HasBass {
+		c.Bass = shift(c.Bass, semitones)
+	}
+	return c
+}
+
+func shift(note, semitones int) int {
+	return ((note+semitones)%12 + 12) % 12
+}
+
+// Format spells the chord with sharps or flats; NotationAuto keeps the chord's own spelling.
+func (c Chord) Format(n Notation) string {
+	names := sharpNames
+	if n == NotationFlat || (n == NotationAuto && c.Flat) {
+		names = flatNames
+	}
+
+	s := names[c.Root] + c.Quality
+	if c.HasBass {
+		s += "/" + names[c.Bass]
+	}
+	return s
+}
+
+func (c Chord) String() string {
+	return c.Format(NotationAuto)
+}
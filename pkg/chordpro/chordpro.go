@@ -0,0 +1,427 @@
+package chordpro
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+type Kind int
+
+const (
+	KindLyrics Kind = iota
+	KindEmpty
+	KindComment
+	KindDirective
+	KindSectionStart
+	KindSectionEnd
+	KindChorusRepeat
+	KindTab
+)
+
+// Line is one source line. Lyrics lines hold their text as segments, each starting at a chord.
+type Line struct {
+	Kind     Kind
+	Segments []Segment
+	// Name and Value hold a directive's name and argument. For section lines Name is the
+	// section (chorus, verse, bridge, tab) and Value its optional label.
+	Name  string
+	Value string
+	// Text is the verbatim content of comments and tab lines.
+	Text string
+}
+
+// Segment is lyric text preceded by an optional chord.
+type Segment struct {
+	Chord string
+	Lyric string
+}
+
+// Song is a parsed ChordPro document.
+type Song struct {
+	Meta  map[string]string
+	Lines []Line
+}
+
+// ParseError reports the first malformed line of a document.
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+var aliases = map[string]string{
+	"t":  "title",
+	"st": "subtitle",
+	"c":  "comment",
+	"ci": "comment",
+	"cb": "comment",
+
+	"comment_italic": "comment",
+	"comment_box":    "comment",
+
+	"soc": "start_of_chorus",
+	"eoc": "end_of_chorus",
+	"sov": "start_of_verse",
+	"eov": "end_of_verse",
+	"sob": "start_of_bridge",
+	"eob": "end_of_bridge",
+	"sot": "start_of_tab",
+	"eot": "end_of_tab",
+}
+
+var metaDirectives = map[string]bool{
+	"title": true, "subtitle": true, "artist": true, "composer": true, "lyricist": true,
+	"album": true, "year": true, "key": true, "capo": true, "tempo": true, "time": true,
+	"duration": true, "copyright": true,
+}
+
+// Parse reads a ChordPro document.
+func Parse(r io.Reader) (*Song, error) {
+	song := &Song{Meta: map[string]string{}}
+	section := ""
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	n := 0
+	for sc.Scan() {
+		n++
+		raw := strings.TrimRight(sc.Text(), " \t\r")
+		if n == 1 {
+			raw = strings.TrimPrefix(raw, "\ufeff")
+		}
+		trimmed := strings.TrimSpace(raw)
+
+		if strings.HasPrefix(trimmed, "{") {
+			if !strings.HasSuffix(trimmed, "}") {
+				return nil, &ParseError{Line: n, Msg: "unclosed directive"}
+			}
+
+			line := directive(trimmed[1 : len(trimmed)-1])
+			switch {
+			case strings.HasPrefix(line.Name, "start_of_"):
+				if section != "" {
+					return nil, &ParseError{Line: n, Msg: fmt.Sprintf("%s inside %s", line.Name, section)}
+				}
+				section = strings.TrimPrefix(line.Name, "start_of_")
+				line.Kind, line.Name = KindSectionStart, section
+			case strings.HasPrefix(line.Name, "end_of_"):
+				name := strings.TrimPrefix(line.Name, "end_of_")
+				if name != section {
+					return nil, &ParseError{Line: n, Msg: fmt.Sprintf("end_of_%s without start_of_%s", name, name)}
+				}
+				section = ""
+				line.Kind, line.Name = KindSectionEnd, name
+			case line.Name == "chorus":
+				line.Kind = KindChorusRepeat
+			case line.Name == "comment":
+				line.Kind, line.Text = KindComment, line.Value
+			case metaDirectives[line.Name]:
+				song.Meta[line.Name] = line.Value
+			}
+
+			song.Lines = append(song.Lines, line)
+			continue
+		}
+
+		if section == "tab" {
+			song.Lines = append(song.Lines, Line{Kind: KindTab, Text: raw})
+			continue
+		}
+		if strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if trimmed == "" {
+			song.Lines = append(song.Lines, Line{Kind: KindEmpty})
+			continue
+		}
+
+		segments, err := parseSegments(raw)
+		if err != nil {
+			return nil, &ParseError{Line: n, Msg: err.Error()}
+		}
+		song.Lines = append(song.Lines, Line{Kind: KindLyrics, Segments: segments})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if section != "" {
+		return nil, &ParseError{Line: n, Msg: fmt.Sprintf("start_of_%s is never closed", section)}
+	}
+
+	return song, nil
+}
+
+func directive(s string) Line {
+	name, value, found := strings.Cut(s, ":")
+	if !found {
+		name, value, _ = strings.Cut(s, " ")
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if full, ok := aliases[name]; ok {
+		name = full
+	}
+
+	return Line{Kind: KindDirective, Name: name, Value: strings.TrimSpace(value)}
+}
+
+func parseSegments(s string) ([]Segment, error) {
+	var segments []Segment
+	current := Segment{}
+
+	for {
+		open := strings.IndexByte(s, '[')
+		if open < 0 {
+			current.Lyric += s
+			break
+		}
+
+		end := strings.IndexByte(s[open:], ']')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed chord")
+		}
+
+		current.Lyric += s[:open]
+		if current.Chord != "" || current.Lyric != "" {
+			segments = append(segments, current)
+		}
+		current = Segment{Chord: strings.TrimSpace(s[open+1 : open+end])}
+		s = s[open+end+1:]
+	}
+
+	return append(segments, current), nil
+}
+
+// Lyrics returns the lyric text without chords, comments or tabs. Sections become
+// stanzas separated by blank lines, and {chorus} repeats the last chorus.
+func (s *Song) Lyrics() string {
+	var out []string
+	var chorus, current []string
+	inChorus := false
+
+	blank := func() {
+		if len(out) > 0 && out[len(out)-1] != "" {
+			out = append(out, "")
+		}
+	}
+
+	for _, line := range s.Lines {
+		switch line.Kind {
+		case KindLyrics:
+			text := strings.TrimSpace(line.lyric())
+			out = append(out, text)
+			if inChorus {
+				current = append(current, text)
+			}
+		case KindEmpty:
+			blank()
+		case KindSectionStart:
+			blank()
+			if line.Name == "chorus" {
+				inChorus, current = true, nil
+			}
+		case KindSectionEnd:
+			blank()
+			if line.Name == "chorus" {
+				inChorus, chorus = false, current
+			}
+		case KindChorusRepeat:
+			blank()
+			out = append(out, chorus...)
+			blank()
+		}
+	}
+
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+func (l Line) lyric() string {
+	var b strings.Builder
+	for _, seg := range l.Segments {
+		b.WriteString(seg.Lyric)
+	}
+	return b.String()
+}
+
+// Render writes the song as plain text with every chord above the syllable it belongs to.
+func (s *Song) Render(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	header := false
+	for _, key := range []string{"title", "subtitle", "artist"} {
+		if v := s.Meta[key]; v != "" {
+			fmt.Fprintln(bw, v)
+			header = true
+		}
+	}
+	var details []string
+	for _, key := range []string{"key", "capo", "tempo"} {
+		if v := s.Meta[key]; v != "" {
+			details = append(details, strings.ToUpper(key[:1])+key[1:]+": "+v)
+		}
+	}
+	if len(details) > 0 {
+		fmt.Fprintln(bw, strings.Join(details, "  "))
+		header = true
+	}
+	if header {
+		fmt.Fprintln(bw)
+	}
+
+	for _, line := range s.Lines {
+		switch line.Kind {
+		case KindLyrics:
+			chords, lyric := line.Columns()
+			if chords != "" {
+				fmt.Fprintln(bw, chords)
+			}
+			if lyric != "" || chords == "" {
+				fmt.Fprintln(bw, lyric)
+			}
+		case KindEmpty:
+			fmt.Fprintln(bw)
+		case KindComment, KindTab:
+			fmt.Fprintln(bw, line.Text)
+		case KindSectionStart:
+			if line.Name != "tab" {
+				fmt.Fprintln(bw, line.Label()+":")
+			}
+		case KindChorusRepeat:
+			fmt.Fprintf(bw, "(%s)\n", line.Label())
+		}
+	}
+
+	return bw.Flush()
+}
+
+// Label names a section or chorus repeat: its label if it has one, else the capitalised
+// section name.
+func (l Line) Label() string {
+	if l.Value != "" {
+		return l.Value
+	}
+
+	name := l.Name
+	if name == "" {
+		name = "chorus"
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// Columns lays out the chord line above the lyric line, padding the lyric where chords
+// are wider than the text under them.
+func (l Line) Columns() (string, string) {
+	var chords, lyric strings.Builder
+	chordLen, lyricLen := 0, 0
+
+	for _, seg := range l.Segments {
+		if seg.Chord != "" {
+			pos := lyricLen
+			if chordLen > 0 && chordLen+1 > pos {
+				pos = chordLen + 1
+			}
+			for ; lyricLen < pos; lyricLen++ {
+				lyric.WriteByte(' ')
+			}
+			for ; chordLen < pos; chordLen++ {
+				chords.WriteByte(' ')
+			}
+			chords.WriteString(seg.Chord)
+			chordLen += utf8.RuneCountInString(seg.Chord)
+		}
+
+		lyric.WriteString(seg.Lyric)
+		lyricLen += utf8.RuneCountInString(seg.Lyric)
+	}
+
+	return chords.String(), strings.TrimRight(lyric.String(), " ")
+}
+
+// Transpose moves every chord and the key by the given number of semitones. Chords that
+// cannot be parsed, such as N.C., are left as they are.
+func (s *Song) Transpose(semitones int, n Notation) {
+	if n == NotationAuto {
+		if key, err := ParseChord(s.Meta["key"]); err == nil {
+			key = key.Transpose(semitones)
+			n = NotationSharp
+			if flatKeys[key.Format(NotationFlat)] {
+				n = NotationFlat
+			}
+		}
+	}
+
+	transpose := func(symbol string) string {
+		c, err := ParseChord(symbol)
+		if err != nil {
+			return symbol
+		}
+		return c.Transpose(semitones).Format(n)
+	}
+
+	for i, line := range s.Lines {
+		switch {
+		case line.Kind == KindLyrics:
+			for j, seg := range line.Segments {
+				if seg.Chord != "" {
+					s.Lines[i].Segments[j].Chord = transpose(seg.Chord)
+				}
+			}
+		case line.Kind == KindDirective && line.Name == "key":
+			s.Lines[i].Value = transpose(line.Value)
+		}
+	}
+	if key, ok := s.Meta["key"]; ok {
+		s.Meta["key"] = transpose(key)
+	}
+}
+
+// Format writes the song back as ChordPro.
+func (s *Song) Format(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, line := range s.Lines {
+		switch line.Kind {
+		case KindLyrics:
+			for _, seg := range line.Segments {
+				if seg.Chord != "" {
+					bw.WriteString("[" + seg.Chord + "]")
+				}
+				bw.WriteString(seg.Lyric)
+			}
+		case KindComment:
+			bw.WriteString(formatDirective("comment", line.Text))
+		case KindSectionStart:
+			bw.WriteString(formatDirective("start_of_"+line.Name, line.Value))
+		case KindSectionEnd:
+			bw.WriteString(formatDirective("end_of_"+line.Name, ""))
+		case KindChorusRepeat, KindDirective:
+			bw.WriteString(formatDirective(line.Name, line.Value))
+		case KindTab:
+			bw.WriteString(line.Text)
+		}
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+func formatDirective(name, value string) string {
+	if value == "" {
+		return "{" + name + "}"
+	}
+	return "{" + name + ": " + value + "}"
+}
+
+// String returns the song as Format writes it.
+func (s *Song) String() string {
+	var b strings.Builder
+	s.Format(&b)
+	return b.String()
+}
@@ -0,0 +1,156 @@
+package chordpro_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"songs/pkg/chordpro"
+)
+
+const doc = `{title: Swing Low}
+{artist: Traditional}
+{key: G}
+# an ignored comment
+
+{start_of_verse}
+[G]Swing low, sweet [C]chari[G]ot
+Comin' for to carry me [D7]home
+{end_of_verse}
+
+{start_of_chorus: Refrain}
+I [G]looked over Jordan and [C]what did I [G]see
+{end_of_chorus}
+{c: slower}
+{chorus}
+
+{start_of_tab}
+e|---0---|
+{end_of_tab}
+[N.C.]
+`
+
+func parse(t *testing.T, s string) *chordpro.Song {
+	t.Helper()
+
+	song, err := chordpro.Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return song
+}
+
+func TestParseMeta(t *testing.T) {
+	song := parse(t, doc)
+
+	if song.Meta["title"] != "Swing Low" || song.Meta["artist"] != "Traditional" || song.Meta["key"] != "G" {
+		t.Errorf("unexpected meta %v", song.Meta)
+	}
+}
+
+func TestLyrics(t *testing.T) {
+	song := parse(t, doc)
+
+	expected := `Swing low, sweet chariot
+Comin' for to carry me home
+
+I looked over Jordan and what did I see
+
+I looked over Jordan and what did I see`
+	if got := song.Lyrics(); got != expected {
+		t.Errorf("unexpected lyrics:\n%s", got)
+	}
+}
+
+func TestRender(t *testing.T) {
+	song := parse(t, "{title: Test}\n[G]Swing low, sweet [C]chari[G]ot\n[Am][D7]Home\n[Em]\n")
+
+	expected := `Test
+
+G                C    G
+Swing low, sweet chariot
+Am D7
+   Home
+Em
+`
+	var b strings.Builder
+	if err := song.Render(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.String() != expected {
+		t.Errorf("unexpected rendering:\n%q", b.String())
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		semitones int
+		notation  chordpro.Notation
+		expected  string
+	}{
+		{"sharps", "[C]a [F#m7]b [Bb/D]c", 2, chordpro.NotationSharp, "[D]a [G#m7]b [C/E]c\n"},
+		{"flats", "[C]a [F#m7]b [A7sus4]c", 1, chordpro.NotationFlat, "[Db]a [Gm7]b [Bb7sus4]c\n"},
+		{"down", "[C]a [E/G#]b", -3, chordpro.NotationSharp, "[A]a [C#/F]b\n"},
+		{"auto follows the key", "{key: C}\n[C]a [A#]b", 3, chordpro.NotationAuto, "{key: Eb}\n[Eb]a [Db]b\n"},
+		{"auto keeps spelling", "[Bb]a [C#]b", 2, chordpro.NotationAuto, "[C]a [D#]b\n"},
+		{"unknown chords stay", "[N.C.]a [G]b", 12, chordpro.NotationSharp, "[N.C.]a [G]b\n"},
+	}
+
+	for _, tt := range tests {
+		song := parse(t, tt.input)
+		song.Transpose(tt.semitones, tt.notation)
+
+		if got := song.String(); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	song := parse(t, doc)
+	again := parse(t, song.String())
+
+	if again.String() != song.String() {
+		t.Errorf("formatting is not stable:\n%s\n---\n%s", song.String(), again.String())
+	}
+	if again.Lyrics() != song.Lyrics() {
+		t.Error("lyrics changed after a round trip")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+	}{
+		{"[G]ok\n[C unclosed", 2},
+		{"{title: x", 1},
+		{"{soc}\n{sov}", 2},
+		{"{eoc}", 1},
+		{"{soc}\nline", 2},
+	}
+
+	for _, tt := range tests {
+		_, err := chordpro.Parse(strings.NewReader(tt.input))
+
+		var pErr *chordpro.ParseError
+		if !errors.As(err, &pErr) {
+			t.Errorf("%q: expected a parse error, got %v", tt.input, err)
+			continue
+		}
+		if pErr.Line != tt.line {
+			t.Errorf("%q: expected line %d, got %d", tt.input, tt.line, pErr.Line)
+		}
+	}
+}
+
+func TestParseNotation(t *testing.T) {
+	if n, err := chordpro.ParseNotation("flat"); err != nil || n != chordpro.NotationFlat {
+		t.Errorf("unexpected %v, %v", n, err)
+	}
+	if _, err := chordpro.ParseNotation("solfege"); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,196 @@
+// Package langdetect guesses the language of a text offline, by comparing the text's most
+// frequent character n-grams with profiles built from small bundled samples of Russian,
+// Ukrainian, English, German, French and Spanish (Cavnar and Trenkle's out-of-place
+// measure). The texts are lyrics, so a few lines are usually enough.
+package langdetect
+
+import (
+	"embed"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Languages lists the languages Detect can return, as BCP-47 tags.
+var Languages = []string{"de", "en", "es", "fr", "ru", "uk"}
+
+// MinLetters is how many letters a text needs before Detect guesses its language.
+const MinLetters = 20
+
+const (
+	// profileSize is how many of the most frequent n-grams make up a profile.
+	profileSize = 300
+	maxN        = 3
+)
+
+//go:embed corpus/*.txt
+var corpus embed.FS
+
+// Detector compares texts with one n-gram profile per language.
+type Detector struct {
+	profiles map[string]profile
+}
+
+// profile ranks n-grams from the most frequent, 0, down.
+type profile map[string]int
+
+var (
+	defaultDetector *Detector
+	defaultOnce     sync.Once
+)
+
+// Detect returns the language of text with the bundled profiles, or "" when the text is
+// too short or written in a script none of the languages uses.
+func Detect(text string) string {
+	defaultOnce.Do(func() {
+		defaultDetector = New(bundledSamples())
+	})
+
+	return defaultDetector.Detect(text)
+}
+
+// New builds a detector from sample texts keyed by language tag.
+func New(samples map[string]string) *Detector {
+	d := &Detector{profiles: make(map[string]profile, len(samples))}
+	for lang, text := range samples {
+		d.profiles[lang] = newProfile(text)
+	}
+
+	return d
+}
+
+// Detect returns the language whose profile is closest to the text's, or "" when the text
+// has fewer than MinLetters letters or is mostly in neither Latin nor Cyrillic script.
+func (d *Detector) Detect(text string) string {
+	letters, latin, cyrillic := countLetters(text)
+	if letters < MinLetters || (latin*2 <= letters && cyrillic*2 <= letters) {
+		return ""
+	}
+
+	doc := newProfile(text)
+	best, bestDistance := "", -1
+	for lang, p := range d.profiles {
+		if isCyrillic(p) != (cyrillic*2 > letters) {
+			continue
+		}
+
+		distance := outOfPlace(doc, p)
+		if bestDistance < 0 || distance < bestDistance || (distance == bestDistance && lang < best) {
+			best, bestDistance = lang, distance
+		}
+	}
+
+	return best
+}
+
+func bundledSamples() map[string]string {
+	samples := make(map[string]string, len(Languages))
+	for _, lang := range Languages {
+		b, err := corpus.ReadFile(path.Join("corpus", lang+".txt"))
+		if err != nil {
+			panic(err)
+		}
+		samples[lang] = string(b)
+	}
+	return samples
+}
+
+// newProfile ranks the text's 1- to 3-grams by frequency. Words are lower-cased and padded
+// with spaces, so n-grams at word edges count separately.
+func newProfile(text string) profile {
+	counts := map[string]int{}
+	for _, word := range words(text) {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= maxN; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				gram := string(runes[i : i+n])
+				if gram != " " {
+					counts[gram]++
+				}
+			}
+		}
+	}
+
+	grams := make([]string, 0, len(counts))
+	for g := range counts {
+		grams = append(grams, g)
+	}
+	sort.Slice(grams, func(i, j int) bool {
+		if counts[grams[i]] != counts[grams[j]] {
+			return counts[grams[i]] > counts[grams[j]]
+		}
+		return grams[i] < grams[j]
+	})
+	if len(grams) > profileSize {
+		grams = grams[:profileSize]
+	}
+
+	p := make(profile, len(grams))
+	for rank, g := range grams {
+		p[g] = rank
+	}
+	return p
+}
+
+// outOfPlace sums how far each of the document's n-grams is from its rank in the
+// language profile; n-grams missing from the profile cost the most.
+func outOfPlace(doc, lang profile) int {
+	distance := 0
+	for gram, rank := range doc {
+		if r, ok := lang[gram]; ok {
+			distance += abs(rank - r)
+		} else {
+			distance += profileSize
+		}
+	}
+	return distance
+}
+
+// words splits text into lower-cased words of letters; apostrophes inside words, common
+// in Ukrainian and French, are kept.
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\'' && r != '’'
+	})
+}
+
+// countLetters counts the letters in text and how many of them are Latin or Cyrillic.
+func countLetters(text string) (letters, latin, cyrillic int) {
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		}
+	}
+	return letters, latin, cyrillic
+}
+
+// isCyrillic reports whether the profile's language is written in Cyrillic, judging by
+// its most frequent n-gram.
+func isCyrillic(p profile) bool {
+	for gram, rank := range p {
+		if rank == 0 {
+			for _, r := range gram {
+				if unicode.IsLetter(r) {
+					return unicode.Is(unicode.Cyrillic, r)
+				}
+			}
+		}
+	}
+	return false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,37 @@
+package langdetect_test
+
+import (
+	"testing"
+
+	"songs/pkg/langdetect"
+	testUtil "songs/util/test"
+)
+
+func TestDetect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "russian", text: "Тёплое место, но улицы ждут отпечатков наших ног.\nЗвёздная пыль на сапогах.", expected: "ru"},
+		{name: "ukrainian", text: "Ніч яка місячна, зоряна, ясная, видно, хоч голки збирай.\nВийди, коханая, працею зморена.", expected: "uk"},
+		{name: "english", text: "Yesterday all my troubles seemed so far away,\nnow it looks as though they're here to stay.", expected: "en"},
+		{name: "german", text: "Ich weiß nicht, was soll es bedeuten, dass ich so traurig bin.\nEin Märchen aus alten Zeiten.", expected: "de"},
+		{name: "french", text: "Non, je ne regrette rien, ni le bien qu'on m'a fait, ni le mal,\ntout ça m'est bien égal.", expected: "fr"},
+		{name: "spanish", text: "Bésame, bésame mucho, como si fuera esta noche la última vez.\nQue tengo miedo a perderte.", expected: "es"},
+		{name: "too short", text: "Hey, ho!", expected: ""},
+		{name: "unknown script", text: "東京の夜は長くて、星が見えない空の下で歌を歌う", expected: ""},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			testUtil.Equal(t, tc.expected, langdetect.Detect(tc.text))
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package lrc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tagOrder is the order metadata tags are written in; other tags follow alphabetically.
+var tagOrder = []string{"ti", "ar", "al", "au", "by", "length", "re", "ve"}
+
+var (
+	timeTag = regexp.MustCompile(`^(\d+):(\d{1,2})(?:[.:](\d{1,3}))?$`)
+	metaTag = regexp.MustCompile(`^([A-Za-z#]+):(.*)$`)
+	wordTag = regexp.MustCompile(`<([^<>]*)>`)
+)
+
+// Lyrics is a parsed LRC document. Timestamps already include the [offset:] tag,
+// which is therefore never kept in Tags.
+type Lyrics struct {
+	Tags  map[string]string
+	Lines []Line
+}
+
+// Line is a lyric line shown from Start. Words is set for enhanced LRC, where every
+// word carries its own <mm:ss.xx> timestamp.
+type Line struct {
+	Start time.Duration
+	Text  string
+	Words []Word
+}
+
+type Word struct {
+	Start time.Duration
+	Text  string
+}
+
+// ParseError reports the first malformed line of a document.
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Parse reads an LRC document. Lines with several timestamps are repeated at each of
+// them, and the result is ordered by time.
+func Parse(r io.Reader) (*Lyrics, error) {
+	lyrics := &Lyrics{Tags: map[string]string{}}
+	var offset time.Duration
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for n := 1; sc.Scan(); n++ {
+		raw := strings.TrimSpace(strings.TrimPrefix(sc.Text(), "\ufeff"))
+		if raw == "" {
+			continue
+		}
+		if !strings.HasPrefix(raw, "[") {
+			return nil, &ParseError{Line: n, Msg: "missing timestamp"}
+		}
+
+		var starts []time.Duration
+		rest := raw
+		for strings.HasPrefix(rest, "[") {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, &ParseError{Line: n, Msg: "unclosed tag"}
+			}
+			tag := rest[1:end]
+
+			if d, ok := parseTimestamp(tag); ok {
+				starts = append(starts, d)
+				rest = rest[end+1:]
+				continue
+			}
+			if len(starts) > 0 {
+				// A bracket after the timestamps belongs to the lyric text.
+				break
+			}
+
+			m := metaTag.FindStringSubmatch(tag)
+			if m == nil {
+				return nil, &ParseError{Line: n, Msg: fmt.Sprintf("invalid tag [%s]", tag)}
+			}
+			key, value := strings.ToLower(m[1]), strings.TrimSpace(m[2])
+			if key == "offset" {
+				ms, err := strconv.Atoi(strings.TrimPrefix(value, "+"))
+				if err != nil {
+					return nil, &ParseError{Line: n, Msg: fmt.Sprintf("invalid offset %q", value)}
+				}
+				offset = time.Duration(ms) * time.Millisecond
+			} else {
+				lyrics.Tags[key] = value
+			}
+			rest = rest[end+1:]
+		}
+
+		if len(starts) == 0 {
+			if strings.TrimSpace(rest) != "" {
+				return nil, &ParseError{Line: n, Msg: "missing timestamp"}
+			}
+			continue
+		}
+
+		text, words, err := parseWords(rest)
+		if err != nil {
+			return nil, &ParseError{Line: n, Msg: err.Error()}
+		}
+		for _, start := range starts {
+			lyrics.Lines = append(lyrics.Lines, Line{Start: start, Text: text, Words: words})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	// A positive offset shows the lyrics earlier.
+	if offset != 0 {
+		for i := range lyrics.Lines {
+			lyrics.Lines[i] = lyrics.Lines[i].shift(-offset)
+		}
+	}
+	sort.SliceStable(lyrics.Lines, func(i, j int) bool {
+		return lyrics.Lines[i].Start < lyrics.Lines[j].Start
+	})
+
+	return lyrics, nil
+}
+
+// parseWords splits enhanced word timestamps out of a line's text.
+func parseWords(s string) (string, []Word, error) {
+	tags := wordTag.FindAllStringSubmatchIndex(s, -1)
+	if tags == nil {
+		return strings.TrimSpace(s), nil, nil
+	}
+
+	var words []Word
+	var texts []string
+	if lead := strings.TrimSpace(s[:tags[0][0]]); lead != "" {
+		texts = append(texts, lead)
+	}
+
+	for i, t := range tags {
+		d, ok := parseTimestamp(s[t[2]:t[3]])
+		if !ok {
+			return "", nil, fmt.Errorf("invalid word timestamp <%s>", s[t[2]:t[3]])
+		}
+
+		end := len(s)
+		if i+1 < len(tags) {
+			end = tags[i+1][0]
+		}
+		text := strings.TrimSpace(s[t[1]:end])
+
+		words = append(words, Word{Start: d, Text: text})
+		if text != "" {
+			texts = append(texts, text)
+		}
+	}
+
+	return strings.Join(texts, " "), words, nil
+}
+
+func parseTimestamp(s string) (time.Duration, bool) {
+	m := timeTag.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, false
+	}
+
+	minutes, _ := strconv.Atoi(m[1])
+	seconds, _ := strconv.Atoi(m[2])
+	if seconds > 59 {
+		return 0, false
+	}
+
+	d := time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	if m[3] != "" {
+		frac, _ := strconv.Atoi(m[3])
+		for i := len(m[3]); i < 3; i++ {
+			frac *= 10
+		}
+		d += time.Duration(frac) * time.Millisecond
+	}
+
+	return d, true
+}
+
+// ParseTimestamp reads a playback position written as mm:ss.xx.
+func ParseTimestamp(s string) (time.Duration, error) {
+	d, ok := parseTimestamp(s)
+	if !ok {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	return d, nil
+}
+
+// FormatTimestamp writes d as mm:ss.xx, the precision of standard LRC.
+func FormatTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := d / (10 * time.Millisecond)
+	return fmt.Sprintf("%02d:%02d.%02d", cs/6000, cs/100%60, cs%100)
+}
+
+func (l Line) shift(by time.Duration) Line {
+	l.Start = clamp(l.Start + by)
+	if l.Words != nil {
+		words := make([]Word, len(l.Words))
+		for i, w := range l.Words {
+			words[i] = Word{Start: clamp(w.Start + by), Text: w.Text}
+		}
+		l.Words = words
+	}
+	return l
+}
+
+func clamp(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// String renders the line in LRC, with word timestamps when it has them.
+func (l Line) String() string {
+	var b strings.Builder
+	b.WriteString("[" + FormatTimestamp(l.Start) + "]")
+
+	if len(l.Words) == 0 {
+		b.WriteString(l.Text)
+		return b.String()
+	}
+	for i, w := range l.Words {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString("<" + FormatTimestamp(w.Start) + ">" + w.Text)
+	}
+	return b.String()
+}
+
+// Format writes the lyrics as an LRC document: metadata tags first, then one line per timestamp.
+func (l *Lyrics) Format(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	written := map[string]bool{}
+	for _, key := range tagOrder {
+		if value, ok := l.Tags[key]; ok {
+			fmt.Fprintf(bw, "[%s:%s]\n", key, value)
+			written[key] = true
+		}
+	}
+	var rest []string
+	for key := range l.Tags {
+		if !written[key] {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+	for _, key := range rest {
+		fmt.Fprintf(bw, "[%s:%s]\n", key, l.Tags[key])
+	}
+
+	for _, line := range l.Lines {
+		bw.WriteString(line.String())
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+// String returns the document as Format writes it.
+func (l *Lyrics) String() string {
+	var b strings.Builder
+	l.Format(&b)
+	return b.String()
+}
+
+// Active returns the index of the line shown at the playback position, or -1 before the first line.
+func (l *Lyrics) Active(at time.Duration) int {
+	return sort.Search(len(l.Lines), func(i int) bool {
+		return l.Lines[i].Start > at
+	}) - 1
+}
+
+// ActiveWord returns the index of the word sung at the playback position, or -1 when
+// the line has no word timestamps or none has started yet.
+func (l Line) ActiveWord(at time.Duration) int {
+	return sort.Search(len(l.Words), func(i int) bool {
+		return l.Words[i].Start > at
+	}) - 1
+}
@@ -0,0 +1,148 @@
+package lrc_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"songs/pkg/lrc"
+)
+
+const doc = `[ar:Muse]
+[ti:Starlight]
+[offset:+500]
+
+[00:12.50]Far away
+[00:10.00][00:40.00]This ship is taking me far away
+[00:20.00]<00:20.00>Will <00:20.40>you <00:20.90>remember <00:21.80>
+[00:30.123][Chorus] Our hopes and expectations
+`
+
+func TestParse(t *testing.T) {
+	l, err := lrc.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if l.Tags["ar"] != "Muse" || l.Tags["ti"] != "Starlight" {
+		t.Errorf("unexpected tags %v", l.Tags)
+	}
+	if _, ok := l.Tags["offset"]; ok {
+		t.Error("offset must be applied, not kept")
+	}
+
+	want := []struct {
+		start time.Duration
+		text  string
+	}{
+		{9500 * time.Millisecond, "This ship is taking me far away"},
+		{12 * time.Second, "Far away"},
+		{19500 * time.Millisecond, "Will you remember"},
+		{29623 * time.Millisecond, "[Chorus] Our hopes and expectations"},
+		{39500 * time.Millisecond, "This ship is taking me far away"},
+	}
+	if len(l.Lines) != len(want) {
+		t.Fatalf("expected %d lines, got %d", len(want), len(l.Lines))
+	}
+	for i, w := range want {
+		if l.Lines[i].Start != w.start || l.Lines[i].Text != w.text {
+			t.Errorf("line %d: got %s %q, want %s %q", i, l.Lines[i].Start, l.Lines[i].Text, w.start, w.text)
+		}
+	}
+
+	words := l.Lines[2].Words
+	if len(words) != 4 || words[1].Text != "you" || words[1].Start != 19900*time.Millisecond || words[3].Text != "" {
+		t.Errorf("unexpected words %+v", words)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+	}{
+		{"[00:01.00]ok\nno timestamp here", 2},
+		{"[00:61.00]bad seconds", 1},
+		{"[00:01.00]<0x:01>word", 1},
+		{"[00:01.00\nunclosed", 1},
+		{"[offset:soon]", 1},
+	}
+
+	for _, tt := range tests {
+		_, err := lrc.Parse(strings.NewReader(tt.input))
+
+		var pErr *lrc.ParseError
+		if !errors.As(err, &pErr) {
+			t.Errorf("%q: expected a parse error, got %v", tt.input, err)
+			continue
+		}
+		if pErr.Line != tt.line {
+			t.Errorf("%q: expected line %d, got %d", tt.input, tt.line, pErr.Line)
+		}
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	l, err := lrc.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := l.String()
+	expected := `[ti:Starlight]
+[ar:Muse]
+[00:09.50]This ship is taking me far away
+[00:12.00]Far away
+[00:19.50]<00:19.50>Will <00:19.90>you <00:20.40>remember <00:21.30>
+[00:29.62][Chorus] Our hopes and expectations
+[00:39.50]This ship is taking me far away
+`
+	if out != expected {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	again, err := lrc.Parse(strings.NewReader(out))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again.String() != out {
+		t.Errorf("formatting is not stable:\n%s", again.String())
+	}
+}
+
+func TestActive(t *testing.T) {
+	l, err := lrc.Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		at   time.Duration
+		line int
+	}{
+		{0, -1},
+		{9500 * time.Millisecond, 0},
+		{15 * time.Second, 1},
+		{time.Hour, 4},
+	}
+	for _, tt := range tests {
+		if got := l.Active(tt.at); got != tt.line {
+			t.Errorf("Active(%s) = %d, want %d", tt.at, got, tt.line)
+		}
+	}
+
+	if got := l.Lines[2].ActiveWord(20 * time.Second); got != 1 {
+		t.Errorf("expected word 1, got %d", got)
+	}
+}
+
+func TestTimestamp(t *testing.T) {
+	d, err := lrc.ParseTimestamp("01:23.45")
+	if err != nil || d != 83450*time.Millisecond {
+		t.Errorf("unexpected %s, %v", d, err)
+	}
+	if s := lrc.FormatTimestamp(d); s != "01:23.45" {
+		t.Errorf("unexpected %s", s)
+	}
+}
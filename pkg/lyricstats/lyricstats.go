@@ -0,0 +1,225 @@
+// Package lyricstats counts what editors ask about lyrics: words, vocabulary, lines,
+// stanzas and how much of a song repeats. Counts add up, so an artist's stats are the sum
+// of their songs' without reading the lyrics again.
+package lyricstats
+
+import (
+	"bufio"
+	"embed"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+//go:embed stopwords/*.txt
+var stopwordFiles embed.FS
+
+var (
+	stopwords     map[string]bool
+	stopwordsOnce sync.Once
+)
+
+// Counts are the raw counts of one or more songs' lyrics.
+type Counts struct {
+	Songs   int `json:"songs"`
+	Words   int `json:"words"`
+	Lines   int `json:"lines"`
+	Stanzas int `json:"stanzas"`
+	// RepeatedLines and RepeatedStanzas count the lines and stanzas that repeat an earlier
+	// one of the same song, such as choruses, spelling aside.
+	RepeatedLines   int `json:"repeated_lines"`
+	RepeatedStanzas int `json:"repeated_stanzas"`
+	// Frequencies counts each word, lower-cased, with ё as е.
+	Frequencies map[string]int `json:"frequencies"`
+}
+
+// WordCount is a word with the number of times it occurs.
+type WordCount struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// Stats are Counts summarized for reading.
+type Stats struct {
+	Songs       int `json:"songs"`
+	Words       int `json:"words"`
+	UniqueWords int `json:"unique_words"`
+	// LexicalDiversity is the share of unique words among all words, from 0 to 1.
+	LexicalDiversity float64 `json:"lexical_diversity"`
+	Lines            int     `json:"lines"`
+	Stanzas          int     `json:"stanzas"`
+	RepeatedLines    int     `json:"repeated_lines"`
+	RepeatedStanzas  int     `json:"repeated_stanzas"`
+	// RepetitionRatio is the share of lines that repeat an earlier one, from 0 to 1.
+	RepetitionRatio float64 `json:"repetition_ratio"`
+	// TopWords are the most frequent words other than Russian and English stop words.
+	TopWords []WordCount `json:"top_words"`
+}
+
+// Count counts the lyrics of one song. Stanzas are separated by blank lines; lines that are
+// only a section label, such as "[Chorus]", are skipped.
+func Count(text string) *Counts {
+	c := &Counts{Songs: 1, Frequencies: map[string]int{}}
+
+	seenLines := map[string]bool{}
+	seenStanzas := map[string]bool{}
+	var stanza []string
+	endStanza := func() {
+		if len(stanza) == 0 {
+			return
+		}
+		c.Stanzas++
+		key := strings.Join(stanza, "\n")
+		if seenStanzas[key] {
+			c.RepeatedStanzas++
+		}
+		seenStanzas[key] = true
+		stanza = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(text)+1)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			endStanza()
+			continue
+		}
+		if label(line) {
+			continue
+		}
+
+		words := Words(line)
+		if len(words) == 0 {
+			continue
+		}
+		c.Lines++
+		c.Words += len(words)
+		for _, w := range words {
+			c.Frequencies[w]++
+		}
+
+		key := strings.Join(words, " ")
+		if seenLines[key] {
+			c.RepeatedLines++
+		}
+		seenLines[key] = true
+		stanza = append(stanza, key)
+	}
+	endStanza()
+
+	return c
+}
+
+// Add adds the counts of other songs to c.
+func (c *Counts) Add(other *Counts) {
+	c.Songs += other.Songs
+	c.Words += other.Words
+	c.Lines += other.Lines
+	c.Stanzas += other.Stanzas
+	c.RepeatedLines += other.RepeatedLines
+	c.RepeatedStanzas += other.RepeatedStanzas
+	if c.Frequencies == nil {
+		c.Frequencies = map[string]int{}
+	}
+	for w, n := range other.Frequencies {
+		c.Frequencies[w] += n
+	}
+}
+
+// Stats summarizes the counts with the top most frequent words that are not stop words.
+func (c *Counts) Stats(top int) Stats {
+	s := Stats{
+		Songs:           c.Songs,
+		Words:           c.Words,
+		UniqueWords:     len(c.Frequencies),
+		Lines:           c.Lines,
+		Stanzas:         c.Stanzas,
+		RepeatedLines:   c.RepeatedLines,
+		RepeatedStanzas: c.RepeatedStanzas,
+		TopWords:        []WordCount{},
+	}
+	if c.Words > 0 {
+		s.LexicalDiversity = float64(s.UniqueWords) / float64(c.Words)
+	}
+	if c.Lines > 0 {
+		s.RepetitionRatio = float64(c.RepeatedLines) / float64(c.Lines)
+	}
+
+	for w, n := range c.Frequencies {
+		if !IsStopWord(w) {
+			s.TopWords = append(s.TopWords, WordCount{Word: w, Count: n})
+		}
+	}
+	sort.Slice(s.TopWords, func(i, j int) bool {
+		if s.TopWords[i].Count != s.TopWords[j].Count {
+			return s.TopWords[i].Count > s.TopWords[j].Count
+		}
+		return s.TopWords[i].Word < s.TopWords[j].Word
+	})
+	if len(s.TopWords) > top {
+		s.TopWords = s.TopWords[:top]
+	}
+
+	return s
+}
+
+// Words splits text into lower-case words, with ё as е. Apostrophes and hyphens inside a
+// word keep it whole: "don't", "кто-то".
+func Words(text string) []string {
+	runes := []rune(strings.ToLower(text))
+
+	var words []string
+	var word []rune
+	for i, r := range runes {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if r == 'ё' {
+				r = 'е'
+			}
+			word = append(word, r)
+			continue
+		case (r == '\'' || r == '’' || r == '-') && len(word) > 0 && i+1 < len(runes) && unicode.IsLetter(runes[i+1]):
+			if r == '’' {
+				r = '\''
+			}
+			word = append(word, r)
+			continue
+		}
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+
+	return words
+}
+
+// IsStopWord reports whether w, a word as Words returns it, is a Russian or English stop word.
+func IsStopWord(w string) bool {
+	stopwordsOnce.Do(func() {
+		stopwords = map[string]bool{}
+		files, _ := stopwordFiles.ReadDir("stopwords")
+		for _, f := range files {
+			data, err := stopwordFiles.ReadFile(path.Join("stopwords", f.Name()))
+			if err != nil {
+				panic(err)
+			}
+			for _, word := range strings.Fields(string(data)) {
+				stopwords[word] = true
+			}
+		}
+	})
+
+	return stopwords[w]
+}
+
+// label reports whether the line is only a section label in brackets.
+func label(line string) bool {
+	return strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]")
+}
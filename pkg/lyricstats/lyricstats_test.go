@@ -0,0 +1,69 @@
+package lyricstats_test
+
+import (
+	"reflect"
+	"testing"
+
+	"songs/pkg/lyricstats"
+	testUtil "songs/util/test"
+)
+
+const lyrics = `[Verse]
+Тёплое место, но улицы ждут
+отпечатков наших ног.
+
+[Chorus]
+Группа крови на рукаве,
+мой порядковый номер на рукаве.
+
+Группа крови на рукаве!
+Мой порядковый номер на рукаве.`
+
+func TestWords(t *testing.T) {
+	t.Parallel()
+
+	testUtil.Equal(t, true, reflect.DeepEqual(
+		lyricstats.Words("Don’t stop — кто-то ждёт, 'till 1999"),
+		[]string{"don't", "stop", "кто-то", "ждет", "till", "1999"},
+	))
+}
+
+func TestCount(t *testing.T) {
+	t.Parallel()
+
+	c := lyricstats.Count(lyrics)
+	testUtil.Equal(t, 1, c.Songs)
+	testUtil.Equal(t, 6, c.Lines)
+	testUtil.Equal(t, 3, c.Stanzas)
+	testUtil.Equal(t, 26, c.Words)
+	testUtil.Equal(t, 2, c.RepeatedLines)
+	testUtil.Equal(t, 1, c.RepeatedStanzas)
+	testUtil.Equal(t, 4, c.Frequencies["рукаве"])
+	testUtil.Equal(t, 1, c.Frequencies["теплое"])
+}
+
+func TestCounts_Stats(t *testing.T) {
+	t.Parallel()
+
+	c := lyricstats.Count(lyrics)
+	c.Add(lyricstats.Count("Yesterday, all my troubles seemed so far away"))
+
+	s := c.Stats(2)
+	testUtil.Equal(t, 2, s.Songs)
+	testUtil.Equal(t, 34, s.Words)
+	testUtil.Equal(t, 7, s.Lines)
+	testUtil.Equal(t, 2.0/7, s.RepetitionRatio)
+	testUtil.Equal(t, float64(s.UniqueWords)/34, s.LexicalDiversity)
+	testUtil.Equal(t, true, reflect.DeepEqual(s.TopWords, []lyricstats.WordCount{
+		{Word: "рукаве", Count: 4},
+		{Word: "группа", Count: 2},
+	}))
+}
+
+func TestIsStopWord(t *testing.T) {
+	t.Parallel()
+
+	testUtil.Equal(t, true, lyricstats.IsStopWord("the"))
+	testUtil.Equal(t, true, lyricstats.IsStopWord("еще"))
+	testUtil.Equal(t, false, lyricstats.IsStopWord("рукаве"))
+}
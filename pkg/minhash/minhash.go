@@ -0,0 +1,127 @@
+// Package minhash estimates how alike two texts are from fixed-size signatures: the
+// texts are cut into overlapping word shingles, and the share of signature slots two
+// texts agree on approximates the Jaccard similarity of their shingle sets.
+package minhash
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"strings"
+)
+
+const (
+	// Size is the number of hash functions, and of values in a signature.
+	Size = 64
+	// Bands and Rows split a signature for locality-sensitive hashing: texts sharing all
+	// rows of any band become candidates. 16 bands of 4 catch pairs from about 0.5 alike.
+	Bands = 16
+	Rows  = Size / Bands
+
+	// ShingleSize is the number of words in a shingle.
+	ShingleSize = 3
+)
+
+// Signature is the MinHash signature of a text.
+type Signature [Size]uint64
+
+// seeds gives each hash function its own permutation of the shingle hashes.
+var seeds = func() [Size]uint64 {
+	var s [Size]uint64
+	x := uint64(0x9e3779b97f4a7c15)
+	for i := range s {
+		x = mix(x + uint64(i))
+		s[i] = x
+	}
+	return s
+}()
+
+// Shingles cuts words into overlapping runs of ShingleSize words and hashes each. Fewer
+// words than that make a single shingle.
+func Shingles(words []string) map[uint64]struct{} {
+	shingles := map[uint64]struct{}{}
+	if len(words) == 0 {
+		return shingles
+	}
+
+	n := min(ShingleSize, len(words))
+	for i := 0; i+n <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+n], " ")))
+		shingles[h.Sum64()] = struct{}{}
+	}
+	return shingles
+}
+
+// New returns the signature of the text's words. A text without words gets a signature
+// of maximum values, which matches only other empty texts.
+func New(words []string) Signature {
+	var sig Signature
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
+
+	for shingle := range Shingles(words) {
+		for i, seed := range seeds {
+			if h := mix(shingle ^ seed); h < sig[i] {
+				sig[i] = h
+			}
+		}
+	}
+	return sig
+}
+
+// Similarity estimates the Jaccard similarity of the texts behind two signatures.
+func (s Signature) Similarity(other Signature) float64 {
+	same := 0
+	for i := range s {
+		if s[i] == other[i] {
+			same++
+		}
+	}
+	return float64(same) / Size
+}
+
+// BandKeys hashes each band of the signature, for finding candidates in buckets.
+func (s Signature) BandKeys() [Bands]uint64 {
+	var keys [Bands]uint64
+	for b := range keys {
+		h := uint64(b)
+		for _, v := range s[b*Rows : (b+1)*Rows] {
+			h = mix(h ^ v)
+		}
+		keys[b] = h
+	}
+	return keys
+}
+
+// MarshalBinary encodes the signature as big-endian values, for storage.
+func (s Signature) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 8*Size)
+	for i, v := range s {
+		binary.BigEndian.PutUint64(b[8*i:], v)
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a signature written by MarshalBinary.
+func (s *Signature) UnmarshalBinary(b []byte) error {
+	if len(b) != 8*Size {
+		return errors.New("minhash: signature has the wrong length")
+	}
+	for i := range s {
+		s[i] = binary.BigEndian.Uint64(b[8*i:])
+	}
+	return nil
+}
+
+// mix is the splitmix64 finalizer, a cheap hash with good avalanche.
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,56 @@
+package minhash_test
+
+import (
+	"strings"
+	"testing"
+
+	"songs/pkg/minhash"
+	testUtil "songs/util/test"
+)
+
+const verse = `группа крови на рукаве мой порядковый номер на рукаве пожелай мне удачи в бою
+пожелай мне не остаться в этой траве не остаться в этой траве пожелай мне удачи`
+
+func TestSimilarity(t *testing.T) {
+	t.Parallel()
+
+	words := strings.Fields(verse)
+	same := minhash.New(words)
+	testUtil.Equal(t, same.Similarity(minhash.New(words)), 1.0)
+
+	// Dropping the last word keeps all shingles but one.
+	near := minhash.New(words[:len(words)-1])
+	if s := same.Similarity(near); s < 0.8 {
+		t.Errorf("expected near-identical texts to be at least 0.8 alike, got %v", s)
+	}
+
+	other := minhash.New(strings.Fields("far away this ship is taking me far away far away from the memories"))
+	if s := same.Similarity(other); s > 0.1 {
+		t.Errorf("expected unrelated texts to be at most 0.1 alike, got %v", s)
+	}
+
+	shared := func(a, b minhash.Signature) int {
+		n := 0
+		for i, key := range a.BandKeys() {
+			if b.BandKeys()[i] == key {
+				n++
+			}
+		}
+		return n
+	}
+	if shared(same, near) == 0 {
+		t.Error("near-identical texts share no band")
+	}
+	if shared(same, other) > 0 {
+		t.Error("unrelated texts share a band")
+	}
+
+	b, err := same.MarshalBinary()
+	testUtil.NoError(t, err)
+	var decoded minhash.Signature
+	testUtil.NoError(t, decoded.UnmarshalBinary(b))
+	testUtil.Equal(t, decoded, same)
+	if err := decoded.UnmarshalBinary(b[1:]); err == nil {
+		t.Error("expected an error for a short signature")
+	}
+}
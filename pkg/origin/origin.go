@@ -0,0 +1,86 @@
+package origin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Matcher decides whether a browser origin is allowed. Patterns are either exact
+// origins (https://songs.example.com), origins with * wildcards that stand for one
+// host label or port (https://*.example.com, http://localhost:*), a regular
+// expression between slashes (/^https://pr-\d+\.preview\.example\.com$/), or a
+// lone * that allows every origin.
+type Matcher struct {
+	any     bool
+	exact   map[string]struct{}
+	regexps []*regexp.Regexp
+}
+
+// Compile parses the patterns and returns every invalid one in a single error.
+func Compile(patterns []string) (*Matcher, error) {
+	m := &Matcher{exact: map[string]struct{}{}}
+
+	var invalid []string
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		switch {
+		case p == "":
+			continue
+		case p == "*":
+			m.any = true
+		case len(p) > 2 && strings.HasPrefix(p, "/") && strings.HasSuffix(p, "/"):
+			re, err := regexp.Compile(p[1 : len(p)-1])
+			if err != nil {
+				invalid = append(invalid, fmt.Sprintf("%s: %v", p, err))
+				continue
+			}
+			m.regexps = append(m.regexps, re)
+		case strings.Contains(p, "*"):
+			parts := strings.Split(normalize(p), "*")
+			for i := range parts {
+				parts[i] = regexp.QuoteMeta(parts[i])
+			}
+			m.regexps = append(m.regexps, regexp.MustCompile("^"+strings.Join(parts, `[^./:]+`)+"$"))
+		default:
+			m.exact[normalize(p)] = struct{}{}
+		}
+	}
+
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid origin patterns: %s", strings.Join(invalid, "; "))
+	}
+
+	return m, nil
+}
+
+// AllowsAny reports whether the lone * pattern was given.
+func (m *Matcher) AllowsAny() bool {
+	return m.any
+}
+
+// Match reports whether origin is allowed by any of the patterns.
+func (m *Matcher) Match(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if m.any {
+		return true
+	}
+
+	origin = normalize(origin)
+	if _, ok := m.exact[origin]; ok {
+		return true
+	}
+	for _, re := range m.regexps {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func normalize(origin string) string {
+	return strings.TrimSuffix(strings.ToLower(origin), "/")
+}
@@ -0,0 +1,59 @@
+package origin_test
+
+import (
+	"testing"
+
+	"songs/pkg/origin"
+)
+
+func TestMatch(t *testing.T) {
+	m, err := origin.Compile([]string{
+		"https://songs.example.com",
+		"https://*.preview.example.com",
+		"http://localhost:*",
+		`/^https://pr-\d+\.example\.org$/`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://songs.example.com", true},
+		{"HTTPS://Songs.Example.com", true},
+		{"http://songs.example.com", false},
+		{"https://pr-12.preview.example.com", true},
+		{"https://a.b.preview.example.com", false},
+		{"https://evil.com/.preview.example.com", false},
+		{"https://preview.example.com", false},
+		{"http://localhost:3000", true},
+		{"http://localhost", false},
+		{"https://pr-7.example.org", true},
+		{"https://pr-x.example.org", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Match(tt.origin); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestMatchAny(t *testing.T) {
+	m, err := origin.Compile([]string{"*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !m.AllowsAny() || !m.Match("https://anything.example") {
+		t.Error("expected * to allow any origin")
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	if _, err := origin.Compile([]string{"/[unclosed/"}); err == nil {
+		t.Error("expected an error for an invalid regular expression")
+	}
+}
@@ -20,11 +20,11 @@ var (
 
 // Pages represents a paginated list of data items.
 type Pages struct {
-	Page       int         `json:"page"`
-	PerPage    int         `json:"per_page"`
-	PageCount  int         `json:"page_count"`
-	TotalCount int         `json:"total_count"`
-	Items      interface{} `json:"items"`
+	Page       int         `json:"page" xml:"page" yaml:"page"`
+	PerPage    int         `json:"per_page" xml:"per_page" yaml:"per_page"`
+	PageCount  int         `json:"page_count" xml:"page_count" yaml:"page_count"`
+	TotalCount int         `json:"total_count" xml:"total_count" yaml:"total_count"`
+	Items      interface{} `json:"items" xml:"items>item" yaml:"items"`
 }
 
 // New creates a new Pages instance.
@@ -0,0 +1,16 @@
+package ratelimit
+
+import "time"
+
+// SetClock replaces the clock of a store, for tests.
+func (s *MemoryStore) SetClock(now func() time.Time) { s.now = now }
+
+// SetClock replaces the clock of a store, for tests.
+func (s *PostgresStore) SetClock(now func() time.Time) { s.now = now }
+
+// Buckets returns how many buckets the store holds, for tests.
+func (s *MemoryStore) Buckets() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
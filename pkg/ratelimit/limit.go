@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit describes a token bucket that holds up to Requests tokens
+// and refills completely over Period.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// ParseLimit parses limits written as "<requests>/<period>", e.g. "100/1m".
+// A bare unit such as "100/s" is read as one unit of that period.
+func ParseLimit(s string) (Limit, error) {
+	requests, period, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: expected <requests>/<period>", s)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", s)
+	}
+
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration", s)
+	}
+
+	return Limit{Requests: n, Period: d}, nil
+}
+
+func (l Limit) String() string {
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// rate returns the refill rate in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets. Implementations must be safe for concurrent use;
+// shared implementations allow several replicas to enforce one limit together.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// newResult builds a Result from the number of tokens left in a bucket after a take.
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	rate := limit.rate()
+
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit.Requests,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     seconds((float64(limit.Requests) - tokens) / rate),
+	}
+	if !allowed {
+		res.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	return res
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s)) * time.Second
+}
+
+// UnmarshalText lets limits be decoded straight from configuration.
+func (l *Limit) UnmarshalText(text []byte) error {
+	limit, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+
+	*l = limit
+	return nil
+}
@@ -0,0 +1,59 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"songs/pkg/ratelimit"
+	testUtil "songs/util/test"
+)
+
+func TestParseLimit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		in       string
+		expected ratelimit.Limit
+		err      bool
+	}{
+		{name: "minutes", in: "100/1m", expected: ratelimit.Limit{Requests: 100, Period: time.Minute}},
+		{name: "bare unit", in: "10/s", expected: ratelimit.Limit{Requests: 10, Period: time.Second}},
+		{name: "spaces", in: " 3/2h ", expected: ratelimit.Limit{Requests: 3, Period: 2 * time.Hour}},
+		{name: "no period", in: "100", err: true},
+		{name: "zero requests", in: "0/1m", err: true},
+		{name: "bad requests", in: "x/1m", err: true},
+		{name: "zero period", in: "5/0s", err: true},
+		{name: "bad period", in: "5/week", err: true},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			limit, err := ratelimit.ParseLimit(tc.in)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error for %q", tc.in)
+				}
+				return
+			}
+			testUtil.NoError(t, err)
+			testUtil.Equal(t, tc.expected, limit)
+		})
+	}
+}
+
+func TestLimit_UnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	var limit ratelimit.Limit
+	testUtil.NoError(t, limit.UnmarshalText([]byte("60/1m")))
+	testUtil.Equal(t, "60/1m0s", limit.String())
+
+	if err := limit.UnmarshalText([]byte("60")); err == nil {
+		t.Fatal("expected an error")
+	}
+}
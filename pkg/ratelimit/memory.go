@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time
+}
+
+// MemoryStore keeps buckets in process memory. Limits are enforced per replica.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Requests), last: now}
+		s.buckets[key] = b
+	}
+
+	rate := limit.rate()
+	tokens := math.Min(float64(limit.Requests), b.tokens+now.Sub(b.last).Seconds()*rate)
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+
+	b.tokens = tokens
+	b.last = now
+	b.full = now.Add(time.Duration((float64(limit.Requests) - tokens) / rate * float64(time.Second)))
+
+	return newResult(limit, tokens, allowed), nil
+}
+
+// sweep drops buckets that have refilled completely, since a fresh bucket is equivalent.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,70 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"songs/pkg/ratelimit"
+	testUtil "songs/util/test"
+)
+
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func TestMemoryStore_Take(t *testing.T) {
+	t.Parallel()
+
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := ratelimit.NewMemoryStore()
+	store.SetClock(c.Now)
+	limit := ratelimit.Limit{Requests: 2, Period: time.Minute}
+	ctx := context.Background()
+
+	res, err := store.Take(ctx, "a", limit)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, res.Allowed)
+	testUtil.Equal(t, 1, res.Remaining)
+	testUtil.Equal(t, 30*time.Second, res.Reset)
+
+	res, _ = store.Take(ctx, "a", limit)
+	testUtil.Equal(t, true, res.Allowed)
+	testUtil.Equal(t, 0, res.Remaining)
+
+	res, _ = store.Take(ctx, "a", limit)
+	testUtil.Equal(t, false, res.Allowed)
+	testUtil.Equal(t, 30*time.Second, res.RetryAfter)
+	testUtil.Equal(t, time.Minute, res.Reset)
+
+	// Other keys have buckets of their own.
+	res, _ = store.Take(ctx, "b", limit)
+	testUtil.Equal(t, true, res.Allowed)
+
+	// A token refills every 30 seconds.
+	c.now = c.now.Add(30 * time.Second)
+	res, _ = store.Take(ctx, "a", limit)
+	testUtil.Equal(t, true, res.Allowed)
+	testUtil.Equal(t, 0, res.Remaining)
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	t.Parallel()
+
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := ratelimit.NewMemoryStore()
+	store.SetClock(c.Now)
+	ctx := context.Background()
+
+	_, _ = store.Take(ctx, "short", ratelimit.Limit{Requests: 1, Period: time.Minute})
+	_, _ = store.Take(ctx, "long", ratelimit.Limit{Requests: 1, Period: time.Hour})
+	testUtil.Equal(t, 2, store.Buckets())
+
+	// After two minutes the short bucket has refilled and is dropped; the long one is kept.
+	c.now = c.now.Add(2 * time.Minute)
+	_, _ = store.Take(ctx, "other", ratelimit.Limit{Requests: 1, Period: time.Minute})
+	testUtil.Equal(t, 2, store.Buckets())
+
+	res, _ := store.Take(ctx, "long", ratelimit.Limit{Requests: 1, Period: time.Hour})
+	testUtil.Equal(t, false, res.Allowed)
+}
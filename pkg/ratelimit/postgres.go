@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// takeQuery refills the bucket for the elapsed time and takes a token when one is available,
+// in a single atomic statement so that all replicas share the same bucket.
+const takeQuery = `
+INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES (@key, @burst - 1, TRUE, clock_timestamp())
+ON CONFLICT (key) DO UPDATE SET
+	allowed = LEAST(@burst, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at) * @rate) >= 1,
+	tokens = LEAST(@burst, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at) * @rate)
+		- CASE WHEN LEAST(@burst, b.tokens + EXTRACT(EPOCH FROM clock_timestamp() - b.updated_at) * @rate) >= 1 THEN 1 ELSE 0 END,
+	updated_at = clock_timestamp()
+RETURNING tokens, allowed`
+
+// sweepQuery deletes buckets left alone for @period: they have refilled completely, so a
+// fresh bucket is equivalent.
+const sweepQuery = `DELETE FROM rate_limit_buckets WHERE updated_at < clock_timestamp() - make_interval(secs => @period)`
+
+// PostgresStore keeps buckets in the rate_limit_buckets table, sharing limits across replicas.
+// Each replica sweeps full buckets at most once per sweepInterval.
+type PostgresStore struct {
+	db *gorm.DB
+
+	mu        sync.Mutex
+	maxPeriod time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db, now: time.Now}
+}
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	if err := s.sweep(ctx, limit); err != nil {
+		return Result{}, err
+	}
+
+	var row struct {
+		Tokens  float64
+		Allowed bool
+	}
+
+	err := s.db.WithContext(ctx).Raw(takeQuery, map[string]interface{}{
+		"key":   key,
+		"burst": float64(limit.Requests),
+		"rate":  limit.rate(),
+	}).Scan(&row).Error
+	if err != nil {
+		return Result{}, err
+	}
+
+	return newResult(limit, row.Tokens, row.Allowed), nil
+}
+
+// sweep deletes the buckets idle for the longest period of the limits taken so far, which
+// have refilled whatever their limit. A failed sweep waits for the next interval too.
+func (s *PostgresStore) sweep(ctx context.Context, limit Limit) error {
+	s.mu.Lock()
+	now := s.now()
+	s.maxPeriod = max(s.maxPeriod, limit.Period)
+	if now.Sub(s.lastSweep) < sweepInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.lastSweep = now
+	period := s.maxPeriod
+	s.mu.Unlock()
+
+	return s.db.WithContext(ctx).Exec(sweepQuery, map[string]interface{}{"period": period.Seconds()}).Error
+}
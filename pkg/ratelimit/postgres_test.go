@@ -0,0 +1,62 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	mockDB "songs/mock/db"
+	"songs/pkg/ratelimit"
+	testUtil "songs/util/test"
+)
+
+func TestPostgresStore_Take(t *testing.T) {
+	t.Parallel()
+
+	db, mock, err := mockDB.NewMockDB()
+	testUtil.NoError(t, err)
+
+	c := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := ratelimit.NewPostgresStore(db)
+	store.SetClock(c.Now)
+	ctx := context.Background()
+	read := ratelimit.Limit{Requests: 60, Period: time.Minute}
+	write := ratelimit.Limit{Requests: 10, Period: time.Hour}
+
+	// The first take sweeps the buckets idle for a whole period.
+	mock.ExpectExec(`^DELETE FROM rate_limit_buckets WHERE updated_at < clock_timestamp\(\) - make_interval\(secs => \$1\)`).
+		WithArgs(60.0).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(`INSERT INTO rate_limit_buckets AS b \(key, tokens, allowed, updated_at\)\s+VALUES \(\$1, \$2 - 1, TRUE, clock_timestamp\(\)\)\s+ON CONFLICT \(key\) DO UPDATE`).
+		WithArgs("read:1.2.3.4", 60.0, 60.0, 1.0, 60.0, 1.0, 60.0, 1.0).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(59.0, true))
+
+	res, err := store.Take(ctx, "read:1.2.3.4", read)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, true, res.Allowed)
+	testUtil.Equal(t, 59, res.Remaining)
+
+	// Within the sweep interval only the bucket is updated.
+	mock.ExpectQuery(`INSERT INTO rate_limit_buckets`).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(0.5, false))
+
+	res, err = store.Take(ctx, "write:1.2.3.4", write)
+	testUtil.NoError(t, err)
+	testUtil.Equal(t, false, res.Allowed)
+	testUtil.Equal(t, 0, res.Remaining)
+	testUtil.Equal(t, 180*time.Second, res.RetryAfter)
+
+	// The next sweep keeps buckets for the longest period taken.
+	c.now = c.now.Add(2 * time.Minute)
+	mock.ExpectExec(`^DELETE FROM rate_limit_buckets`).
+		WithArgs(3600.0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO rate_limit_buckets`).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(58.0, true))
+
+	_, err = store.Take(ctx, "read:1.2.3.4", read)
+	testUtil.NoError(t, err)
+	testUtil.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,165 @@
+// Package translit renders Cyrillic text in Latin letters and derives search keys that
+// stay the same whichever way a name was spelled: in Cyrillic, by ISO 9 or informally.
+package translit
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Scheme maps lower-case Cyrillic letters to Latin.
+type Scheme struct {
+	Name    string
+	letters map[rune]string
+}
+
+var (
+	// ISO9 is ISO 9:1995, adopted as GOST 7.79-2000 system A: one Latin letter, with
+	// diacritics, per Cyrillic letter, so it can be reversed.
+	ISO9 = &Scheme{Name: "iso9", letters: map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "ë", 'ж': "ž",
+		'з': "z", 'и': "i", 'й': "j", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+		'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "c",
+		'ч': "č", 'ш': "š", 'щ': "ŝ", 'ъ': "ʺ", 'ы': "y", 'ь': "ʹ", 'э': "è", 'ю': "û",
+		'я': "â", 'і': "ì", 'ї': "ï", 'є': "ê", 'ґ': "g̀", 'ў': "ǔ",
+	}}
+
+	// Informal is the plain ASCII spelling most people type: "Tsoy", "Shchedrin".
+	Informal = &Scheme{Name: "latin", letters: map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+		'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+		'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+		'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+		'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g", 'ў': "u",
+	}}
+)
+
+// Schemes lists the schemes by name.
+var Schemes = map[string]*Scheme{
+	ISO9.Name:     ISO9,
+	Informal.Name: Informal,
+}
+
+// String transliterates the Cyrillic letters in text and keeps everything else. A capital
+// letter becomes a capital Latin one, or capitals throughout in an upper-case word.
+func (s *Scheme) String(text string) string {
+	runes := []rune(text)
+
+	var b strings.Builder
+	b.Grow(len(text))
+	for i, r := range runes {
+		latin, ok := s.letters[unicode.ToLower(r)]
+		switch {
+		case !ok:
+			b.WriteRune(r)
+		case !unicode.IsUpper(r):
+			b.WriteString(latin)
+		case upperWord(runes, i):
+			b.WriteString(strings.ToUpper(latin))
+		default:
+			first := []rune(latin)
+			if len(first) > 0 {
+				first[0] = unicode.ToUpper(first[0])
+			}
+			b.WriteString(string(first))
+		}
+	}
+
+	return b.String()
+}
+
+// upperWord reports whether the capital at i is part of an upper-case word, judging by
+// its neighbours.
+func upperWord(runes []rune, i int) bool {
+	if i+1 < len(runes) && unicode.IsLetter(runes[i+1]) {
+		return unicode.IsUpper(runes[i+1])
+	}
+	return i > 0 && unicode.IsUpper(runes[i-1])
+}
+
+// variants folds the spellings the schemes and informal habits disagree on into one;
+// the longest match wins.
+var variants = map[string]string{
+	"shch": "s", "sch": "s", "shh": "s",
+	"zh": "z", "kh": "h", "ch": "c", "sh": "s", "ts": "c", "tz": "c", "cz": "c",
+	"yu": "u", "ju": "u", "ya": "a", "ja": "a", "yo": "e", "jo": "e", "ye": "e", "je": "e",
+	"y": "i", "j": "i", "w": "v",
+}
+
+const maxVariant = 4
+
+// Key returns the search key of a name: lower-case Latin letters and digits, with words
+// separated by single spaces. "Кино", "Kino" and "KINO" share a key, as do "Цой", "Tsoi"
+// and "Coj". Keys are for matching only; they lose too much to be shown.
+func Key(name string) string {
+	latin := norm.NFD.String(Informal.String(strings.ToLower(name)))
+
+	var clean []rune
+	for _, r := range latin {
+		switch {
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '’', r == 'ʹ', r == 'ʺ':
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			clean = append(clean, r)
+		case len(clean) > 0 && clean[len(clean)-1] != ' ':
+			clean = append(clean, ' ')
+		}
+	}
+
+	var b strings.Builder
+	var last rune
+	for i := 0; i < len(clean); {
+		out, n := string(clean[i]), 1
+		for l := min(maxVariant, len(clean)-i); l > 0; l-- {
+			if v, ok := variants[string(clean[i:i+l])]; ok {
+				out, n = v, l
+				break
+			}
+		}
+		i += n
+
+		for _, r := range out {
+			if r != last || !unicode.IsLetter(r) {
+				b.WriteRune(r)
+			}
+			last = r
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
+
+// Similarity tells how alike two search keys are, ignoring spaces: 1 for the same letters,
+// less by the share of letters to change, down to 0.
+func Similarity(a, b string) float64 {
+	x, y := []rune(strings.ReplaceAll(a, " ", "")), []rune(strings.ReplaceAll(b, " ", ""))
+	longest := max(len(x), len(y))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(distance(x, y))/float64(longest)
+}
+
+// distance is the Levenshtein distance between a and b.
+func distance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,72 @@
+package translit_test
+
+import (
+	"testing"
+
+	"songs/pkg/translit"
+	testUtil "songs/util/test"
+)
+
+func TestScheme(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scheme   *translit.Scheme
+		text     string
+		expected string
+	}{
+		{translit.Informal, "Группа крови на рукаве", "Gruppa krovi na rukave"},
+		{translit.Informal, "Щука и ЩУКА, Ёж", "Shchuka i SHCHUKA, Yozh"},
+		{translit.Informal, "Їжак, Євген", "Yizhak, Yevgen"},
+		{translit.Informal, "Rock'n'roll мёртв", "Rock'n'roll myortv"},
+		{translit.ISO9, "Щука, Цой, Чайф", "Ŝuka, Coj, Čajf"},
+		{translit.ISO9, "объявление, соль", "obʺâvlenie, solʹ"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.scheme.Name+"/"+test.text, func(t *testing.T) {
+			testUtil.Equal(t, test.scheme.String(test.text), test.expected)
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	same := [][]string{
+		{"Кино", "Kino", "KINO", " kino! "},
+		{"Виктор Цой", "Viktor Tsoi", "Viktor Tsoy", "Wiktor Coj"},
+		{"Чайф", "Chaif", "Čajf"},
+		{"Ляпис Трубецкой", "Lyapis Trubetskoy", "Lâpis Trubeckoj"},
+		{"Юрий Шевчук", "Yuriy Shevchuk", "Ûrij Ševčuk"},
+		{"Щука", "Shchuka", "Ŝuka"},
+		{"Жанна", "Zhanna", "Žanna"},
+		{"Мария", "Mariya", "Maria"},
+		{"Соль", "Sol'", "Solʹ"},
+	}
+	for _, names := range same {
+		key := translit.Key(names[0])
+		for _, name := range names[1:] {
+			if got := translit.Key(name); got != key {
+				t.Errorf("Key(%q) = %q, Key(%q) = %q", names[0], key, name, got)
+			}
+		}
+	}
+
+	testUtil.Equal(t, translit.Key("Пачка сигарет"), "packa sigaret")
+	testUtil.Equal(t, translit.Key("Blink-182"), "blink 182")
+	testUtil.Equal(t, translit.Key("2000"), "2000")
+
+	if translit.Key("Кино") == translit.Key("Кармен") {
+		t.Error("different names share a key")
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	t.Parallel()
+
+	testUtil.Equal(t, translit.Similarity("ac dc", "acdc"), 1.0)
+	testUtil.Equal(t, translit.Similarity("nautilus", "nautilis"), 0.875)
+	testUtil.Equal(t, translit.Similarity("", ""), 1.0)
+	testUtil.Equal(t, translit.Similarity("abc", ""), 0.0)
+}
@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+var errUnknownKey = errors.New("unknown signing key")
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// keySet holds the RSA public keys of a JWKS document loaded from a file or URL.
+// URL backed sets are refreshed when stale or when an unknown key ID shows up,
+// but never more often than once per refresh interval.
+type keySet struct {
+	file    string
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu       sync.RWMutex
+	keys     map[string]*rsa.PublicKey
+	loadedAt time.Time
+}
+
+func newKeySet(file, url string, refresh time.Duration) *keySet {
+	return &keySet{
+		file:    file,
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *keySet) key(kid string) (*rsa.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := s.lookup(kid)
+	stale := s.keys == nil || (s.url != "" && time.Since(s.loadedAt) > s.refresh)
+	s.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+
+	if err := s.reload(); err != nil {
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if key, ok := s.lookup(kid); ok {
+		return key, nil
+	}
+
+	return nil, errUnknownKey
+}
+
+// lookup must be called with s.mu held. An empty kid matches a set with a single key.
+func (s *keySet) lookup(kid string) (*rsa.PublicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func (s *keySet) reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.keys != nil && time.Since(s.loadedAt) < s.refresh {
+		return nil
+	}
+
+	data, err := s.fetch()
+	if err != nil {
+		return fmt.Errorf("failed to load jwks: %w", err)
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse jwks: %w", err)
+	}
+
+	s.keys = keys
+	s.loadedAt = time.Now()
+
+	return nil
+}
+
+func (s *keySet) fetch() ([]byte, error) {
+	if s.file != "" {
+		return os.ReadFile(s.file)
+	}
+
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func parseJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set jwkSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid modulus: %w", k.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: invalid exponent: %w", k.Kid, err)
+		}
+
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Config struct {
+	// Secret enables HS256 tokens signed with a shared secret.
+	Secret string
+	// JWKSFile or JWKSURL enable RS256 tokens verified against a JSON Web Key Set.
+	JWKSFile    string
+	JWKSURL     string
+	JWKSRefresh time.Duration
+
+	Issuer   string
+	Audience string
+
+	// RolesClaim is a dot separated path to the claim holding the roles, e.g. "realm_access.roles".
+	RolesClaim string
+	// RoleMap maps claim values to roles. Values missing from it are matched against role names.
+	RoleMap map[string]Role
+}
+
+// Identity is the authenticated caller behind a verified token.
+type Identity struct {
+	Subject string
+	Role    Role
+}
+
+type Verifier struct {
+	config  Config
+	methods []string
+	keys    *keySet
+}
+
+func NewVerifier(c Config) (*Verifier, error) {
+	v := &Verifier{config: c}
+
+	if c.Secret != "" {
+		v.methods = append(v.methods, jwt.SigningMethodHS256.Alg())
+	}
+	if c.JWKSFile != "" || c.JWKSURL != "" {
+		if c.JWKSFile != "" && c.JWKSURL != "" {
+			return nil, errors.New("only one of jwks file and jwks url can be set")
+		}
+		v.methods = append(v.methods, jwt.SigningMethodRS256.Alg())
+		v.keys = newKeySet(c.JWKSFile, c.JWKSURL, c.JWKSRefresh)
+	}
+	if len(v.methods) == 0 {
+		return nil, errors.New("either a secret or a jwks source is required")
+	}
+	if v.config.RolesClaim == "" {
+		v.config.RolesClaim = "roles"
+	}
+
+	return v, nil
+}
+
+// Verify checks the token signature and registered claims and maps it to an Identity.
+func (v *Verifier) Verify(token string) (*Identity, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(v.methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if v.config.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(v.config.Issuer))
+	}
+	if v.config.Audience != "" {
+		opts = append(opts, jwt.WithAudience(v.config.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, v.keyFunc, opts...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	return &Identity{
+		Subject: subject,
+		Role:    v.role(claims),
+	}, nil
+}
+
+func (v *Verifier) keyFunc(t *jwt.Token) (interface{}, error) {
+	switch t.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		return []byte(v.config.Secret), nil
+	case jwt.SigningMethodRS256.Alg():
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.key(kid)
+	default:
+		return nil, fmt.Errorf("unexpected signing method %s", t.Method.Alg())
+	}
+}
+
+// role returns the highest role found in the configured roles claim.
+func (v *Verifier) role(claims jwt.MapClaims) Role {
+	var value interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(v.config.RolesClaim, ".") {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return RoleAnonymous
+		}
+		value = m[part]
+	}
+
+	var names []string
+	switch value := value.(type) {
+	case string:
+		names = strings.Fields(value)
+	case []interface{}:
+		for _, n := range value {
+			if s, ok := n.(string); ok {
+				names = append(names, s)
+			}
+		}
+	}
+
+	best := RoleAnonymous
+	for _, name := range names {
+		role, ok := v.config.RoleMap[name]
+		if !ok {
+			role, _ = ParseRole(name)
+		}
+		if role > best {
+			best = role
+		}
+	}
+
+	return best
+}
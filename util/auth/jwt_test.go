@@ -0,0 +1,161 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"songs/util/auth"
+)
+
+const testSecret = "test-secret"
+
+func TestVerifier_HS256(t *testing.T) {
+	t.Parallel()
+
+	v, err := auth.NewVerifier(auth.Config{
+		Secret:     testSecret,
+		Issuer:     "songs-test",
+		RolesClaim: "realm_access.roles",
+		RoleMap:    map[string]auth.Role{"songs-editor": auth.RoleEditor},
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		valid  bool
+		role   auth.Role
+	}{
+		{
+			name:   "mapped role",
+			claims: jwt.MapClaims{"sub": "alice", "iss": "songs-test", "realm_access": map[string]interface{}{"roles": []string{"offline", "songs-editor"}}},
+			valid:  true,
+			role:   auth.RoleEditor,
+		},
+		{
+			name:   "role by name",
+			claims: jwt.MapClaims{"sub": "bob", "iss": "songs-test", "realm_access": map[string]interface{}{"roles": []string{"viewer", "admin"}}},
+			valid:  true,
+			role:   auth.RoleAdmin,
+		},
+		{
+			name:   "no roles",
+			claims: jwt.MapClaims{"sub": "carol", "iss": "songs-test"},
+			valid:  true,
+			role:   auth.RoleAnonymous,
+		},
+		{
+			name:   "wrong issuer",
+			claims: jwt.MapClaims{"sub": "dave", "iss": "someone-else"},
+		},
+		{
+			name:   "missing subject",
+			claims: jwt.MapClaims{"iss": "songs-test"},
+		},
+		{
+			name:   "expired",
+			claims: jwt.MapClaims{"sub": "erin", "iss": "songs-test", "exp": time.Now().Add(-time.Hour).Unix()},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, ok := tt.claims["exp"]; !ok {
+				tt.claims["exp"] = time.Now().Add(time.Hour).Unix()
+			}
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tt.claims).SignedString([]byte(testSecret))
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			identity, err := v.Verify(token)
+			if !tt.valid {
+				if err == nil {
+					t.Fatalf("expected token to be rejected")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if identity.Subject != tt.claims["sub"] {
+				t.Fatalf("Wrong subject: got %v want %v", identity.Subject, tt.claims["sub"])
+			}
+			if identity.Role != tt.role {
+				t.Fatalf("Wrong role: got %v want %v", identity.Role, tt.role)
+			}
+		})
+	}
+}
+
+func TestVerifier_RS256(t *testing.T) {
+	t.Parallel()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	jwks, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, jwks, 0o600); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	v, err := auth.NewVerifier(auth.Config{JWKSFile: file, JWKSRefresh: time.Hour})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	claims := jwt.MapClaims{"sub": "alice", "roles": "editor", "exp": time.Now().Add(time.Hour).Unix()}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	identity, err := v.Verify(signed)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if identity.Role != auth.RoleEditor {
+		t.Fatalf("Wrong role: got %v want %v", identity.Role, auth.RoleEditor)
+	}
+
+	// HS256 must not be accepted when only RS256 keys are configured.
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	if _, err := v.Verify(forged); err == nil {
+		t.Fatalf("expected HS256 token to be rejected")
+	}
+
+	token.Header["kid"] = "unknown-key"
+	unknown, _ := token.SignedString(key)
+	if _, err := v.Verify(unknown); err == nil {
+		t.Fatalf("expected token with unknown kid to be rejected")
+	}
+}
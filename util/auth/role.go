@@ -0,0 +1,35 @@
+package auth
+
+import "strings"
+
+// Role is an access level. Higher roles include every permission of the lower ones.
+type Role int
+
+const (
+	RoleAnonymous Role = iota
+	RoleViewer
+	RoleEditor
+	RoleAdmin
+)
+
+var roleNames = map[Role]string{
+	RoleAnonymous: "anonymous",
+	RoleViewer:    "viewer",
+	RoleEditor:    "editor",
+	RoleAdmin:     "admin",
+}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+// ParseRole converts a role name into a Role. Unknown names yield RoleAnonymous and false.
+func ParseRole(s string) (Role, bool) {
+	for role, name := range roleNames {
+		if strings.EqualFold(s, name) {
+			return role, true
+		}
+	}
+
+	return RoleAnonymous, false
+}
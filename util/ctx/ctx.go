@@ -2,7 +2,13 @@ package ctx
 
 import "context"
 
-const keyRequestID key = "requestID"
+const (
+	keyRequestID  key = "requestID"
+	keyAPIKeyName key = "apiKeyName"
+	keySubject    key = "subject"
+	keyRole       key = "role"
+	keyLanguage   key = "language"
+)
 
 type key string
 
@@ -15,3 +21,43 @@ func RequestID(ctx context.Context) string {
 func SetRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, keyRequestID, requestID)
 }
+
+func APIKeyName(ctx context.Context) string {
+	name, _ := ctx.Value(keyAPIKeyName).(string)
+
+	return name
+}
+
+func SetAPIKeyName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, keyAPIKeyName, name)
+}
+
+func Subject(ctx context.Context) string {
+	subject, _ := ctx.Value(keySubject).(string)
+
+	return subject
+}
+
+func SetSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, keySubject, subject)
+}
+
+func Role(ctx context.Context) string {
+	role, _ := ctx.Value(keyRole).(string)
+
+	return role
+}
+
+func SetRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, keyRole, role)
+}
+
+func Language(ctx context.Context) string {
+	language, _ := ctx.Value(keyLanguage).(string)
+
+	return language
+}
+
+func SetLanguage(ctx context.Context, language string) context.Context {
+	return context.WithValue(ctx, keyLanguage, language)
+}
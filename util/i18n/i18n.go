@@ -0,0 +1,191 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+)
+
+// DefaultLanguage is used when the client accepts none of the available languages.
+const DefaultLanguage = "en"
+
+// Adding a language only takes a locales/<lang>.json catalogue with the same keys as en.json.
+//
+//go:embed locales/*.json
+var files embed.FS
+
+// rules supplies the go-playground locale rules for languages that have them,
+// catalogues for any other language borrow the rules of the default language.
+var rules = map[string]func() locales.Translator{
+	"en": en.New,
+	"ru": ru.New,
+}
+
+type catalogue struct {
+	Validation map[string]string `json:"validation"`
+	Errors     map[string]string `json:"errors"`
+}
+
+type namedLocale struct {
+	locales.Translator
+	name string
+}
+
+func (l namedLocale) Locale() string {
+	return l.name
+}
+
+var (
+	uni       *ut.UniversalTranslator
+	languages []string
+)
+
+func init() {
+	var err error
+	if uni, languages, err = load(); err != nil {
+		panic(fmt.Sprintf("i18n: %v", err))
+	}
+}
+
+func load() (*ut.UniversalTranslator, []string, error) {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	u := ut.New(rules[DefaultLanguage]())
+
+	var langs []string
+	for _, entry := range entries {
+		lang := strings.TrimSuffix(entry.Name(), path.Ext(entry.Name()))
+
+		data, err := files.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var c catalogue
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		var locale locales.Translator = namedLocale{Translator: rules[DefaultLanguage](), name: lang}
+		if newLocale, ok := rules[lang]; ok {
+			locale = newLocale()
+		}
+		if err := u.AddTranslator(locale, true); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		t, _ := u.GetTranslator(lang)
+		for key, text := range c.Validation {
+			if err := t.Add("validation."+key, text, true); err != nil {
+				return nil, nil, fmt.Errorf("%s: validation.%s: %w", entry.Name(), key, err)
+			}
+		}
+		for key, text := range c.Errors {
+			if err := t.Add("errors."+key, text, true); err != nil {
+				return nil, nil, fmt.Errorf("%s: errors.%s: %w", entry.Name(), key, err)
+			}
+		}
+
+		langs = append(langs, lang)
+	}
+
+	return u, langs, nil
+}
+
+// Languages returns the languages that have a catalogue.
+func Languages() []string {
+	return append([]string(nil), languages...)
+}
+
+// Lookup translates key into lang, falling back to the default language.
+// The boolean is false when neither catalogue has the key.
+func Lookup(lang, key string, params ...string) (string, bool) {
+	for _, l := range []string{lang, DefaultLanguage} {
+		t, found := uni.GetTranslator(l)
+		if !found {
+			continue
+		}
+
+		if text, err := t.T(key, params...); err == nil {
+			return text, true
+		}
+	}
+
+	return "", false
+}
+
+// Validation returns the message for a failed validation tag on field.
+func Validation(lang, tag, field, param string) string {
+	if text, ok := Lookup(lang, "validation."+tag, field, param); ok {
+		return text
+	}
+
+	text, _ := Lookup(lang, "validation.default", field, tag)
+	return text
+}
+
+// Error returns the title of the problem identified by slug, or fallback when it has none.
+func Error(lang, slug, fallback string) string {
+	if text, ok := Lookup(lang, "errors."+slug); ok {
+		return text
+	}
+
+	return fallback
+}
+
+// Negotiate picks the best available language for an Accept-Language header value.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{tag: strings.ToLower(tag), q: q})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	for _, c := range candidates {
+		if c.tag == "*" {
+			return DefaultLanguage
+		}
+
+		primary, _, _ := strings.Cut(c.tag, "-")
+		for _, lang := range languages {
+			if c.tag == lang || primary == lang {
+				return lang
+			}
+		}
+	}
+
+	return DefaultLanguage
+}
@@ -0,0 +1,92 @@
+package i18n_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"songs/util/i18n"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{header: "", expected: "en"},
+		{header: "ru", expected: "ru"},
+		{header: "ru-RU,ru;q=0.9,en-US;q=0.8", expected: "ru"},
+		{header: "de-DE,en;q=0.5,ru;q=0.7", expected: "ru"},
+		{header: "en-GB;q=0.3, ru;q=0", expected: "en"},
+		{header: "fr, *;q=0.1", expected: "en"},
+		{header: "RU", expected: "ru"},
+	}
+
+	for _, tt := range tests {
+		if lang := i18n.Negotiate(tt.header); lang != tt.expected {
+			t.Errorf("Negotiate(%q): got %v want %v", tt.header, lang, tt.expected)
+		}
+	}
+}
+
+// Every catalogue must translate every key of the default one.
+func TestCataloguesComplete(t *testing.T) {
+	t.Parallel()
+
+	files, err := filepath.Glob("locales/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no catalogues found: %v", err)
+	}
+
+	keys := func(file string) map[string]bool {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		var c map[string]map[string]string
+		if err := json.Unmarshal(data, &c); err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+
+		k := map[string]bool{}
+		for section, entries := range c {
+			for key := range entries {
+				k[section+"."+key] = true
+			}
+		}
+		return k
+	}
+
+	expected := keys(filepath.Join("locales", i18n.DefaultLanguage+".json"))
+	for _, file := range files {
+		got := keys(file)
+		for key := range expected {
+			if !got[key] {
+				t.Errorf("%s: missing %s", file, key)
+			}
+		}
+		for key := range got {
+			if !expected[key] {
+				t.Errorf("%s: unexpected %s", file, key)
+			}
+		}
+	}
+}
+
+func TestValidation(t *testing.T) {
+	t.Parallel()
+
+	if msg := i18n.Validation("ru", "required", "group", ""); msg != "group обязательное поле" {
+		t.Errorf("Wrong message: %v", msg)
+	}
+	if msg := i18n.Validation("xx", "max", "group", "255"); msg != "group must be a maximum of 255 in length" {
+		t.Errorf("Wrong fallback message: %v", msg)
+	}
+	if msg := i18n.Validation("en", "no_such_tag", "group", ""); msg != "something wrong on group; no_such_tag" {
+		t.Errorf("Wrong default message: %v", msg)
+	}
+}
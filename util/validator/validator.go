@@ -1,22 +1,32 @@
 package validator
 
 import (
-	"fmt"
 	"reflect"
 	"regexp"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+
+	"songs/util/i18n"
 )
 
 const (
 	alphaSpaceRegexString string = "^[a-zA-Z ]+$"
+	slugRegexString       string = "^[a-z0-9]+(-[a-z0-9]+)*$"
 )
 
+var slugRegex = regexp.MustCompile(slugRegexString)
+
 type ErrResponse struct {
 	Errors []string `json:"errors"`
 }
 
+// InvalidParam describes a single field that failed validation.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
 func New() *validator.Validate {
 	validate := validator.New()
 	validate.SetTagName("form")
@@ -31,38 +41,46 @@ func New() *validator.Validate {
 	})
 
 	validate.RegisterValidation("alpha_space", isAlphaSpace)
+	validate.RegisterValidation("slug", isSlug)
 
 	return validate
 }
 
+// ToErrResponse reports validation errors in the default language.
 func ToErrResponse(err error) *ErrResponse {
+	params := ToInvalidParams(err, i18n.DefaultLanguage)
+	if params == nil {
+		return nil
+	}
+
+	resp := ErrResponse{
+		Errors: make([]string, len(params)),
+	}
+	for i, p := range params {
+		resp.Errors[i] = p.Reason
+	}
+
+	return &resp
+}
+
+// ToInvalidParams reports validation errors with reasons translated into lang.
+func ToInvalidParams(err error, lang string) []InvalidParam {
 	if fieldErrors, ok := err.(validator.ValidationErrors); ok {
-		resp := ErrResponse{
-			Errors: make([]string, len(fieldErrors)),
-		}
+		params := make([]InvalidParam, len(fieldErrors))
 
 		for i, err := range fieldErrors {
-			switch err.Tag() {
-			case "required":
-				resp.Errors[i] = fmt.Sprintf("%s is a required field", err.Field())
-			case "max":
-				resp.Errors[i] = fmt.Sprintf("%s must be a maximum of %s in length", err.Field(), err.Param())
-			case "url":
-				resp.Errors[i] = fmt.Sprintf("%s must be a valid URL", err.Field())
-			case "alpha_space":
-				resp.Errors[i] = fmt.Sprintf("%s can only contain alphabetic and space characters", err.Field())
-			case "datetime":
-				if err.Param() == "2006-01-02" {
-					resp.Errors[i] = fmt.Sprintf("%s must be a valid date", err.Field())
-				} else {
-					resp.Errors[i] = fmt.Sprintf("%s must follow %s format", err.Field(), err.Param())
-				}
-			default:
-				resp.Errors[i] = fmt.Sprintf("something wrong on %s; %s", err.Field(), err.Tag())
+			tag := err.Tag()
+			if tag == "datetime" && err.Param() == "2006-01-02" {
+				tag = "date"
+			}
+
+			params[i] = InvalidParam{
+				Name:   err.Field(),
+				Reason: i18n.Validation(lang, tag, err.Field(), err.Param()),
 			}
 		}
 
-		return &resp
+		return params
 	}
 
 	return nil
@@ -72,3 +90,7 @@ func isAlphaSpace(fl validator.FieldLevel) bool {
 	reg := regexp.MustCompile(alphaSpaceRegexString)
 	return reg.MatchString(fl.Field().String())
 }
+
+func isSlug(fl validator.FieldLevel) bool {
+	return slugRegex.MatchString(fl.Field().String())
+}
@@ -66,3 +66,20 @@ func TestToErrResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestToInvalidParams_Russian(t *testing.T) {
+	t.Parallel()
+
+	vr := validator.New()
+	err := vr.Struct(struct {
+		Date string `json:"date" form:"datetime=2006-01-02"`
+	}{Date: "2020-02-31"})
+
+	params := validator.ToInvalidParams(err, "ru")
+	if len(params) != 1 {
+		t.Fatalf("Expected one invalid param, got %v", params)
+	}
+	if params[0].Name != "date" || params[0].Reason != "date должен быть корректной датой" {
+		t.Fatalf("Wrong invalid param: %+v", params[0])
+	}
+}